@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+// Methods of the JSON-RPC API
+var API_METHODS = []string{"list", "add", "complete", "update"}
+
+// JSON-RPC error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	// The request was valid but the command failed, e.g. the task doesn't exist
+	rpcCommandError = -32000
+)
+
+// A JSON-RPC 2.0 request. Requests without an ID are notifications and get no response
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// A task returned by the API, with its ID
+type APITask struct {
+	ID int
+	Task
+}
+
+// Decode a task along with its ID, which Task's UnmarshalJSON would leave out if it were
+// promoted
+func (t *APITask) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &t.Task); err != nil {
+		return err
+	}
+	var key struct{ ID int }
+	if err := json.Unmarshal(b, &key); err != nil {
+		return err
+	}
+	t.ID = key.ID
+	return nil
+}
+
+// Parameters of the API methods. Pointer fields are left unchanged by update when missing
+type apiParams struct {
+	// Task to complete or update
+	ID int `json:"id"`
+	// Filter terms selecting the tasks to list, such as "+work priority:high"
+	Filter      string    `json:"filter"`
+	Description *string   `json:"description"`
+	Tags        *[]string `json:"tags"`
+	// A date as accepted by `add --due`, empty to clear it
+	Due      *string `json:"due"`
+	Priority *string `json:"priority"`
+	Status   *string `json:"status"`
+	Points   *int    `json:"points"`
+}
+
+func newAPICmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	aCmd := &cobra.Command{
+		Use:          "api --stdio",
+		Short:        tr("Serve a JSON-RPC API for editor plugins"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !APIStdio {
+				return errors.New(tr("Must use --stdio, the API is only served over stdin and stdout"))
+			}
+			// The daemon serves the requests sent by the CLI with its db open
+			if mgr.db != nil {
+				return serveAPI(cmd.InOrStdin(), out, func(run func(*bolt.DB) error) error {
+					return run(mgr.db)
+				})
+			}
+			// The db is only opened while handling a request, so the CLI can be used while an
+			// editor keeps the API running
+			withDB := func(run func(*bolt.DB) error) error {
+				if err := mgr.Open(false); err != nil {
+					return err
+				}
+				defer mgr.Close()
+				return run(mgr.db)
+			}
+			sc := bufio.NewScanner(cmd.InOrStdin())
+			sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+			for sc.Scan() {
+				// A running daemon holds the db, so it handles the request instead
+				if _, ok := runOnDaemon([]string{"api", "--stdio"}, strings.NewReader(sc.Text()), out); ok {
+					continue
+				}
+				if err := serveAPI(strings.NewReader(sc.Text()), out, withDB); err != nil {
+					return err
+				}
+			}
+			return sc.Err()
+		},
+	}
+	aCmd.Flags().BoolVar(&APIStdio, "stdio", false, "Read requests from stdin and write responses to stdout, one JSON object per line")
+	return aCmd
+}
+
+// Handle the JSON-RPC requests in `in`, one per line, until it ends, writing each response to
+// `out` on its own line. `withDB` runs a request against the database
+func serveAPI(in io.Reader, out io.Writer, withDB func(func(*bolt.DB) error) error) error {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(out)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, err.Error()}})
+			continue
+		}
+
+		var result any
+		err := withDB(func(db *bolt.DB) error {
+			var err error
+			result, err = handleAPIRequest(db, req)
+			return err
+		})
+		if len(req.ID) == 0 {
+			continue
+		}
+		res := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+		if err != nil {
+			var rpcErr *rpcError
+			if !errors.As(err, &rpcErr) {
+				rpcErr = &rpcError{rpcCommandError, strings.TrimSpace(err.Error())}
+			}
+			res.Result, res.Error = nil, rpcErr
+		}
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Run the API method `req` asks for against `db`, returning its result
+func handleAPIRequest(db *bolt.DB, req rpcRequest) (any, error) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &rpcError{rpcInvalidRequest, tr(`Invalid request, must have "jsonrpc": "2.0" and a method`)}
+	}
+	if !slices.Contains(API_METHODS, req.Method) {
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf(tr(`Unknown method "%s", must be one of %s`), req.Method, strings.Join(API_METHODS, ", "))}
+	}
+	var p apiParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+	}
+	now := time.Now()
+
+	switch req.Method {
+	case "list":
+		filter, err := parseFilter(strings.Fields(p.Filter), now)
+		if err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		tasks := []APITask{}
+		for _, t := range filter.Apply(getTasks(db, TASKS_BUCKET)) {
+			tasks = append(tasks, APITask{t.dbKey, t.task})
+		}
+		return tasks, nil
+	case "add":
+		if p.Description == nil || strings.TrimSpace(*p.Description) == "" {
+			return nil, &rpcError{rpcInvalidParams, tr("Must provide a task description")}
+		}
+		t := newTask(strings.TrimSpace(*p.Description), nil)
+		t.UUID = newUUID()
+		if err := applyAPIParams(&t, p, now); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		if err := insertTasks(db, TASKS_BUCKET, []Task{t}); err != nil {
+			return nil, err
+		}
+		for _, added := range getTasks(db, TASKS_BUCKET) {
+			if added.task.UUID == t.UUID {
+				return APITask{added.dbKey, added.task}, nil
+			}
+		}
+		return nil, errors.New(tr("Could not find the added task"))
+	case "complete":
+		if _, err := completeTasks(db, []int{p.ID}, false); err != nil {
+			return nil, err
+		}
+	case "update":
+		if p.Description != nil && strings.TrimSpace(*p.Description) == "" {
+			return nil, &rpcError{rpcInvalidParams, tr("Must provide a task description")}
+		}
+		err := updateTasks(db, []int{p.ID}, func(t *Task) error {
+			return applyAPIParams(t, p, now)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	t, err := getTask(db, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf(tr("Task %d does not exist"), p.ID)
+	}
+	return APITask{p.ID, t}, nil
+}
+
+// Set the fields of `t` given in `p`. Dates are relative to `now`
+func applyAPIParams(t *Task, p apiParams, now time.Time) error {
+	if p.Description != nil {
+		t.Desc = strings.TrimSpace(*p.Description)
+	}
+	if p.Tags != nil {
+		t.Tags = nil
+		for _, tag := range *p.Tags {
+			if tag = strings.TrimPrefix(strings.TrimSpace(tag), "+"); tag != "" && !slices.Contains(t.Tags, tag) {
+				t.Tags = append(t.Tags, tag)
+			}
+		}
+	}
+	if p.Due != nil {
+		t.Due = ""
+		if *p.Due != "" {
+			due, err := parseDate(*p.Due, now)
+			if err != nil {
+				return err
+			}
+			t.Due = due.Format(RFC3339)
+		}
+	}
+	if p.Priority != nil {
+		priority, err := parsePriority(*p.Priority)
+		if err != nil {
+			return err
+		}
+		t.Priority = priority
+	}
+	if p.Points != nil {
+		if *p.Points < 0 {
+			return errors.New(tr("Points can't be negative"))
+		}
+		t.Points = *p.Points
+	}
+	if p.Status != nil && *p.Status != "" {
+		name, err := parseStatus(*p.Status)
+		if err != nil {
+			return err
+		}
+		to, _ := config.status(name)
+		if err := canMove(*t, to); err != nil {
+			return err
+		}
+		setTaskStatus(t, to, now)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// Limits on the archive, the archive section of the config file. `finish` deletes the tasks
+// past them from the archive
+type ArchiveConfig struct {
+	// Delete tasks completed longer ago than this period, such as "180d", "26w" or "6m". Tasks
+	// are kept forever if empty
+	MaxAge string `json:"max_age,omitempty"`
+	// Keep at most this many tasks, deleting the ones archived first. No limit if 0
+	MaxEntries int `json:"max_entries,omitempty"`
+}
+
+// Returns an error if the period or the number of entries of `a` is invalid
+func (a ArchiveConfig) validate() error {
+	if a.MaxAge != "" {
+		if _, err := parseAgo(a.MaxAge, time.Now()); err != nil {
+			return fmt.Errorf("archive: %v", err)
+		}
+	}
+	if a.MaxEntries < 0 {
+		return errors.New(tr("archive: max_entries must be 0 or more"))
+	}
+	return nil
+}
+
+// Delete the tasks of the archive past the limits of `a` as of `now`: tasks completed, or
+// created if they have no completion time, before max_age, then the tasks archived first
+// beyond max_entries. Returns the number of deleted tasks
+func pruneArchive(db *bolt.DB, a ArchiveConfig, now time.Time) (int, error) {
+	if a.MaxAge == "" && a.MaxEntries == 0 {
+		return 0, nil
+	}
+	var cutoff time.Time
+	if a.MaxAge != "" {
+		var err error
+		if cutoff, err = parseAgo(a.MaxAge, now); err != nil {
+			return 0, err
+		}
+	}
+
+	var keys, kept []int
+	for _, t := range getTasks(db, ARCHIVE_BUCKET) {
+		ts := t.task.Completed
+		if ts == "" {
+			ts = t.task.Created
+		}
+		if at, err := parseTimestamp(ts); err == nil && at.Before(cutoff) {
+			keys = append(keys, t.dbKey)
+		} else {
+			kept = append(kept, t.dbKey)
+		}
+	}
+	if a.MaxEntries > 0 && len(kept) > a.MaxEntries {
+		keys = append(keys, kept[:len(kept)-a.MaxEntries]...)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return len(keys), deleteKeys(keys, db, ARCHIVE_BUCKET)
+}
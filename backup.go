@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+// Layout of the timestamp in the names of backups, sorting them in time order
+const backupLayout = "20060102-150405"
+
+func newBackupCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	bCmd := &cobra.Command{
+		Use:          "backup -[k]",
+		Short:        tr("Save a copy of the database in the backups directory"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if BackupKeep < 0 {
+				return fmt.Errorf(tr("Invalid number of backups %d, must be 0 or more"), BackupKeep)
+			}
+			dir, err := taskDir()
+			if err != nil {
+				return err
+			}
+			path, err := writeBackup(mgr.db, backupDir(dir), time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Saved a backup to %s\n"), path)
+
+			deleted, err := pruneBackups(backupDir(dir), BackupKeep)
+			if err != nil {
+				return err
+			}
+			if len(deleted) > 0 {
+				fmt.Fprintf(out, tr("Deleted %d old backups\n"), len(deleted))
+			}
+			return nil
+		},
+	}
+	bCmd.Flags().IntVarP(&BackupKeep, "keep", "k", 10, "Number of backups to keep, older ones are deleted. 0 keeps every backup")
+	return bCmd
+}
+
+// Returns the directory holding the backups of the database in `dir`
+func backupDir(dir string) string {
+	return filepath.Join(dir, "backups")
+}
+
+// Copy `db` to a file in `dir` named after `now`. The copy is made in a read transaction, so
+// it's consistent even while other commands write. Returns the path of the copy
+func writeBackup(db *bolt.DB, dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "tasks-"+now.Format(backupLayout)+".db")
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0600)
+	})
+	return path, err
+}
+
+// Delete all but the newest `keep` backups in `dir`. A `keep` of 0 keeps every backup.
+// Returns the paths of the deleted backups
+func pruneBackups(dir string, keep int) ([]string, error) {
+	if keep == 0 {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "tasks-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	// Newest first
+	slices.SortFunc(paths, func(a, b string) int { return strings.Compare(b, a) })
+	if len(paths) <= keep {
+		return nil, nil
+	}
+	var deleted []string
+	for _, path := range paths[keep:] {
+		if err := os.Remove(path); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, path)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+// Operations of `batch`
+var BATCH_OPS = []string{"add", "update", "complete", "delete"}
+
+// A line of `batch`. Takes the parameters of the API methods, with "desc" as a shorter
+// "description"
+type batchOp struct {
+	Op string `json:"op"`
+	apiParams
+	Desc *string `json:"desc"`
+}
+
+// The result of a line of `batch`: the ID of the task it changed, or why it failed
+type batchResult struct {
+	Line  int    `json:"line"`
+	Op    string `json:"op"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func newBatchCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "batch [file]",
+		Short:        tr("Apply the changes in a file or stdin, one JSON object per line, all at once"),
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			results, err := runBatch(mgr.db, in, time.Now())
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(out)
+			failed := 0
+			for _, r := range results {
+				if r.Error != "" {
+					failed++
+				}
+				enc.Encode(r)
+			}
+			if failed > 0 {
+				return fmt.Errorf(tr("%d of %d operations failed, nothing was changed"), failed, len(results))
+			}
+			return nil
+		},
+	}
+}
+
+// Apply the operations in `in`, one JSON object per line, in a single transaction of `db`.
+// Every line is run and gets a result, but nothing is saved if any line fails. Tasks are
+// deleted once the other operations are done, so the IDs of a batch are the IDs the tasks
+// have before it, and new tasks get the next IDs. The IDs of the results are those the
+// tasks have after the batch
+func runBatch(db *bolt.DB, in io.Reader, now time.Time) ([]batchResult, error) {
+	var results []batchResult
+	// UUIDs of the changed tasks by result, to find their IDs once the deletions are done
+	uuids := map[int]string{}
+	failed := errors.New("failed")
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(TASKS_BUCKET)
+		if err != nil {
+			return err
+		}
+		var deleted []int
+		sc := bufio.NewScanner(in)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for n := 1; sc.Scan(); n++ {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			r := batchResult{Line: n}
+			var op batchOp
+			if err := json.Unmarshal([]byte(line), &op); err != nil {
+				r.Error = err.Error()
+				results = append(results, r)
+				continue
+			}
+			r.Op, r.ID = op.Op, op.ID
+			if op.Desc != nil && op.Description == nil {
+				op.Description = op.Desc
+			}
+			uuid, err := applyBatchOp(b, op, now)
+			if err == nil && op.Op == "delete" {
+				if slices.Contains(deleted, op.ID) {
+					err = fmt.Errorf(tr("Task %d is deleted twice"), op.ID)
+				}
+				deleted = append(deleted, op.ID)
+			}
+			if err != nil {
+				r.Error = strings.TrimSpace(err.Error())
+			} else if uuid != "" {
+				uuids[len(results)] = uuid
+			}
+			results = append(results, r)
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
+		if slices.ContainsFunc(results, func(r batchResult) bool { return r.Error != "" }) {
+			return failed
+		}
+
+		if len(deleted) > 0 {
+			if err := deleteKeysTx(tx, deleted, TASKS_BUCKET); err != nil {
+				return err
+			}
+			b = tx.Bucket(TASKS_BUCKET)
+		}
+		keys := map[string]int{}
+		b.ForEach(func(k, v []byte) error {
+			keys[bToTask(v).UUID] = btoi(k)
+			return nil
+		})
+		for i, uuid := range uuids {
+			results[i].ID = keys[uuid]
+		}
+		return nil
+	})
+	if err != nil && err != failed {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Apply `op` to the tasks bucket `b`. Returns the UUID of the task added, updated or
+// completed. Deletions only check that the task exists, leaving the deletion to runBatch
+func applyBatchOp(b *bolt.Bucket, op batchOp, now time.Time) (string, error) {
+	if !slices.Contains(BATCH_OPS, op.Op) {
+		return "", fmt.Errorf(tr(`Unknown op "%s", must be one of %s`), op.Op, strings.Join(BATCH_OPS, ", "))
+	}
+	if op.Op == "add" {
+		if op.Description == nil || strings.TrimSpace(*op.Description) == "" {
+			return "", errors.New(tr("Must provide a task description"))
+		}
+		t := newTask(strings.TrimSpace(*op.Description), nil)
+		t.UUID = newUUID()
+		if err := applyAPIParams(&t, op.apiParams, now); err != nil {
+			return "", err
+		}
+		// new tasks are displayed last
+		b.ForEach(func(k, v []byte) error {
+			t.Order = max(t.Order, bToTask(v).Order)
+			return nil
+		})
+		t.Order++
+		id, _ := b.NextSequence()
+		buf, err := json.Marshal(t)
+		if err != nil {
+			return "", err
+		}
+		return t.UUID, b.Put(itob(int(id)), buf)
+	}
+
+	v := b.Get(itob(op.ID))
+	if v == nil {
+		return "", fmt.Errorf(tr("Task %d does not exist"), op.ID)
+	}
+	t := bToTask(v)
+	old := snapshot(t)
+	switch op.Op {
+	case "delete":
+		return "", nil
+	case "complete":
+		if !isDone(t) {
+			complete, _ := config.status(STATUS.COMPLETE)
+			setTaskStatus(&t, complete, now)
+		}
+	case "update":
+		if op.Description != nil && strings.TrimSpace(*op.Description) == "" {
+			return "", errors.New(tr("Must provide a task description"))
+		}
+		if err := applyAPIParams(&t, op.apiParams, now); err != nil {
+			return "", err
+		}
+	}
+	recordChanges(old, &t, now)
+	// Tasks from before UUIDs get one so their ID can be found after the deletions
+	if t.UUID == "" {
+		t.UUID = newUUID()
+	}
+	buf, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return t.UUID, b.Put(itob(op.ID), buf)
+}
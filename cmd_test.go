@@ -2,11 +2,17 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
+	"net/smtp"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +20,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+func TestMain(m *testing.M) {
+	// Assert on English messages regardless of the language of the environment
+	locale = "en"
+	// Assert on plain output even when the tests run in a terminal
+	colorOutput = func() bool { return false }
+	os.Exit(m.Run())
+}
+
 func TestUpdateCmdInput(t *testing.T) {
 	db, path := setup()
 	defer teardown(db, path)
@@ -26,7 +40,7 @@ func TestUpdateCmdInput(t *testing.T) {
 		errMsg string
 	}{
 		{"Empty input", []string{}, "Failed to error when no arguments are passed"},
-		{"Multiple inputs", []string{"1", "2"}, "Failed to error when more than 1 argument is passed"},
+		{"Multiple inputs, one out of range", []string{"1", "10", "-s"}, "Failed to error when one of the IDs is out of range"},
 		{"Non-ASCII int", []string{"a"}, "Failed to error when argument is not an ASCII int"},
 		{"ID Out of range", []string{"10"}, "Failed to error when ID is out of range"},
 		{"ID is 0", []string{"0"}, "Failed to error when ID is 0"},
@@ -62,6 +76,7 @@ func TestUpdateCmdFlags(t *testing.T) {
 		{"-d no tag", []string{"1", "-d=updated"}, "updated", STATUS.INCOMPLETE, "", false},
 		{"-d with tag", []string{"1", "-d=tagged +test"}, "tagged", STATUS.INCOMPLETE, "test", false},
 		{"-d and -s with tag", []string{"1", "-d=triple +tres", "-s"}, "triple", STATUS.COMPLETE, "tres", false},
+		{"-d with multiple tags", []string{"1", "-d=multi +uno +dos"}, "multi", STATUS.INCOMPLETE, "uno,dos", false},
 		{"No flag used", []string{"1"}, "", "", "", true},
 		{"Empty -d flag", []string{"1", "-d=+fail"}, "", "", "", true},
 	}
@@ -70,10 +85,10 @@ func TestUpdateCmdFlags(t *testing.T) {
 		// avoid lingering values while looping through cmd executions
 		resetGlobals()
 		// reset the task for each run
-		updateTask(db, 1, Task{"initial", STATUS.INCOMPLETE, "2006-01-02T15:04:05Z07:00", "", ""})
+		updateTask(db, 1, Task{Desc: "initial", Status: STATUS.INCOMPLETE, Created: "2006-01-02T15:04:05Z07:00"})
 		// to test -s in reverse, set the intial status to completed
 		if num == 1 {
-			updateTask(db, 1, Task{"initial", STATUS.COMPLETE, "2006-01-02T15:04:05Z07:00", "", ""})
+			updateTask(db, 1, Task{Desc: "initial", Status: STATUS.COMPLETE, Created: "2006-01-02T15:04:05Z07:00"})
 		}
 
 		t.Run(tc.name, func(t *testing.T) {
@@ -94,14 +109,14 @@ func TestUpdateCmdFlags(t *testing.T) {
 				t.Fatalf("Failed to retrieve task: %v", err)
 			}
 
-			if task.Desc != tc.expectedDesc || task.Status != tc.expectedStatus || task.Tag != tc.expectedTag {
+			if task.Desc != tc.expectedDesc || task.Status != tc.expectedStatus || strings.Join(task.Tags, ",") != tc.expectedTag {
 				expected := fmt.Sprintf(
 					"Description:%s, Status:%s, Tag:%s",
 					tc.expectedDesc, tc.expectedStatus, tc.expectedTag,
 				)
 				actual := fmt.Sprintf(
 					"Description:%s, Status:%s, Tag:%s",
-					task.Desc, task.Status, task.Tag,
+					task.Desc, task.Status, strings.Join(task.Tags, ","),
 				)
 				t.Fatalf("\nExpected: %s\nActual: %s", expected, actual)
 			}
@@ -109,6 +124,31 @@ func TestUpdateCmdFlags(t *testing.T) {
 	}
 }
 
+func TestAddCmdClipboard(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	aCmd, _ := setupCmd(newAddCmd, db)
+	resetGlobals()
+
+	readClipboard = func() (string, error) {
+		return "  call the dentist\n", nil
+	}
+
+	aCmd.SetArgs([]string{"-c", "+health"})
+	if err := aCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	task, err := getTask(db, 1)
+	if err != nil {
+		t.Fatalf("Failed to retrieve task: %v", err)
+	}
+	if task.Desc != "call the dentist" || !reflect.DeepEqual(task.Tags, []string{"health"}) {
+		t.Fatalf("Expected clipboard task tagged health, Got %+v", task)
+	}
+}
+
 func TestInsert(t *testing.T) {
 	db, path := setup()
 	defer teardown(db, path)
@@ -116,7 +156,7 @@ func TestInsert(t *testing.T) {
 	strs := []string{"test", "prueba", "tesuto", "hoao"}
 	expected := len(strs)
 	for _, s := range strs {
-		if err := insert(db, TASKS_BUCKET, s, ""); err != nil {
+		if err := insert(db, TASKS_BUCKET, s, nil); err != nil {
 			t.Fatalf("Failed to insert into db: %v", err)
 		}
 	}
@@ -136,7 +176,7 @@ func TestGetCount(t *testing.T) {
 	count := 0
 
 	for _, s := range strs {
-		if err := insert(db, TASKS_BUCKET, s, ""); err != nil {
+		if err := insert(db, TASKS_BUCKET, s, nil); err != nil {
 			t.Fatalf("Failed to insert into db: %v", err)
 		}
 	}
@@ -171,7 +211,7 @@ func TestDeleteTask(t *testing.T) {
 	expected := len(strs) - len(removeKeys)
 
 	for _, s := range strs {
-		err := insert(db, TASKS_BUCKET, s, "")
+		err := insert(db, TASKS_BUCKET, s, nil)
 		if err != nil {
 			t.Fatalf("Failed to insert into db: %v", err)
 		}
@@ -202,7 +242,7 @@ func TestDeleteMultipleTasks(t *testing.T) {
 	expected := []string{"b", "d", "f"}
 
 	for _, s := range strs {
-		err := insert(db, TASKS_BUCKET, s, "")
+		err := insert(db, TASKS_BUCKET, s, nil)
 		if err != nil {
 			t.Fatalf("Failed to insert into db: %v", err)
 		}
@@ -245,14 +285,14 @@ func TestCompleteTask(t *testing.T) {
 	var count int
 
 	for _, s := range strs {
-		err := insert(db, TASKS_BUCKET, s, "")
+		err := insert(db, TASKS_BUCKET, s, nil)
 		if err != nil {
 			t.Fatalf("Failed to insert into db: %v", err)
 		}
 	}
 
 	for _, id := range complete {
-		completeTask(id, db)
+		completeTask(id, db, io.Discard)
 	}
 
 	db.View(func(tx *bolt.Tx) error {
@@ -271,6 +311,204 @@ func TestCompleteTask(t *testing.T) {
 	}
 }
 
+func TestUpdateCmdTags(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+
+	var tests = []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"1", "-t=+new,second"}, "old,new,second"},
+		{[]string{"1", "-t=old"}, "old"},
+		{[]string{"1", "-u=old"}, ""},
+		{[]string{"1", "-t=new", "-u=old,missing"}, "new"},
+		{[]string{"1", "-d=retyped +typed", "-t=added"}, "typed,added"},
+	}
+	for _, tt := range tests {
+		resetGlobals()
+		resetTasks(db)
+		insert(db, TASKS_BUCKET, "initial", []string{"old"})
+
+		uCmd.SetArgs(tt.args)
+		if err := uCmd.Execute(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		task, _ := getTask(db, 1)
+		if got := strings.Join(task.Tags, ","); got != tt.expected {
+			t.Errorf("%v: Expected tags %q, Got %q", tt.args, tt.expected, got)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	// 01/15/2025 was a Wednesday
+	now := time.Date(2025, 1, 15, 18, 30, 0, 0, time.UTC)
+
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{"05/31/2025", "2025-05-31"},
+		{"2025-05-31", "2025-05-31"},
+		{"today", "2025-01-15"},
+		{"Tomorrow", "2025-01-16"},
+		{"yesterday", "2025-01-14"},
+		{"friday", "2025-01-17"},
+		{"next fri", "2025-01-17"},
+		{"wednesday", "2025-01-22"},
+		{"mon", "2025-01-20"},
+		{"in 3 days", "2025-01-18"},
+		{"in 1 week", "2025-01-22"},
+		{"in 2 months", "2025-03-15"},
+		{"eow", "2025-01-19"},
+		{"EOM", "2025-01-31"},
+		{"someday", ""},
+		{"13/01/2025", ""},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.input, now)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("%q: Expected an error, Got %v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got.Format("2006-01-02 15:04") != tt.expected+" 00:00" {
+			t.Errorf("%q: Expected %s, Got %v (%v)", tt.input, tt.expected, got, err)
+		}
+	}
+}
+
+func TestUpdateCmdDue(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+	resetTasks(db)
+
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+	insert(db, TASKS_BUCKET, "initial", nil)
+
+	resetGlobals()
+	uCmd.SetArgs([]string{"1", "--due=05/31/2025"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	task, _ := getTask(db, 1)
+	if got := formatTimestamp(task.Due, "01/02/2006"); got != "05/31/2025" {
+		t.Fatalf("Expected the task to be due on 05/31/2025, Got %s", got)
+	}
+
+	resetGlobals()
+	uCmd.SetArgs([]string{"1", "--due=whenever"})
+	if err := uCmd.Execute(); err == nil {
+		t.Fatalf("Failed to error on an invalid date")
+	}
+
+	resetGlobals()
+	uCmd.SetArgs([]string{"1", "--no-due"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task, _ := getTask(db, 1); task.Due != "" {
+		t.Fatalf("Expected the due date to be removed, Got %s", task.Due)
+	}
+}
+
+func TestUpdateCmdPriority(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+	resetTasks(db)
+
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+	insert(db, TASKS_BUCKET, "initial", nil)
+
+	var tests = []struct {
+		priority    string
+		expected    string
+		expectError bool
+	}{
+		{"high", "high", false},
+		{"Medium", "med", false},
+		{"urgent", "med", true},
+		{"none", "", false},
+	}
+	for _, tt := range tests {
+		resetGlobals()
+		uCmd.SetArgs([]string{"1", "--priority=" + tt.priority})
+		err := uCmd.Execute()
+		if (err != nil) != tt.expectError {
+			t.Fatalf("%s: Unexpected error: %v", tt.priority, err)
+		}
+		if task, _ := getTask(db, 1); task.Priority != tt.expected {
+			t.Errorf("%s: Expected priority %q, Got %q", tt.priority, tt.expected, task.Priority)
+		}
+	}
+}
+
+func TestAppendPrependCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	aCmd, _ := setupCmd(newAppendCmd, db)
+	pCmd, _ := setupCmd(newPrependCmd, db)
+	insert(db, TASKS_BUCKET, "write report", []string{"work"})
+
+	var tests = []struct {
+		cmd          *cobra.Command
+		args         []string
+		expectedDesc string
+		expectedTags string
+		expectError  bool
+	}{
+		{aCmd, []string{"1", "for", "Q2"}, "write report for Q2", "work", false},
+		{pCmd, []string{"1", "URGENT:"}, "URGENT: write report for Q2", "work", false},
+		{aCmd, []string{"1", "draft +review"}, "URGENT: write report for Q2 draft", "work,review", false},
+		{aCmd, []string{"1"}, "URGENT: write report for Q2 draft", "work,review", true},
+		{pCmd, []string{"5", "nope"}, "URGENT: write report for Q2 draft", "work,review", true},
+	}
+	for _, tt := range tests {
+		tt.cmd.SetArgs(tt.args)
+		if err := tt.cmd.Execute(); (err != nil) != tt.expectError {
+			t.Fatalf("%s %v: Unexpected error: %v", tt.cmd.Name(), tt.args, err)
+		}
+		task, _ := getTask(db, 1)
+		if task.Desc != tt.expectedDesc || strings.Join(task.Tags, ",") != tt.expectedTags {
+			t.Errorf("%s %v: Expected %q %s, Got %q %v", tt.cmd.Name(), tt.args, tt.expectedDesc, tt.expectedTags, task.Desc, task.Tags)
+		}
+	}
+}
+
+func TestUpdateCmdMultiple(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+	resetTasks(db)
+
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil), newTask("c", nil)})
+
+	resetGlobals()
+	uCmd.SetArgs([]string{"1", "3", "1", "-s"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var statuses []string
+	for _, tp := range getTasks(db, TASKS_BUCKET) {
+		statuses = append(statuses, tp.task.Status)
+	}
+	expected := []string{STATUS.COMPLETE, STATUS.INCOMPLETE, STATUS.COMPLETE}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Fatalf("Expected %v, Got %v", expected, statuses)
+	}
+}
+
 func TestDoCmdInput(t *testing.T) {
 	db, path := setup()
 	defer teardown(db, path)
@@ -299,6 +537,39 @@ func TestDoCmdInput(t *testing.T) {
 	}
 }
 
+func TestDoAutoFinish(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	resetArchive(db)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil)})
+
+	config.Do.AutoFinish = true
+	doCmd, _ := setupCmd(newDoCmd, db)
+	doCmd.SetArgs([]string{"1"})
+	if err := doCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tasks := getTasks(db, TASKS_BUCKET); len(tasks) != 1 || tasks[0].task.Desc != "b" {
+		t.Fatalf("Expected task a to be finished, Got %v", tasks)
+	}
+
+	// -f=false keeps the task in the list
+	doCmd, _ = setupCmd(newDoCmd, db)
+	doCmd.SetArgs([]string{"1", "-f=false"})
+	if err := doCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tasks := getTasks(db, TASKS_BUCKET); len(tasks) != 1 || tasks[0].task.Status != STATUS.COMPLETE {
+		t.Fatalf("Expected task b to be completed and kept, Got %v", tasks)
+	}
+	if archived := getTasks(db, ARCHIVE_BUCKET); len(archived) != 1 {
+		t.Fatalf("Expected 1 archived task, Got %d", len(archived))
+	}
+}
+
 func TestDoCmdFLags(t *testing.T) {
 	db, path := setup()
 	defer teardown(db, path)
@@ -316,6 +587,8 @@ func TestDoCmdFLags(t *testing.T) {
 		// Keys are 1 indexed -> task 1 corresponds to  strs[0]
 		{"-f works", []string{"1", "-f"}, len(strs) - 1, []string{strs[0]}},
 		{"-f with multiple IDs", []string{"1", "2", "-f"}, len(strs) - 2, []string{strs[0], strs[1]}},
+		{"-a and -f", []string{"-a", "-f"}, 0, strs},
+		{"-t and -f", []string{"-t=odd", "-f"}, len(strs) - 2, []string{strs[0], strs[2]}},
 	}
 
 	for _, tc := range input {
@@ -323,9 +596,13 @@ func TestDoCmdFLags(t *testing.T) {
 		resetGlobals()
 		resetArchive(db)
 		resetTasks(db)
-		// insert the default tasks
-		for _, s := range strs {
-			insert(db, TASKS_BUCKET, s, "")
+		// insert the default tasks, tagging every other task
+		for i, s := range strs {
+			var tags []string
+			if i%2 == 0 {
+				tags = []string{"odd"}
+			}
+			insert(db, TASKS_BUCKET, s, tags)
 		}
 
 		doCmd.SetArgs(tc.input)
@@ -354,6 +631,57 @@ func TestDoCmdFLags(t *testing.T) {
 	}
 }
 
+func TestDoCmdAtomic(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+
+	doCmd, _ := setupCmd(newDoCmd, db)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil)})
+
+	// task 5 doesn't exist, so task 1 must not be completed or archived either
+	doCmd.SetArgs([]string{"1", "5", "-f"})
+	if err := doCmd.Execute(); err == nil {
+		t.Fatalf("Failed to error on a missing task")
+	}
+	tasks := getTasks(db, TASKS_BUCKET)
+	if len(tasks) != 2 || tasks[0].task.Status != STATUS.INCOMPLETE {
+		t.Fatalf("Expected both tasks to be left incomplete, Got %+v", tasks)
+	}
+	if archive := getTasks(db, ARCHIVE_BUCKET); len(archive) != 0 {
+		t.Fatalf("Expected an empty archive, Got %d tasks", len(archive))
+	}
+
+	doCmd.SetArgs([]string{"2", "1", "2", "-f"})
+	if err := doCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	archive := getTasks(db, ARCHIVE_BUCKET)
+	if len(archive) != 2 || archive[0].task.Desc != "b" || archive[1].task.Desc != "a" {
+		t.Fatalf("Expected b and a to be archived once each, Got %+v", archive)
+	}
+}
+
+func TestDoCmdAlreadyDone(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil)})
+	completeTask(1, db, io.Discard)
+
+	// Only the task that wasn't done yet is reported as completed
+	doCmd, buf := setupCmd(newDoCmd, db)
+	doCmd.SetArgs([]string{"1", "2", "2"})
+	if err := doCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "You already finished task 1\n") || strings.Contains(out, "Completed task 1\n") || strings.Count(out, "Completed task 2\n") != 1 {
+		t.Fatalf("Expected task 1 already finished and task 2 completed once, Got %q", out)
+	}
+}
+
 func TestFinish(t *testing.T) {
 	db, path := setup()
 	defer teardown(db, path)
@@ -364,17 +692,17 @@ func TestFinish(t *testing.T) {
 	expectedArchive := []string{"b", "c"}
 
 	for _, s := range strs {
-		err := insert(db, TASKS_BUCKET, s, "")
+		err := insert(db, TASKS_BUCKET, s, nil)
 		if err != nil {
 			t.Fatalf("Failed to insert into db: %v", err)
 		}
 	}
 
 	for _, id := range complete {
-		completeTask(id, db)
+		completeTask(id, db, io.Discard)
 	}
 
-	finish(db)
+	finish(db, "")
 
 	// make sure correct tasks were deleted & deleted tasks were added to archive
 	var result []string
@@ -405,119 +733,4651 @@ func TestFinish(t *testing.T) {
 	}
 }
 
-func TestFormatTasks(t *testing.T) {
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	c, err := loadConfig(dir)
+	if err != nil || !reflect.DeepEqual(c, defaultConfig()) {
+		t.Fatalf("Expected the default config without a config file, Got %v (%v)", c, err)
+	}
+
+	var tests = []struct {
+		name   string
+		config string
+		err    bool
+	}{
+		{"Custom statuses", `{"statuses": [{"name": "incomplete", "icon": "o", "next": ["done"]}, {"name": "complete", "icon": "x", "aliases": ["done"], "done": true}]}`, false},
+		{"Missing complete", `{"statuses": [{"name": "incomplete"}]}`, true},
+		{"Unknown transition", `{"statuses": [{"name": "incomplete", "next": ["waiting"]}, {"name": "complete"}]}`, true},
+		{"Invalid JSON", `{"statuses": `, true},
+		{"Invalid default filter", `{"default_filter": "-someday someday"}`, true},
+		{"Invalid report", `{"reports": {"next": {"sort": "size"}}}`, true},
+		{"Built in report", `{"reports": {"monthly": {}}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(configPath(dir), []byte(tt.config), 0600)
+			c, err := loadConfig(dir)
+			if (err != nil) != tt.err {
+				t.Fatalf("Expected error %v, Got %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(c.Statuses[0].Next, []string{"complete"}) {
+				t.Fatalf("Expected the alias in the transitions to be resolved, Got %v", c.Statuses[0].Next)
+			}
+		})
+	}
+}
+
+func TestStatusCmd(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
 	db, path := setup()
 	defer teardown(db, path)
+	resetTasks(db)
 
-	strs := []string{"a", "b", "c"}
-	complete := []int{2, 3}
-	expected := `1: a 🔴
-2: b ✅
-3: c ✅`
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil), newTask("c", nil)})
+	sCmd, buf := setupCmd(newStatusCmd, db)
 
-	for _, s := range strs {
-		err := insert(db, TASKS_BUCKET, s, "")
-		if err != nil {
-			t.Fatalf("Failed to insert into db: %v", err)
-		}
+	sCmd.SetArgs([]string{"1", "in-progress"})
+	if err := sCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "1: a 🟡") {
+		t.Fatalf("Expected task 1 to be in progress, Got %q", buf.String())
 	}
 
-	for _, id := range complete {
-		completeTask(id, db)
+	sCmd.SetArgs([]string{"2", "3", "cancelled"})
+	if err := sCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task, _ := getTask(db, 2); task.Status != "cancelled" || task.Completed == "" {
+		t.Fatalf("Expected task 2 to be cancelled with a completion time, Got %q %q", task.Status, task.Completed)
 	}
 
-	tp := getTasks(db, TASKS_BUCKET)
-	result := formatTasks(tp)
+	sCmd.SetArgs([]string{"3", "todo"})
+	if err := sCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task, _ := getTask(db, 3); task.Status != STATUS.INCOMPLETE || task.Completed != "" {
+		t.Fatalf("Expected task 3 to be incomplete again, Got %q %q", task.Status, task.Completed)
+	}
 
-	if result != expected {
-		t.Logf("Expected len: %d, Got len: %d", len(expected), len(result))
-		t.Fatalf("Expected %s, Got %s", expected, result)
+	buf.Reset()
+	sCmd.SetArgs([]string{})
+	sCmd.Execute()
+	if !strings.Contains(buf.String(), "🟡  in-progress          1 tasks") {
+		t.Fatalf("Expected the statuses with their counts, Got %q", buf.String())
+	}
+
+	// Transitions not listed in the config are refused
+	config.Statuses[1].Next = []string{"blocked"}
+	sCmd.SetArgs([]string{"1", "done"})
+	if err := sCmd.Execute(); err == nil {
+		t.Fatalf("Expected an error moving an in-progress task to complete")
+	}
+
+	// Cancelled tasks are finished but not counted as completed
+	finish(db, "")
+	if tasks := getTasks(db, TASKS_BUCKET); len(tasks) != 2 {
+		t.Fatalf("Expected the cancelled task to be finished, Got %d tasks left", len(tasks))
+	}
+	stCmd, buf := setupCmd(newStatsCmd, db)
+	stCmd.SetArgs([]string{})
+	stCmd.Execute()
+	if !strings.Contains(buf.String(), "You completed 0 tasks") || !strings.Contains(buf.String(), "1 tasks were cancelled") {
+		t.Fatalf("Expected cancelled tasks to be reported apart, Got %q", buf.String())
 	}
 }
 
-func TestParseTags(t *testing.T) {
-	var tests = []struct {
-		input,
-		tag,
-		output string
-	}{
-		{"no tag", "", "no tag"},
-		{"+start house", "start", "house"},
-		{"car +end", "end", "car"},
-		{"+shop milk eggs + stuff", "shop", "milk eggs + stuff"},
-		// pick the first tag from left, but still remove all tags
-		{"+a +b +c", "a", ""},
-		// trim whitespace
-		{"  +trim me  ", "trim", "me"},
-		// don't leave extra whitespace when removing tag
-		{"a +middle c", "middle", "a c"},
-		// only trim 1 whitespace preceding the tag
-		{"d  +middle e", "middle", "d  e"},
+func TestStartStopCmd(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil), newTask("c", nil)})
+	startCmd, buf := setupCmd(newStartCmd, db)
+	stopCmd, _ := setupCmd(newStopCmd, db)
+
+	startCmd.SetArgs([]string{"1", "2"})
+	if err := startCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "1: a 🟡\n2: b 🟡") {
+		t.Fatalf("Expected tasks 1 and 2 in progress, Got %q", buf.String())
+	}
+	if task, _ := getTask(db, 1); len(task.Intervals) != 1 || task.Intervals[0].End != "" {
+		t.Fatalf("Expected an open interval, Got %v", task.Intervals)
 	}
 
-	for _, tt := range tests {
-		testName := fmt.Sprintf("%v", tt.input)
-		t.Run(testName, func(t *testing.T) {
-			parsedTags, parsedStr := parseTags(tt.input)
-			var tag string
-			if len(parsedTags) >= 1 {
-				tag = parsedTags[0]
-			} else {
-				tag = ""
-			}
+	stopCmd.SetArgs([]string{"1"})
+	if err := stopCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task, _ := getTask(db, 1); task.Status != STATUS.INCOMPLETE || task.Intervals[0].End == "" {
+		t.Fatalf("Expected task 1 incomplete with a closed interval, Got %q %v", task.Status, task.Intervals)
+	}
+	stopCmd.SetArgs([]string{"3"})
+	if err := stopCmd.Execute(); err == nil {
+		t.Fatalf("Expected an error stopping a task that isn't in progress")
+	}
 
-			if tag != tt.tag {
-				t.Errorf("Wrong tag, Expected: %v, Got: %v", tt.tag, tag)
-			}
-			if parsedStr != tt.output {
-				t.Errorf("Wrong output, Expected: %v, Got: %v", tt.output, parsedStr)
-			}
-		})
+	// Completing a task stops its clock
+	completeTask(2, db, io.Discard)
+	if task, _ := getTask(db, 2); task.Intervals[0].End == "" {
+		t.Fatalf("Expected completing the task to close its interval")
 	}
-}
 
-// Creates and connects to a temporary file to serve as the db.
-// Also initializes the task and archive buckets.
-// Returns the db and its path
-func setup() (*bolt.DB, string) {
-	path := filepath.Join(os.TempDir(), "task-test.db")
-	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
-	check(err)
-	db.Update(func(tx *bolt.Tx) error {
-		tx.CreateBucketIfNotExists(TASKS_BUCKET)
-		tx.CreateBucketIfNotExists(ARCHIVE_BUCKET)
-		return nil
-	})
-	return db, path
+	config.TrackTime = false
+	startCmd.SetArgs([]string{"3"})
+	startCmd.Execute()
+	if task, _ := getTask(db, 3); task.Status != STATUS.IN_PROGRESS || len(task.Intervals) != 0 {
+		t.Fatalf("Expected task 3 in progress without time tracking, Got %q %v", task.Status, task.Intervals)
+	}
 }
 
-// Deletes the db at the designated path
-func teardown(db *bolt.DB, path string) {
-	db.Close()
-	os.Remove(path)
-}
+func TestBlockCmd(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
 
-// Reset global values such as flags to their default values.
-// Helps avoid bugs when running tests in a loop
-func resetGlobals() {
-	UpdateStatus = false
-	UpdatedDesc = ""
-	DeleteOnDo = false
-}
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil)})
+	bCmd, _ := setupCmd(newBlockCmd, db)
+	bCmd.SetArgs([]string{"2", "-r", "waiting on Bob"})
+	if err := bCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
-func resetArchive(db *bolt.DB) {
-	db.Update(func(tx *bolt.Tx) error {
-		tx.DeleteBucket(ARCHIVE_BUCKET)
-		tx.CreateBucket(ARCHIVE_BUCKET)
-		return nil
-	})
+	lCmd, buf := setupCmd(newListCmd, db)
+	lCmd.SetArgs([]string{"--blocked"})
+	lCmd.Execute()
+	if buf.String() != "2: b ⛔ (waiting on Bob)\n" {
+		t.Fatalf("Expected only the blocked task with its reason, Got %q", buf.String())
+	}
+
+	// The reason is dropped once the task is unblocked
+	sCmd, _ := setupCmd(newStartCmd, db)
+	sCmd.SetArgs([]string{"2"})
+	sCmd.Execute()
+	if task, _ := getTask(db, 2); task.BlockedReason != "" {
+		t.Fatalf("Expected the reason to be cleared, Got %q", task.BlockedReason)
+	}
 }
 
-func resetTasks(db *bolt.DB) {
-	db.Update(func(tx *bolt.Tx) error {
-		tx.DeleteBucket(TASKS_BUCKET)
-		tx.CreateBucket(TASKS_BUCKET)
-		return nil
+func TestListIDs(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", []string{"home"}), newTask("b", []string{"work"}), newTask("c", []string{"work"})})
+	lCmd, buf := setupCmd(newListCmd, db)
+	lCmd.SetArgs([]string{"+work", "--ids"})
+	if err := lCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != "2\n3\n" {
+		t.Fatalf("Expected the IDs of the work tasks, Got %q", buf.String())
+	}
+
+	// Nothing is printed when no task matches, so piped commands get no arguments
+	resetGlobals()
+	lCmd, buf = setupCmd(newListCmd, db)
+	lCmd.SetArgs([]string{"+nothing", "--ids"})
+	lCmd.Execute()
+	if buf.String() != "" {
+		t.Fatalf("Expected no output, Got %q", buf.String())
+	}
+
+	resetGlobals()
+	lCmd, _ = setupCmd(newListCmd, db)
+	lCmd.SetArgs([]string{"--ids", "-g", "tag"})
+	if err := lCmd.Execute(); err == nil {
+		t.Fatal("Expected --ids and --group to be rejected together")
+	}
+}
+
+func TestListPrint0(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("buy milk", nil), newTask("call Bob\nabout the keys", []string{"work"})})
+	lCmd, buf := setupCmd(newListCmd, db)
+	lCmd.SetArgs([]string{"-0"})
+	if err := lCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != "buy milk\x00call Bob\nabout the keys\x00" {
+		t.Fatalf("Expected the descriptions ending with NUL, Got %q", buf.String())
+	}
+
+	resetGlobals()
+	lCmd, buf = setupCmd(newListCmd, db)
+	lCmd.SetArgs([]string{"+work", "--ids", "--print0"})
+	lCmd.Execute()
+	if buf.String() != "2\x00" {
+		t.Fatalf("Expected the ID ending with NUL, Got %q", buf.String())
+	}
+}
+
+func TestSearchExitCodes(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", []string{"work"})})
+	var tests = []struct {
+		newCmd       func(*connectionManager, io.Writer) *cobra.Command
+		args         []string
+		expectedOut  string
+		expectedCode int
+	}{
+		{newListCmd, []string{"+work"}, "1: a 🔴\n", 0},
+		{newListCmd, []string{"+home"}, "No tasks\n", 1},
+		{newListCmd, []string{"+work", "-q"}, "", 0},
+		{newListCmd, []string{"+home", "--quiet"}, "", 1},
+		{newListCmd, []string{"+home", "--ids"}, "", 1},
+		{newListCmd, []string{"work"}, "Error: Tags must be in the form +tag, got \"work\"\n", 2},
+		{newCountCmd, []string{"-t", "work"}, "1 tasks\n", 0},
+		{newCountCmd, []string{"-t", "home"}, "0 tasks\n", 1},
+		{newCountCmd, []string{"-t", "work", "--json"}, `{"open":1,"completed":0,"archived":0}` + "\n", 0},
+		{newCountCmd, []string{"-t", "home", "--json"}, `{"open":0,"completed":0,"archived":0}` + "\n", 1},
+	}
+
+	for _, tt := range tests {
+		resetGlobals()
+		cmd, buf := setupCmd(tt.newCmd, db)
+		cmd.SetArgs(tt.args)
+		err := cmd.Execute()
+		if code := exitCode(cmd, err); code != tt.expectedCode {
+			t.Errorf("%s %v: Expected exit code %d, Got %d (%v)", cmd.Name(), tt.args, tt.expectedCode, code, err)
+		}
+		if buf.String() != tt.expectedOut {
+			t.Errorf("%s %v: Expected %q, Got %q", cmd.Name(), tt.args, tt.expectedOut, buf.String())
+		}
+	}
+
+	// Other commands keep exiting with 1 on errors
+	dCmd, _ := setupCmd(newDoCmd, db)
+	dCmd.SetArgs([]string{"5"})
+	if err := dCmd.Execute(); exitCode(dCmd, err) != 1 {
+		t.Fatalf("Expected do to exit with 1, Got %d (%v)", exitCode(dCmd, err), err)
+	}
+}
+
+func TestCancelCmd(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	resetArchive(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil), newTask("c", nil)})
+	cCmd, buf := setupCmd(newCancelCmd, db)
+	cCmd.SetArgs([]string{"1"})
+	if err := cCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "1: a ❌") {
+		t.Fatalf("Expected task 1 to be cancelled, Got %q", buf.String())
+	}
+
+	cCmd.SetArgs([]string{"3", "-f"})
+	if err := cCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	archive := getTasks(db, ARCHIVE_BUCKET)
+	if len(archive) != 1 || archive[0].task.Status != STATUS.CANCELLED || archive[0].task.Completed == "" {
+		t.Fatalf("Expected task 3 to be archived as cancelled, Got %v", archive)
+	}
+	if n := getCount(db, TASKS_BUCKET); n != 2 {
+		t.Fatalf("Expected 2 tasks left, Got %d", n)
+	}
+
+	// Cancelled tasks are kept apart from completions
+	completeTask(2, db, io.Discard)
+	finish(db, "")
+	summary := formatArchiveSummary(getTasks(db, ARCHIVE_BUCKET))
+	if !strings.HasPrefix(summary, "Completions:       1\n") || !strings.Contains(summary, "cancelled:         2") {
+		t.Fatalf("Expected 1 completion and 2 cancelled tasks, Got:\n%s", summary)
+	}
+}
+
+func TestTimeSpent(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	task := Task{Intervals: []Interval{
+		{Start: "2024-03-01T09:00:00Z", End: "2024-03-01T10:30:00Z"},
+		{Start: "2024-03-01T11:00:00Z"},
+	}}
+	if spent := timeSpent(task, now); spent != 150*time.Minute {
+		t.Fatalf("Expected 2h30m, Got %v", spent)
+	}
+}
+
+func TestFinishTag(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	insert(db, TASKS_BUCKET, "a", []string{"chores"})
+	insert(db, TASKS_BUCKET, "b", []string{"work"})
+	insert(db, TASKS_BUCKET, "c", []string{"chores"})
+	for _, id := range []int{1, 2} {
+		completeTask(id, db, io.Discard)
+	}
+
+	finished, err := finish(db, "chores")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(finished) != 1 || finished[0].Desc != "a" {
+		t.Fatalf("Expected only task a to be finished, Got %v", finished)
+	}
+
+	var result []string
+	for _, tp := range getTasks(db, TASKS_BUCKET) {
+		result = append(result, tp.task.Desc)
+	}
+	if expected := []string{"b", "c"}; !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Expected %v, Got %v", expected, result)
+	}
+}
+
+func TestPruneArchive(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	db.Update(func(tx *bolt.Tx) error { return tx.DeleteBucket(ARCHIVE_BUCKET) })
+
+	now := time.Now()
+	var archived []Task
+	for _, days := range []int{400, 200, 100, 10, 1} {
+		task := newTask(fmt.Sprintf("%dd", days), nil)
+		task.Status, task.Completed = STATUS.COMPLETE, timestamp(now.AddDate(0, 0, -days))
+		archived = append(archived, task)
+	}
+	insertTasks(db, ARCHIVE_BUCKET, archived)
+
+	left := func() []string {
+		var descs []string
+		for _, t := range getTasks(db, ARCHIVE_BUCKET) {
+			descs = append(descs, t.task.Desc)
+		}
+		return descs
+	}
+	if n, err := pruneArchive(db, ArchiveConfig{}, now); err != nil || n != 0 {
+		t.Fatalf("Expected no limits to keep everything, Got %d, %v", n, err)
+	}
+	if n, _ := pruneArchive(db, ArchiveConfig{MaxAge: "6m"}, now); n != 2 || !reflect.DeepEqual(left(), []string{"100d", "10d", "1d"}) {
+		t.Fatalf("Expected the tasks older than 6 months deleted, Got %d, %v", n, left())
+	}
+	if n, _ := pruneArchive(db, ArchiveConfig{MaxEntries: 2}, now); n != 1 || !reflect.DeepEqual(left(), []string{"10d", "1d"}) {
+		t.Fatalf("Expected the first archived task deleted, Got %d, %v", n, left())
+	}
+
+	// finish prunes with the limits of the config file
+	config.Archive = ArchiveConfig{MaxAge: "1w"}
+	fCmd, buf := setupCmd(newFinishCmd, db)
+	fCmd.SetArgs([]string{})
+	fCmd.Execute()
+	if !strings.Contains(buf.String(), "Deleted 1 old tasks from the archive") || !reflect.DeepEqual(left(), []string{"1d"}) {
+		t.Fatalf("Expected finish to prune the archive, Got %q and %v", buf.String(), left())
+	}
+	// A limit that can't be read is an error, not a panic
+	config.Archive = ArchiveConfig{MaxAge: "forever"}
+	if err := fCmd.Execute(); err == nil || !strings.Contains(err.Error(), `Invalid period "forever"`) {
+		t.Fatalf("Expected an invalid period error, Got %v", err)
+	}
+
+	dir := t.TempDir()
+	for _, c := range []string{`{"archive": {"max_age": "forever"}}`, `{"archive": {"max_entries": -1}}`} {
+		os.WriteFile(configPath(dir), []byte(c), 0600)
+		if _, err := loadConfig(dir); err == nil || !strings.Contains(err.Error(), "archive:") {
+			t.Errorf("%s: Expected an invalid archive error, Got %v", c, err)
+		}
+	}
+}
+
+func TestParseTaskID(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{
+		{Desc: "a", UUID: "1234abcd-0000-4000-8000-000000000000"},
+		{Desc: "b", UUID: "1234abff-0000-4000-8000-000000000000"},
+		{Desc: "c"},
+	})
+	if tasks := getTasks(db, TASKS_BUCKET); len(tasks[2].task.UUID) != 36 {
+		t.Fatalf("Expected new tasks to get a UUID, Got %q", tasks[2].task.UUID)
+	}
+
+	var tests = []struct {
+		input    string
+		expected int
+		err      bool
+	}{
+		{"2", 2, false},
+		{"99", 99, false},
+		{"1234abc", 1, false},
+		{"1234ABF", 2, false},
+		{"1234ab", 0, true},
+		{"12a", 0, true},
+		{"0504560", 1, false},
+		// Shorter numbers are IDs, even when a hash starts with them
+		{"0504", 504, false},
+		{"050456", 50456, false},
+		{"1234", 1234, false},
+		{"68F7", 2, false},
+		{"zzzz", 0, true},
+		{"x", 0, true},
+	}
+	for _, tt := range tests {
+		id, err := parseTaskID(db, tt.input)
+		if (err != nil) != tt.err || id != tt.expected {
+			t.Errorf("%s: Expected %d (error %v), Got %d (%v)", tt.input, tt.expected, tt.err, id, err)
+		}
+	}
+}
+
+func TestAssignUUIDs(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	// A task written before tasks had a UUID
+	db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(TASKS_BUCKET).Put(itob(1), []byte(`{"Desc":"old","Status":"incomplete"}`))
+	})
+	if err := db.Update(func(tx *bolt.Tx) error { return assignUUIDs(tx, TASKS_BUCKET, ARCHIVE_BUCKET) }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	task, _ := getTask(db, 1)
+	if len(task.UUID) != 36 {
+		t.Fatalf("Expected the task to get a UUID, Got %q", task.UUID)
+	}
+
+	sCmd, buf := setupCmd(newShowCmd, db)
+	sCmd.SetArgs([]string{task.UUID[:8]})
+	if err := sCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "old") {
+		t.Fatalf("Expected show to find the task by UUID prefix, Got %q", buf.String())
+	}
+	// Archiving keeps the UUID
+	if _, err := completeTasks(db, []int{1}, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if archived := getTasks(db, ARCHIVE_BUCKET); archived[len(archived)-1].task.UUID != task.UUID {
+		t.Fatalf("Expected the archived task to keep its UUID")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("write report", nil)})
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+	uCmd.SetArgs([]string{"1", "-d", "write the report", "-p", "high"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := completeTasks(db, []int{1}, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	task, _ := getTask(db, 1)
+	var got []string
+	for _, c := range task.History {
+		got = append(got, c.Field+": "+c.Old+" -> "+c.New)
+	}
+	expected := []string{"description: write report -> write the report", "priority:  -> high", "status: incomplete -> complete"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %q, Got %q", expected, got)
+	}
+
+	sCmd, buf := setupCmd(newShowCmd, db)
+	sCmd.SetArgs([]string{"1", "--history"})
+	if err := sCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "History (3 changes)") || !regexp.MustCompile(`priority: +- +→ +high`).MatchString(buf.String()) {
+		t.Fatalf("Expected the history, Got %q", buf.String())
+	}
+	if c := cloneTask(task); c.History != nil {
+		t.Fatalf("Expected a copy to start without history, Got %v", c.History)
+	}
+}
+
+func TestMergeTasks(t *testing.T) {
+	base := newTask("write report", []string{"work"})
+	base.UUID, base.Created = newUUID(), "2025-03-01T09:00:00Z"
+
+	// The description is changed on one machine and the task completed on the other
+	a := snapshot(base)
+	old := snapshot(a)
+	a.Desc = "write the report"
+	recordChanges(old, &a, time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC))
+	a.Comments = []Comment{{"2025-03-02T09:00:00Z", "draft sent"}}
+
+	b := snapshot(base)
+	old = snapshot(b)
+	complete, _ := config.status(STATUS.COMPLETE)
+	setTaskStatus(&b, complete, time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC))
+	b.Tags = []string{"home"}
+	recordChanges(old, &b, time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC))
+	b.Comments = []Comment{{"2025-03-01T10:00:00Z", "started"}}
+
+	merged := mergeTasks(a, b)
+	if merged.Desc != "write the report" || merged.Status != STATUS.COMPLETE || merged.Completed != "2025-03-03T09:00:00Z" {
+		t.Fatalf("Expected both edits to survive, Got %+v", merged)
+	}
+	if !reflect.DeepEqual(merged.Tags, []string{"home"}) || len(merged.Comments) != 2 || merged.Comments[0].Text != "started" {
+		t.Fatalf("Expected the later tags and both comments, Got %+v", merged)
+	}
+	if len(merged.History) != 3 {
+		t.Fatalf("Expected the changes of both machines, Got %v", merged.History)
+	}
+	if other := mergeTasks(b, a); !reflect.DeepEqual(merged, other) {
+		t.Fatalf("Expected the same task merging either way, Got %+v and %+v", merged, other)
+	}
+	if again := mergeTasks(merged, b); !reflect.DeepEqual(merged, again) {
+		t.Fatalf("Expected merging again to change nothing, Got %+v", again)
+	}
+
+	// Edits made at the same time settle on the same value either way
+	a, b = snapshot(base), snapshot(base)
+	a.Priority, b.Priority = "high", "low"
+	if x, y := mergeTasks(a, b), mergeTasks(b, a); x.Priority != y.Priority {
+		t.Fatalf("Expected the same priority merging either way, Got %s and %s", x.Priority, y.Priority)
+	}
+}
+
+func TestDBDumpLoad(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	b := newTask("b", nil)
+	b.Comments = []Comment{{Time: timestamp(time.Now()), Text: "note"}}
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", []string{"x"}), b})
+	insertTasks(db, ARCHIVE_BUCKET, []Task{newTask("done", nil)})
+	deleteKey(1, db, TASKS_BUCKET)
+
+	dump := func() string {
+		dCmd, buf := setupCmd(newDBDumpCmd, db)
+		dCmd.SetArgs([]string{})
+		if err := dCmd.Execute(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return buf.String()
+	}
+	text := dump()
+	if !strings.HasPrefix(text, "{\n  \"format\": \"task-cli\",\n  \"version\": 1,\n  \"tasks\": [\n    {\n      \"ID\": 1,\n      \"Desc\": \"b\",") {
+		t.Errorf("Unexpected dump %s", text)
+	}
+	file := filepath.Join(t.TempDir(), "tasks.txt")
+	os.WriteFile(file, []byte(strings.Replace(text, `"Desc": "b"`, `"Desc": "b edited"`, 1)), 0600)
+
+	// Nothing changes unless confirmed
+	lCmd, buf := setupCmd(newDBLoadCmd, db)
+	lCmd.SetIn(strings.NewReader("n\n"))
+	lCmd.SetArgs([]string{file})
+	if err := lCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Replace the 1 tasks and 1 archived tasks of the database with the 1 tasks and 1 archived tasks of") || !strings.Contains(buf.String(), "Aborted, nothing was changed") {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("replaced", nil)})
+	lCmd, buf = setupCmd(newDBLoadCmd, db)
+	lCmd.SetArgs([]string{file, "-y"})
+	if err := lCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Loaded 1 tasks and 1 archived tasks") {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+	tp := getTasks(db, TASKS_BUCKET)
+	if len(tp) != 1 || tp[0].dbKey != 1 || tp[0].task.Desc != "b edited" || len(tp[0].task.Comments) != 1 {
+		t.Errorf("Expected only task 1 edited, Got %+v", tp)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(backupDir(filepath.Join(home, "task")), "*.db")); len(backups) != 1 {
+		t.Errorf("Expected a backup before loading, Got %v", backups)
+	}
+	// The sequence of the bucket is restored, so `add` gives new tasks the next ID
+	aCmd, buf := setupCmd(newAddCmd, db)
+	aCmd.SetArgs([]string{"c"})
+	if err := aCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tp := getTasks(db, TASKS_BUCKET); len(tp) != 2 || tp[0].task.Desc != "b edited" || tp[1].dbKey != 2 || tp[1].task.Desc != "c" {
+		t.Errorf("Expected the new task to be task 2, Got %+v (%s)", tp, buf.String())
+	}
+	deleteKey(2, db, TASKS_BUCKET)
+	// A loaded dump dumps the same
+	if again := dump(); again != strings.Replace(text, `"Desc": "b"`, `"Desc": "b edited"`, 1) {
+		t.Errorf("Expected the same dump after loading, Got %s", again)
+	}
+
+	var invalid = []struct {
+		dump, err string
+	}{
+		{`{"format": "other", "version": 1}`, `format must be "task-cli"`},
+		{`{"format": "task-cli", "version": 2}`, "unsupported version 2"},
+		{`{"format": "task-cli", "version": 1, "tasks": [{"ID": 0, "Desc": "a"}]}`, `invalid ID 0 of "a"`},
+		{`{"format": "task-cli", "version": 1, "archive": [{"ID": 1}, {"ID": 1}]}`, "ID 1 is used twice"},
+		{`{"format": "task-cli"`, "unexpected end of JSON input"},
+	}
+	for _, tt := range invalid {
+		lCmd, _ := setupCmd(newDBLoadCmd, db)
+		lCmd.SetIn(strings.NewReader(tt.dump))
+		lCmd.SetArgs([]string{"-", "-y"})
+		if err := lCmd.Execute(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Expected an error containing %q, Got %v", tt.err, err)
+		}
+	}
+	if n := getCount(db, TASKS_BUCKET); n != 1 {
+		t.Errorf("Expected invalid dumps to change nothing, Got %d tasks", n)
+	}
+
+	// The dump uses up stdin, so there's nothing left to answer the question
+	LoadYes = false
+	lCmd, _ = setupCmd(newDBLoadCmd, db)
+	lCmd.SetIn(strings.NewReader(text))
+	lCmd.SetArgs([]string{"-"})
+	if err := lCmd.Execute(); err == nil || !strings.Contains(err.Error(), "Use -y") {
+		t.Errorf("Expected -y to be required, Got %v", err)
+	}
+
+	// IDs with gaps are numbered from 1 again, in order
+	lCmd, _ = setupCmd(newDBLoadCmd, db)
+	lCmd.SetIn(strings.NewReader(`{"format": "task-cli", "version": 1, "tasks": [{"ID": 5, "Desc": "five"}, {"ID": 1, "Desc": "one"}]}`))
+	lCmd.SetArgs([]string{"-", "-y"})
+	if err := lCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("new", nil)})
+	var got []string
+	for _, t := range getTasks(db, TASKS_BUCKET) {
+		got = append(got, fmt.Sprintf("%d: %s", t.dbKey, t.task.Desc))
+	}
+	if expected := []string{"1: one", "2: five", "3: new"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, Got %v", expected, got)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+
+	reset := func() {
+		resetTasks(db)
+		insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", []string{"x"}), newTask("c", nil)})
+	}
+	run := func(input string, args ...string) (string, error) {
+		bCmd, buf := setupCmd(newBatchCmd, db)
+		bCmd.SetIn(strings.NewReader(input))
+		bCmd.SetArgs(args)
+		err := bCmd.Execute()
+		return buf.String(), err
+	}
+
+	reset()
+	out, err := run(`{"op":"add","desc":"d","tags":["y"],"priority":"high"}
+{"op":"delete","id":1}
+
+{"op":"update","id":2,"description":"b2","tags":["z"],"due":"2026-03-04"}
+{"op":"complete","id":3}
+{"op":"update","id":4,"points":3}
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// IDs are those before the batch, results have those after it
+	expected := `{"line":1,"op":"add","id":3}
+{"line":2,"op":"delete","id":1}
+{"line":4,"op":"update","id":1}
+{"line":5,"op":"complete","id":2}
+{"line":6,"op":"update","id":3}
+`
+	if out != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+	tp := getTasks(db, TASKS_BUCKET)
+	if len(tp) != 3 {
+		t.Fatalf("Expected 3 tasks, Got %+v", tp)
+	}
+	b, c, d := tp[0].task, tp[1].task, tp[2].task
+	if b.Desc != "b2" || strings.Join(b.Tags, ",") != "z" || formatTimestamp(b.Due, "2006-01-02") != "2026-03-04" || len(b.History) != 3 {
+		t.Errorf("Unexpected updated task %+v", b)
+	}
+	if c.Desc != "c" || c.Status != STATUS.COMPLETE || c.Completed == "" {
+		t.Errorf("Unexpected completed task %+v", c)
+	}
+	if d.Desc != "d" || strings.Join(d.Tags, ",") != "y" || d.Priority != "high" || d.Points != 3 || d.UUID == "" || d.Order <= c.Order {
+		t.Errorf("Unexpected added task %+v", d)
+	}
+
+	// Any failure leaves every task as it was
+	reset()
+	out, err = run(`{"op":"add","desc":"d"}
+not json
+{"op":"zap","id":1}
+{"op":"update","id":9}
+{"op":"add","desc":" "}
+{"op":"update","id":1,"priority":"urgent"}
+{"op":"delete","id":2}
+{"op":"delete","id":2}
+`)
+	if err == nil || err.Error() != "6 of 8 operations failed, nothing was changed" {
+		t.Errorf("Expected the batch to fail, Got %v", err)
+	}
+	for _, want := range []string{
+		`{"line":2,"op":"","error":"invalid character`,
+		`{"line":3,"op":"zap","id":1,"error":"Unknown op \"zap\", must be one of add, update, complete, delete"}`,
+		`{"line":4,"op":"update","id":9,"error":"Task 9 does not exist"}`,
+		`{"line":5,"op":"add","error":"Must provide a task description"}`,
+		`{"line":6,"op":"update","id":1,"error":"Invalid priority`,
+		`{"line":8,"op":"delete","id":2,"error":"Task 2 is deleted twice"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in:\n%s", want, out)
+		}
+	}
+	if tp := getTasks(db, TASKS_BUCKET); len(tp) != 3 || tp[0].task.Priority != "" {
+		t.Errorf("Expected the tasks to be unchanged, Got %+v", tp)
+	}
+
+	// Operations can be read from a file
+	file := filepath.Join(t.TempDir(), "ops.jsonl")
+	os.WriteFile(file, []byte(`{"op":"complete","id":1}`), 0600)
+	if out, err := run("", file); err != nil || out != `{"line":1,"op":"complete","id":1}`+"\n" {
+		t.Errorf("Unexpected result %q, %v", out, err)
+	}
+}
+
+func TestDBMerge(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	shared := newTask("shared", nil)
+	shared.UUID = newUUID()
+	// A task from before UUIDs, matched by description and creation time
+	old := newTask("old", nil)
+	insertTasks(db, TASKS_BUCKET, []Task{shared, old})
+	old, _ = getTask(db, 2)
+	old.UUID = ""
+
+	otherPath := filepath.Join(t.TempDir(), "other.db")
+	other, err := bolt.Open(otherPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	edited := snapshot(shared)
+	edited.Desc = "shared task"
+	recordChanges(shared, &edited, time.Now().Add(time.Minute))
+	insertTasks(other, TASKS_BUCKET, []Task{edited, old, newTask("new", nil)})
+	done := newTask("done", nil)
+	done.Status = STATUS.COMPLETE
+	insertTasks(other, ARCHIVE_BUCKET, []Task{done})
+	other.Close()
+
+	dCmd, buf := setupCmd(newDBMergeCmd, db)
+	dCmd.SetArgs([]string{otherPath, "--dry-run"})
+	if err := dCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Would create 2 tasks, update 1 and skip 1") || len(getTasks(db, TASKS_BUCKET)) != 2 {
+		t.Fatalf("Expected the counts without changes, Got %q", buf.String())
+	}
+
+	mCmd, buf := setupCmd(newDBMergeCmd, db)
+	mCmd.SetArgs([]string{otherPath})
+	if err := mCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Created 2 tasks, updated 1 and skipped 1") {
+		t.Fatalf("Expected the counts, Got %q", buf.String())
+	}
+	var descs []string
+	for _, t := range getTasks(db, TASKS_BUCKET) {
+		descs = append(descs, t.task.Desc)
+	}
+	if !reflect.DeepEqual(descs, []string{"shared task", "old", "new"}) {
+		t.Fatalf("Expected the edit merged and the new task added, Got %q", descs)
+	}
+	if archived := getTasks(db, ARCHIVE_BUCKET); archived[len(archived)-1].task.Desc != "done" {
+		t.Fatalf("Expected the archived task added to the archive")
+	}
+
+	// Merging again changes nothing
+	buf.Reset()
+	mCmd.SetArgs([]string{otherPath})
+	mCmd.Execute()
+	if !strings.Contains(buf.String(), "Created 0 tasks, updated 0 and skipped 4") {
+		t.Fatalf("Expected nothing to merge, Got %q", buf.String())
+	}
+}
+
+func TestRenumberCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	rCmd, buf := setupCmd(newRenumberCmd, db)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil), newTask("c", nil)})
+	// display order: c, a, b
+	moveTask(db, 3, -1)
+	moveTask(db, 3, -1)
+
+	rCmd.SetArgs([]string{})
+	if err := rCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "3 → 1\n1 → 2\n2 → 3\n") {
+		t.Fatalf("Expected the old → new mapping, Got %q", buf.String())
+	}
+
+	var got []string
+	for _, tp := range getTasks(db, TASKS_BUCKET) {
+		got = append(got, fmt.Sprintf("%d:%s", tp.dbKey, tp.task.Desc))
+	}
+	if expected := []string{"1:c", "2:a", "3:b"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, Got %v", expected, got)
+	}
+
+	// New tasks keep being numbered after the existing ones
+	insert(db, TASKS_BUCKET, "d", nil)
+	if tasks := getTasks(db, TASKS_BUCKET); tasks[3].dbKey != 4 {
+		t.Fatalf("Expected the new task to get ID 4, Got %d", tasks[3].dbKey)
+	}
+
+	buf.Reset()
+	if err := rCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != "Task IDs are already in order\n" {
+		t.Fatalf("Expected no changes, Got %q", buf.String())
+	}
+}
+
+func TestDupCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	dCmd, _ := setupCmd(newDupCmd, db)
+	resetGlobals()
+
+	insertTask(db, TASKS_BUCKET, Task{Desc: "template", Status: STATUS.COMPLETE, Completed: time.Now().Format(RFC3339), Tags: []string{"repeat"}, Due: "2006-01-02T15:04:05Z07:00"})
+
+	dCmd.SetArgs([]string{"1", "-c=2"})
+	if err := dCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tp := getTasks(db, TASKS_BUCKET)
+	if len(tp) != 3 {
+		t.Fatalf("Have %d tasks, expected 3", len(tp))
+	}
+	for _, c := range tp[1:] {
+		if c.task.Desc != "template" || c.task.Status != STATUS.INCOMPLETE || c.task.Completed != "" {
+			t.Fatalf("Copy should be an incomplete task with the same description, Got %+v", c.task)
+		}
+		if !reflect.DeepEqual(c.task.Tags, []string{"repeat"}) || c.task.Due != tp[0].task.Due {
+			t.Fatalf("Copy should keep the tags and due date, Got %+v", c.task)
+		}
+	}
+
+	for _, args := range [][]string{{}, {"10"}, {"1", "-c=0"}} {
+		dCmd.SetArgs(args)
+		if err := dCmd.Execute(); err == nil {
+			t.Fatalf("Failed to error on %v", args)
+		}
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	for _, s := range []string{"a", "b", "c"} {
+		insert(db, TASKS_BUCKET, s, nil)
+	}
+
+	var moves = []struct {
+		name     string
+		key      int
+		delta    int
+		expected []string
+		fails    bool
+	}{
+		{"Move down", 1, 1, []string{"b", "a", "c"}, false},
+		{"Move up", 3, -1, []string{"b", "c", "a"}, false},
+		{"Past the top", 2, -1, nil, true},
+		{"Past the bottom", 1, 1, nil, true},
+		{"Missing task", 10, 1, nil, true},
+	}
+
+	for _, m := range moves {
+		t.Run(m.name, func(t *testing.T) {
+			err := moveTask(db, m.key, m.delta)
+			if m.fails {
+				if err == nil {
+					t.Fatalf("Should have errored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result []string
+			for _, tp := range getTasks(db, TASKS_BUCKET) {
+				result = append(result, tp.task.Desc)
+			}
+			if !reflect.DeepEqual(m.expected, result) {
+				t.Fatalf("Expected %v, Got %v", m.expected, result)
+			}
+		})
+	}
+
+	// New tasks are added to the bottom of the list
+	insert(db, TASKS_BUCKET, "d", nil)
+	tp := getTasks(db, TASKS_BUCKET)
+	if last := tp[len(tp)-1].task.Desc; last != "d" {
+		t.Fatalf("Expected new task to be last, Got %s", last)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	strs := []string{"a", "b", "c", "d"}
+	complete := []int{1, 3}
+	expected := []string{"b", "d"}
+
+	for _, s := range strs {
+		if err := insert(db, TASKS_BUCKET, s, nil); err != nil {
+			t.Fatalf("Failed to insert into db: %v", err)
+		}
+	}
+	for _, id := range complete {
+		completeTask(id, db, io.Discard)
+	}
+
+	if purged := purge(db); purged != len(complete) {
+		t.Fatalf("Purged %d tasks, expected %d", purged, len(complete))
+	}
+
+	var result []string
+	for _, tp := range getTasks(db, TASKS_BUCKET) {
+		result = append(result, tp.task.Desc)
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Expected %v, Got %v", expected, result)
+	}
+	if count := getCount(db, ARCHIVE_BUCKET); count != 0 {
+		t.Fatalf("%d tasks were added to the archive, expected 0", count)
+	}
+}
+
+func TestFormatTasks(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	strs := []string{"a", "b", "c"}
+	complete := []int{2, 3}
+	expected := `1: a 🔴
+2: b ✅
+3: c ✅`
+
+	for _, s := range strs {
+		err := insert(db, TASKS_BUCKET, s, nil)
+		if err != nil {
+			t.Fatalf("Failed to insert into db: %v", err)
+		}
+	}
+
+	for _, id := range complete {
+		completeTask(id, db, io.Discard)
+	}
+
+	tp := getTasks(db, TASKS_BUCKET)
+	result := formatTasks(tp)
+
+	if result != expected {
+		t.Logf("Expected len: %d, Got len: %d", len(expected), len(result))
+		t.Fatalf("Expected %s, Got %s", expected, result)
+	}
+}
+
+func TestFormatTasksWrapping(t *testing.T) {
+	resetGlobals()
+	terminalWidth = func() int { return 20 }
+	defer func() { terminalWidth = func() int { return 0 } }()
+
+	tp := []TaskPosition{
+		{task: Task{Desc: "a long description that needs wrapping", Status: STATUS.INCOMPLETE}, dbKey: 1},
+		{task: Task{Desc: "first line\nsecond", Status: STATUS.COMPLETE}, dbKey: 2},
+	}
+	expected := `1: a long
+   description that
+   needs wrapping 🔴
+2: first line
+   second ✅`
+
+	result := formatTasks(tp)
+	if result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestFormatTasksAnnotations(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	ShowAnnotations = true
+
+	tp := []TaskPosition{
+		{task: Task{Desc: "call the landlord", Status: STATUS.INCOMPLETE, Comments: []Comment{
+			{Time: "2024-03-01T10:00:00Z", Text: "left a message"},
+			{Time: "2024-03-02T10:00:00Z", Text: "no answer"},
+			// Written with a local offset before timestamps were stored in UTC, 8:00 UTC
+			{Time: "2024-03-02T11:00:00+03:00", Text: "called again"},
+		}}, dbKey: 9},
+		{task: Task{Desc: "b", Status: STATUS.INCOMPLETE}, dbKey: 10},
+	}
+	expected := `9:  call the landlord 🔴
+    03/02/2024 no answer
+10: b 🔴`
+
+	if result := formatTasks(tp); result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestAddCmdEditor(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	aCmd, _ := setupCmd(newAddCmd, db)
+	resetGlobals()
+
+	editText = func(initial string) (string, error) {
+		return initial + " plan the trip\n- book flights\n- book hotel", nil
+	}
+
+	aCmd.SetArgs([]string{"-e", "+travel"})
+	if err := aCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	task, _ := getTask(db, 1)
+	expected := "plan the trip\n- book flights\n- book hotel"
+	if task.Desc != expected || !reflect.DeepEqual(task.Tags, []string{"travel"}) {
+		t.Fatalf("Expected %q tagged travel, Got %+v", expected, task)
+	}
+}
+
+func TestEditCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+	prevEdit := editText
+	defer func() { editText = prevEdit }()
+
+	due := timestamp(time.Date(2026, 3, 4, 0, 0, 0, 0, time.Local))
+	reset := func() {
+		resetGlobals()
+		resetTasks(db)
+		done := newTask("done", nil)
+		done.Status = STATUS.COMPLETE
+		b := newTask("call  mom", nil)
+		b.Due = due
+		insertTasks(db, TASKS_BUCKET, []Task{newTask("buy milk", []string{"errands"}), b, done})
+	}
+
+	// Open tasks are listed one per line
+	reset()
+	var shown string
+	editText = func(initial string) (string, error) {
+		shown = initial
+		return initial, nil
+	}
+	eCmd, buf := setupCmd(newEditCmd, db)
+	eCmd.SetArgs([]string{"--all"})
+	if err := eCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(shown, "\n1 buy milk +errands\n2 call mom due:2026-03-04\n") || !strings.HasPrefix(shown, "# ") {
+		t.Errorf("Unexpected text to edit %q", shown)
+	}
+	// Collapsed spaces and dates without their time aren't changes
+	if buf.String() != "No changes\n" {
+		t.Errorf("Expected no changes, Got %q", buf.String())
+	}
+
+	var tests = []struct {
+		name, edited string
+		expected     []Task
+		err          string
+	}{
+		{"Changes", "1 buy oat milk +errands +home\n# a comment\n2 call mom due:none\n", []Task{{Desc: "buy oat milk", Tags: []string{"errands", "home"}}, {Desc: "call  mom"}}, ""},
+		{"Removed line", "2 call dad +family due:2026-03-05", []Task{{Desc: "buy milk", Tags: []string{"errands"}}, {Desc: "call dad", Tags: []string{"family"}, Due: "2026-03-05"}}, ""},
+		{"Unknown ID", "1 buy milk\n3 done", nil, `Line 2: "3" isn't the ID of a task being edited`},
+		{"No ID", "buy milk", nil, `Line 1: "buy" isn't the ID of a task being edited`},
+		{"Twice", "1 a\n1 b", nil, "Line 2: task 1 is listed twice"},
+		{"No description", "1 +errands", nil, "Line 1: Must provide a task description"},
+		{"Invalid date", "2 call mom due:someday", nil, "Line 1: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset()
+			editText = func(string) (string, error) { return tt.edited, nil }
+			eCmd, _ := setupCmd(newEditCmd, db)
+			eCmd.SetArgs([]string{"-a"})
+			err := eCmd.Execute()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Expected an error containing %q, Got %v", tt.err, err)
+				}
+				if task, _ := getTask(db, 1); task.Desc != "buy milk" {
+					t.Errorf("Expected the tasks to be left as they are, Got %+v", task)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for i, want := range tt.expected {
+				got, _ := getTask(db, i+1)
+				gotDue := ""
+				if got.Due != "" {
+					gotDue = formatTimestamp(got.Due, "2006-01-02")
+				}
+				if got.Desc != want.Desc || strings.Join(got.Tags, ",") != strings.Join(want.Tags, ",") || gotDue != want.Due {
+					t.Errorf("Task %d: Expected %+v, Got %+v", i+1, want, got)
+				}
+			}
+		})
+	}
+
+	// Tasks can be picked by ID, and the changes are in their history
+	reset()
+	editText = func(initial string) (string, error) {
+		shown = initial
+		return strings.Replace(initial, "call mom", "call mom tonight", 1), nil
+	}
+	eCmd, buf = setupCmd(newEditCmd, db)
+	eCmd.SetArgs([]string{"2"})
+	if err := eCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(shown, "buy milk") || buf.String() != "Updated task 2\n" {
+		t.Errorf("Expected only task 2 to be edited, Got %q and %q", shown, buf.String())
+	}
+	if task, _ := getTask(db, 2); len(task.History) != 1 || task.History[0].New != "call mom tonight" {
+		t.Errorf("Expected the change in the history, Got %+v", task.History)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected int
+	}{
+		{"abc", 3},
+		{"日本語", 6},
+		{"tesuto テスト", 13},
+		{"✅", 2},
+		{"🔴", 2},
+		{"⚠️", 2},
+		{"e\u0301", 1},
+		// Symbols with a text presentation stay narrow
+		{"☺", 1},
+		{"🗒", 1},
+		{"👩‍💻", 2},
+	}
+
+	for _, tt := range tests {
+		if w := textWidth(tt.input); w != tt.expected {
+			t.Errorf("%q: Expected width %d, Got %d", tt.input, tt.expected, w)
+		}
+	}
+
+	var cuts = []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{"abcdef", 6, "abcdef"},
+		{"abcdef", 4, "abc…"},
+		{"日本語", 4, "日…"},
+		{"a👩‍💻b", 3, "a…"},
+		{"a👩‍💻bc", 4, "a👩‍💻…"},
+		{"abc", 0, ""},
+	}
+	for _, tt := range cuts {
+		if got := truncateText(tt.input, tt.width); got != tt.expected {
+			t.Errorf("%q in %d columns: Expected %q, Got %q", tt.input, tt.width, tt.expected, got)
+		}
+	}
+}
+
+func TestFormatTasksAlignment(t *testing.T) {
+	resetGlobals()
+	ShowTags = true
+	defer resetGlobals()
+
+	tp := []TaskPosition{
+		{task: Task{Desc: "a", Status: STATUS.INCOMPLETE, Tags: []string{"仕事"}}, dbKey: 9},
+		{task: Task{Desc: "b", Status: STATUS.INCOMPLETE, Tags: []string{"home"}}, dbKey: 10},
+		{task: Task{Desc: "c", Status: STATUS.INCOMPLETE}, dbKey: 11},
+	}
+	expected := `9:  仕事: a 🔴
+10: home: b 🔴
+11: :     c 🔴`
+
+	result := formatTasks(tp)
+	if result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestFormatTasksTagIcons(t *testing.T) {
+	resetGlobals()
+	ShowTags = true
+	defer resetGlobals()
+	config.TagIcons = map[string]string{"work": "💼", "home": "🏠"}
+
+	tp := []TaskPosition{
+		{task: Task{Desc: "a", Status: STATUS.INCOMPLETE, Tags: []string{"work", "errands"}}, dbKey: 1},
+		{task: Task{Desc: "b", Status: STATUS.INCOMPLETE, Tags: []string{"home"}}, dbKey: 2},
+	}
+	expected := `1: 💼,errands: a 🔴
+2: 🏠:         b 🔴`
+	if result := formatTasks(tp); result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	ShowTags = false
+	if result := formatTasks(tp); result != "1: a 🔴\n2: b 🔴" {
+		t.Fatalf("Expected no tags without -t, Got:\n%s", result)
+	}
+}
+
+func TestFormatTasksColors(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	defer func() { colorOutput = func() bool { return false } }()
+	colorOutput = func() bool { return true }
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format(RFC3339)
+	tp := []TaskPosition{
+		{task: Task{Desc: "late", Status: STATUS.INCOMPLETE, Due: yesterday, Priority: "low"}, dbKey: 1},
+		{task: Task{Desc: "urgent", Status: STATUS.INCOMPLETE, Priority: "high"}, dbKey: 2},
+		{task: Task{Desc: "plain", Status: STATUS.INCOMPLETE, Priority: "med"}, dbKey: 3},
+		{task: Task{Desc: "done", Status: STATUS.COMPLETE, Due: yesterday, Priority: "high"}, dbKey: 4},
+	}
+	expected := "\x1b[31m1: late 🔴\x1b[0m\n\x1b[33m2: urgent 🔴\x1b[0m\n3: plain 🔴\n4: done ✅"
+	if result := formatTasks(tp); result != expected {
+		t.Fatalf("Expected %q, Got %q", expected, result)
+	}
+
+	config.Colors = map[string]string{"high": "bold bright-magenta", "overdue": ""}
+	expected = "1: late 🔴\n\x1b[1;95m2: urgent 🔴\x1b[0m\n3: plain 🔴\n4: done ✅"
+	if result := formatTasks(tp); result != expected {
+		t.Fatalf("Expected %q, Got %q", expected, result)
+	}
+
+	NoColor = true
+	if result := formatTasks(tp); strings.Contains(result, "\x1b") {
+		t.Fatalf("Expected no colors with --no-color, Got %q", result)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(configPath(dir), []byte(`{"colors": {"high": "blinking red"}}`), 0600)
+	if _, err := loadConfig(dir); err == nil || !strings.Contains(err.Error(), `Unknown color "blinking"`) {
+		t.Errorf("Expected an unknown color error, Got %v", err)
+	}
+	os.WriteFile(configPath(dir), []byte(`{"colors": {"someday": "red"}}`), 0600)
+	if _, err := loadConfig(dir); err == nil {
+		t.Errorf("Expected an unknown color state error")
+	}
+}
+
+func TestFormatPattern(t *testing.T) {
+	// 01/06/2025 was a Monday
+	completed := []time.Time{
+		time.Date(2025, 1, 6, 9, 15, 0, 0, time.Local),
+		time.Date(2025, 1, 6, 9, 45, 0, 0, time.Local),
+		time.Date(2025, 1, 12, 21, 0, 0, 0, time.Local),
+	}
+	got := formatPattern(completed)
+
+	for _, row := range []string{
+		"09 | " + strings.Repeat("█", histogramWidth) + " 2\n",
+		"21 | " + strings.Repeat("█", histogramWidth/2) + " 1\n",
+		"10 |\n",
+		"Mon | " + strings.Repeat("█", histogramWidth) + " 2\n",
+		"Tue |\n",
+		"Sun | " + strings.Repeat("█", histogramWidth/2) + " 1",
+	} {
+		if !strings.Contains(got, row) {
+			t.Errorf("Expected %q in:\n%s", row, got)
+		}
+	}
+}
+
+func TestDateFormat(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	defer func() { locale = "en" }()
+	day := time.Date(2025, 3, 7, 15, 4, 0, 0, time.Local)
+
+	var tests = []struct {
+		format   string
+		locale   string
+		expected string
+	}{
+		{"", "en", "03/07/2025"},
+		{"2006-01-02", "en", "2025-03-07"},
+		{"Jan 2", "ja", "Mar 7"},
+		{"locale", "es", "07/03/2025"},
+		{"locale", "ja", "2025/03/07"},
+		{"locale", "fr", "03/07/2025"},
+	}
+	for _, tt := range tests {
+		config.DateFormat, locale = tt.format, tt.locale
+		if got := formatDate(day); got != tt.expected {
+			t.Errorf("%q in %s: Expected %s, Got %s", tt.format, tt.locale, tt.expected, got)
+		}
+	}
+
+	config.DateFormat, locale = "2006-01-02", "en"
+	if got := formatDateStamp(day.Format(RFC3339), true); got != "2025-03-07 15:04" {
+		t.Errorf("Expected the date and time, Got %s", got)
+	}
+	if got := formatBurnup([]BurnupPoint{{day, 1, 1}}); !strings.Contains(got, "2025-03-07 | ") {
+		t.Errorf("Expected the burnup chart to use the date format, Got:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(configPath(dir), []byte(`{"date_format": "yyyy-mm-dd"}`), 0600)
+	if _, err := loadConfig(dir); err == nil || !strings.Contains(err.Error(), `invalid date_format "yyyy-mm-dd"`) {
+		t.Errorf("Expected an invalid date_format error, Got %v", err)
+	}
+	for _, layout := range []string{"2006-01-02", "Jan 2", "Monday 02.01.2006"} {
+		if err := checkDateFormat(layout); err != nil {
+			t.Errorf("%s: Unexpected error: %v", layout, err)
+		}
+	}
+	// The reference date formats to the layout itself, which mustn't be taken for a layout
+	// without any element
+	os.WriteFile(configPath(dir), []byte(`{"date_format": "2006-01-02"}`), 0600)
+	if c, err := loadConfig(dir); err != nil || c.DateFormat != "2006-01-02" {
+		t.Errorf("Expected the date_format to be accepted, Got %q (%v)", c.DateFormat, err)
+	}
+}
+
+func TestEnvSettings(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(configPath(dir), []byte(`{"date_format": "Jan 2", "do": {"auto_finish": false}, "archive": {"max_age": "30d"}}`), 0600)
+
+	t.Setenv("TASK_DO_AUTO_FINISH", "true")
+	t.Setenv("TASK_DATE_FORMAT", "2006-01-02")
+	t.Setenv("TASK_DEFAULTS", `{"list": ["-t"]}`)
+	c, err := loadConfig(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !c.Do.AutoFinish || c.DateFormat != "2006-01-02" || !reflect.DeepEqual(c.Defaults["list"], []string{"-t"}) {
+		t.Errorf("Expected the environment to override the config file, Got %+v", c)
+	}
+	if c.Archive.MaxAge != "30d" || !c.TrackTime {
+		t.Errorf("Expected the other settings to be kept, Got %+v", c)
+	}
+	if buf, _ := os.ReadFile(configPath(dir)); strings.Contains(string(buf), "2006-01-02") {
+		t.Errorf("Expected the config file to be left as is, Got %s", buf)
+	}
+
+	// Overrides apply without a config file
+	empty := t.TempDir()
+	if c, err := loadConfig(empty); err != nil || !c.Do.AutoFinish {
+		t.Errorf("Expected auto_finish from the environment, Got %+v, %v", c.Do, err)
+	}
+
+	var tests = []struct {
+		env, value, err string
+	}{
+		{"TASK_TRACK_TIME", "yes", `TASK_TRACK_TIME: Invalid value "yes" for track_time`},
+		{"TASK_TIMEZONE", "Nowhere/Else", `with the TASK_* environment variables: unknown timezone "Nowhere/Else"`},
+		{"TASK_ARCHIVE_MAX_ENTRIES", "-1", "max_entries must be 0 or more"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			if _, err := loadConfig(empty); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected an error containing %q, Got %v", tt.err, err)
+			}
+		})
+	}
+
+	if keys := settingKeys(); !strings.Contains(strings.Join(keys, " "), "email.server") || settingEnv("do.auto_finish") != "TASK_DO_AUTO_FINISH" {
+		t.Errorf("Unexpected setting keys %v", keys)
+	}
+}
+
+func TestConfigCmd(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir, _ := taskDir()
+	os.Mkdir(dir, 0700)
+
+	run := func(args ...string) (string, error) {
+		cCmd, buf := setupCmd(newConfigCmd, db)
+		cCmd.SetArgs(args)
+		err := cCmd.Execute()
+		return buf.String(), err
+	}
+	for _, args := range [][]string{
+		{"set", "do.auto_finish", "true"},
+		{"set", "archive.max_entries", "5000"},
+		{"set", "date_format", "2006-01-02"},
+		{"set", "views.work", "+work"},
+	} {
+		if _, err := run(args...); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", args, err)
+		}
+	}
+	if !config.Do.AutoFinish || config.Archive.MaxEntries != 5000 || config.DateFormat != "2006-01-02" || config.Views["work"] != "+work" {
+		t.Fatalf("Expected the settings to be in use, Got %+v", config)
+	}
+	if c, err := loadConfig(dir); err != nil || !c.Do.AutoFinish || c.Views["work"] != "+work" {
+		t.Fatalf("Expected the settings to be saved, Got %+v, %v", c, err)
+	}
+
+	var errors = []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"set", "color", "false"}, `Unknown setting "color"`},
+		{[]string{"get", "db.path"}, `Unknown setting "db.path"`},
+		{[]string{"set", "do.auto_finish", "yes"}, `Invalid value "yes" for do.auto_finish`},
+		{[]string{"set", "archive.max_age", "forever"}, `Invalid period "forever"`},
+	}
+	for _, tt := range errors {
+		if _, err := run(tt.args...); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: Expected %q, Got %v", tt.args, tt.expected, err)
+		}
+	}
+	if c, _ := loadConfig(dir); c.Archive.MaxAge != "" {
+		t.Errorf("Expected an invalid value not to be saved, Got %q", c.Archive.MaxAge)
+	}
+
+	if got, _ := run("get", "archive.max_entries"); got != "5000\n" {
+		t.Errorf("Expected 5000, Got %q", got)
+	}
+	if got, _ := run("get", "do"); got != `{"auto_finish":true}`+"\n" {
+		t.Errorf("Expected the do section, Got %q", got)
+	}
+	if got, _ := run("get", "email.server"); got != "" {
+		t.Errorf("Expected nothing for a setting that isn't set, Got %q", got)
+	}
+	got, _ := run("list")
+	if !regexp.MustCompile(`(?m)^date_format +2006-01-02$`).MatchString(got) || !regexp.MustCompile(`(?m)^track_time +true$`).MatchString(got) {
+		t.Errorf("Expected every setting, Got %q", got)
+	}
+
+	// The SMTP password is saved but never printed
+	for _, args := range [][]string{
+		{"set", "email", `{"server": "smtp.example.com:587", "password": "hunter1", "from": "me@example.com", "to": ["me@example.com"]}`},
+		{"set", "email.password", "hunter2"},
+		{"get", "email.password"},
+		{"get", "email"},
+		{"list"},
+	} {
+		got, err := run(args...)
+		if err != nil || strings.Contains(got, "hunter") || !strings.Contains(got, "********") {
+			t.Errorf("%v: Expected the password to be masked, Got %q, %v", args, got, err)
+		}
+	}
+	if config.Email == nil || config.Email.Password != "hunter2" {
+		t.Errorf("Expected the password to be in use, Got %+v", config.Email)
+	}
+}
+
+func TestTimezones(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	if got := timestamp(time.Date(2025, 3, 8, 5, 0, 0, 0, tokyo)); got != "2025-03-07T20:00:00Z" {
+		t.Errorf("Expected the timestamp in UTC, Got %s", got)
+	}
+
+	if err := setTimezone("Asia/Tokyo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := formatDateStamp("2025-03-07T20:00:00Z", true); got != "03/08/2025 05:00" {
+		t.Errorf("Expected the time in Tokyo, Got %s", got)
+	}
+
+	// Completed in the morning of 03/08 in Tokyo, the evening before in UTC
+	task := newTask("a", nil)
+	task.Status, task.Completed = STATUS.COMPLETE, "2025-03-07T20:00:00Z"
+	insertTasks(db, ARCHIVE_BUCKET, []Task{task})
+	sCmd, buf := setupCmd(newStatsCmd, db)
+	sCmd.SetArgs([]string{"-o", "03/08/2025"})
+	sCmd.Execute()
+	if !strings.Contains(buf.String(), "You completed 1 tasks") {
+		t.Errorf("Expected the task completed on 03/08 in Tokyo, Got %q", buf.String())
+	}
+
+	if err := setTimezone("Mars/Olympus"); err == nil {
+		t.Errorf("Expected an error for an unknown timezone")
+	}
+	dir := t.TempDir()
+	os.WriteFile(configPath(dir), []byte(`{"timezone": "Mars/Olympus"}`), 0600)
+	if _, err := loadConfig(dir); err == nil || !strings.Contains(err.Error(), `unknown timezone "Mars/Olympus"`) {
+		t.Errorf("Expected an unknown timezone error, Got %v", err)
+	}
+}
+
+func TestRelativeDates(t *testing.T) {
+	now := time.Date(2025, 3, 7, 15, 4, 0, 0, time.Local)
+
+	var tests = []struct {
+		t        time.Time
+		withTime bool
+		expected string
+	}{
+		{now.Add(-20 * time.Second), true, "just now"},
+		{now.Add(-5 * time.Minute), true, "5m ago"},
+		{now.Add(3 * time.Hour), true, "in 3h"},
+		{now.Add(-30 * time.Hour), true, "yesterday"},
+		{now.AddDate(0, 0, -2), true, "2d ago"},
+		{time.Date(2025, 3, 7, 0, 0, 0, 0, time.Local), false, "today"},
+		{time.Date(2025, 3, 8, 0, 0, 0, 0, time.Local), false, "tomorrow"},
+		{time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local), false, "in 3d"},
+		{time.Date(2025, 3, 28, 0, 0, 0, 0, time.Local), false, "in 3w"},
+		{time.Date(2024, 12, 1, 0, 0, 0, 0, time.Local), false, "3mo ago"},
+		{time.Date(2023, 3, 1, 0, 0, 0, 0, time.Local), false, "2y ago"},
+	}
+	for _, tt := range tests {
+		if got := formatRelative(tt.t, now, tt.withTime); got != tt.expected {
+			t.Errorf("%s: Expected %s, Got %s", tt.t, tt.expected, got)
+		}
+	}
+
+	resetGlobals()
+	defer resetGlobals()
+	if got := formatWhen("", false); got != "-" {
+		t.Errorf("Expected - for no date, Got %s", got)
+	}
+	RelativeDates = true
+	if got := formatWhen("", true); got != "-" {
+		t.Errorf("Expected - for no date, Got %s", got)
+	}
+	if got := formatWhen(time.Now().AddDate(0, 0, -1).Format(RFC3339), false); got != "yesterday" {
+		t.Errorf("Expected yesterday, Got %s", got)
+	}
+}
+
+func TestStatsCompare(t *testing.T) {
+	// 01/15/2025 was a Wednesday
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.Local)
+	before, after, err := comparedPeriods("week", Period{}, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if before.String() != "01/06/2025-01/12/2025" || after.String() != "01/13/2025-01/15/2025" {
+		t.Fatalf("Expected 01/06/2025-01/12/2025 and 01/13/2025-01/15/2025, Got %s and %s", before, after)
+	}
+	if _, _, err := comparedPeriods("01/01/2025", Period{}, now); err == nil {
+		t.Fatalf("Failed to error on an invalid range")
+	}
+
+	completed := func(d int, tags ...string) TaskPosition {
+		return TaskPosition{task: Task{Completed: time.Date(2025, 1, d, 10, 0, 0, 0, time.Local).Format(RFC3339), Tags: tags}}
+	}
+	archive := []TaskPosition{completed(7, "work"), completed(8, "work"), completed(14, "home"), completed(2)}
+
+	expected := strings.Join([]string{
+		"             01/06/2025-01/12/2025  01/13/2025-01/15/2025  Change",
+		"Completions  2                      1                      -1",
+		"Average/day  0.3                    0.4                    +0.1",
+		"+home        0                      1                      +1",
+		"+work        2                      0                      -2",
+	}, "\n")
+	if got := formatComparison(archive, before, after); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestMonthlyReport(t *testing.T) {
+	date := func(m time.Month, d int) string {
+		return time.Date(2025, m, d, 10, 0, 0, 0, time.UTC).Format(RFC3339)
+	}
+	tasks := []TaskPosition{
+		{task: Task{Desc: "open", Status: STATUS.INCOMPLETE, Created: date(5, 2)}},
+		{task: Task{Desc: "done, not finished", Status: STATUS.COMPLETE, Created: date(5, 3), Completed: date(5, 4), Tags: []string{"home"}}},
+	}
+	archive := []TaskPosition{
+		{task: Task{Desc: "lingering", Status: STATUS.COMPLETE, Created: date(3, 1), Completed: date(5, 20), Tags: []string{"work"}}},
+		{task: Task{Desc: "quick", Status: STATUS.COMPLETE, Created: date(5, 10), Completed: date(5, 10), Tags: []string{"work"}}},
+		{task: Task{Desc: "last month", Status: STATUS.COMPLETE, Created: date(4, 1), Completed: date(4, 2), Tags: []string{"home"}}},
+	}
+
+	r := monthlyReport(tasks, archive, time.Date(2025, 5, 31, 0, 0, 0, 0, time.Local))
+	expected := strings.Join([]string{
+		"# Report for 2025-05",
+		"",
+		"- **Completed:** 3",
+		"- **Created:** 3",
+		"- **Backlog change:** +0",
+		"- **Top tags:** +work (2), +home (1)",
+		`- **Longest open:** "lingering" (80 days)`,
+	}, "\n")
+	if got := formatReport(r, true); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	empty := formatReport(monthlyReport(nil, nil, time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)), false)
+	if !strings.Contains(empty, "Longest open:    -") {
+		t.Fatalf("Expected no longest open task in:\n%s", empty)
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	date := func(d int) string {
+		return time.Date(2025, 5, d, 10, 0, 0, 0, time.Local).Format(RFC3339)
+	}
+	now := time.Date(2025, 5, 10, 12, 0, 0, 0, time.Local)
+	tasks := []TaskPosition{
+		{dbKey: 1, task: Task{Desc: "<script>alert(1)</script>", Status: STATUS.INCOMPLETE, Created: date(8), Due: date(9)}},
+		{dbKey: 2, task: Task{Desc: "done, not finished", Status: STATUS.COMPLETE, Created: date(1), Completed: date(9), Tags: []string{"home"}}},
+	}
+	archive := []TaskPosition{
+		{task: Task{Desc: "quick", Status: STATUS.COMPLETE, Created: date(9), Completed: date(9), Tags: []string{"work"}}},
+		{task: Task{Desc: "too early", Status: STATUS.COMPLETE, Created: date(1), Completed: date(2)}},
+	}
+
+	period, err := reportPeriod("", "", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := time.Date(2025, 5, 4, 0, 0, 0, 0, time.Local); !period.Start.Equal(expected) || !period.End.Equal(now) {
+		t.Fatalf("Expected the week up to now, Got %v", period)
+	}
+	if _, err := reportPeriod("05/10/2025", "05/01/2025", now); err == nil {
+		t.Fatalf("Expected an error for an end before the start")
+	}
+
+	r, err := htmlReport("Weekly", CustomReport{Columns: htmlReportColumns}, tasks, archive, period, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if r.Open != 1 || r.Overdue != 1 || r.Completed != 2 || r.Created != 2 {
+		t.Fatalf("Expected 1 open, 1 overdue, 2 completed and 2 created tasks, Got %+v", r)
+	}
+	if len(r.PerDay) != 7 || r.PerDay[5].Count != 2 || r.PerDay[5].Percent != 100 || r.PerDay[0].Percent != 0 {
+		t.Fatalf("Expected 2 completions on the 6th day, Got %+v", r.PerDay)
+	}
+
+	var buf bytes.Buffer
+	if err := writeHTMLReport(&buf, r, now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	page := buf.String()
+	for _, expected := range []string{
+		"<h1>Weekly</h1>",
+		"05/04/2025 – 05/10/2025",
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		`<td class="due overdue">05/09/2025</td>`,
+		"<td>done, not finished</td>",
+		`style="width: 100%"`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %q", expected)
+		}
+	}
+	if strings.Contains(page, "too early") || strings.Contains(page, "<script>") {
+		t.Errorf("Unexpected content in:\n%s", page)
+	}
+}
+
+func TestExportFilter(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	now := time.Now()
+	recent := newTask("recent", []string{"work"})
+	old := newTask("old", []string{"work"})
+	old.Created = timestamp(now.AddDate(0, -2, 0))
+	changed := newTask("changed", []string{"work", "urgent"})
+	changed.Created = old.Created
+	changed.History = []Change{{Time: timestamp(now.AddDate(0, 0, -3)), Field: "priority", New: "high"}}
+	home := newTask("home", []string{"home"})
+	done := newTask("done", []string{"work"})
+	done.Status = STATUS.COMPLETE
+	insertTasks(db, TASKS_BUCKET, []Task{recent, old, changed, home, done})
+
+	var tests = []struct {
+		flags    []string
+		expected []string
+	}{
+		{[]string{"--tag", "work"}, []string{"recent", "old", "changed", "done"}},
+		{[]string{"--tag", "work,urgent"}, []string{"changed"}},
+		{[]string{"--tag", "work", "--status", "todo", "--since", "30d"}, []string{"recent", "changed"}},
+		{[]string{"--since", "1w", "-s", "done"}, []string{"done"}},
+		{[]string{"--since", "today", "-t", "home"}, []string{"home"}},
+	}
+	for _, tt := range tests {
+		eCmd, buf := setupCmd(newExportCmd, db)
+		eCmd.SetArgs(tt.flags)
+		if err := eCmd.Execute(); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", tt.flags, err)
+		}
+		var tasks []Task
+		json.Unmarshal(buf.Bytes(), &tasks)
+		var got []string
+		for _, t := range tasks {
+			got = append(got, t.Desc)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%v: Expected %q, Got %q", tt.flags, tt.expected, got)
+		}
+	}
+
+	for _, flags := range [][]string{{"--status", "nope"}, {"--since", "someday"}} {
+		eCmd, _ := setupCmd(newExportCmd, db)
+		eCmd.SetArgs(flags)
+		if err := eCmd.Execute(); err == nil {
+			t.Errorf("%v: Expected an error", flags)
+		}
+	}
+}
+
+func TestFormatPrintable(t *testing.T) {
+	now := time.Date(2025, 5, 9, 12, 0, 0, 0, time.Local)
+	due := func(d int) string {
+		return time.Date(2025, 5, d, 0, 0, 0, 0, time.Local).Format(RFC3339)
+	}
+	tasks := []TaskPosition{
+		{dbKey: 1, task: Task{Desc: "file taxes", Status: STATUS.INCOMPLETE, Due: due(8), Priority: "high"}},
+		{dbKey: 2, task: Task{Desc: "already done", Status: STATUS.COMPLETE}},
+		{dbKey: 3, task: Task{Desc: "pay rent", Status: STATUS.IN_PROGRESS, Due: due(9), Tags: []string{"home"}, Assignee: "alice"}},
+		{dbKey: 10, task: Task{Desc: "read", Status: STATUS.INCOMPLETE}},
+	}
+
+	expected := strings.Join([]string{
+		"Tasks for Friday 05/09/2025                                          Page 1 of 2",
+		strings.Repeat("=", printWidth),
+		"",
+		"Overdue (1)",
+		"[ ] 1   file taxes",
+		"        due 05/08/2025 · high",
+		"",
+		"Today (1)",
+		"[ ] 3   pay rent",
+		"        in-progress · due 05/09/2025 · +home · @alice",
+		"\fTasks for Friday 05/09/2025                                          Page 2 of 2",
+		strings.Repeat("=", printWidth),
+		"",
+		"No due date (1)",
+		"[ ] 10  read",
+		"",
+	}, "\n")
+	if got := formatPrintable(tasks, now, 12); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+	if got := formatPrintable(tasks, now, 60); strings.Contains(got, "\f") || strings.Contains(got, "already done") {
+		t.Fatalf("Expected a single page of open tasks, Got:\n%s", got)
+	}
+}
+
+func TestWeeklyReport(t *testing.T) {
+	date := func(d int) string {
+		return time.Date(2025, 5, d, 10, 0, 0, 0, time.Local).Format(RFC3339)
+	}
+	// Monday
+	now := time.Date(2025, 5, 12, 8, 0, 0, 0, time.Local)
+	tasks := []TaskPosition{
+		{dbKey: 1, task: Task{Desc: "later", Status: STATUS.INCOMPLETE, Due: date(25)}},
+		{dbKey: 2, task: Task{Desc: "taxes", Status: STATUS.INCOMPLETE, Due: date(14), Tags: []string{"home"}}},
+		{dbKey: 3, task: Task{Desc: "rent", Status: STATUS.INCOMPLETE, Due: date(9)}},
+		{dbKey: 4, task: Task{Desc: "done, not finished", Status: STATUS.COMPLETE, Completed: date(10), Due: date(12)}},
+	}
+	archive := []TaskPosition{
+		{task: Task{Desc: "email", Status: STATUS.COMPLETE, Completed: date(6), Tags: []string{"work"}}},
+		{task: Task{Desc: "two weeks ago", Status: STATUS.COMPLETE, Completed: date(1)}},
+		{task: Task{Desc: "dropped", Status: "cancelled", Completed: date(7)}},
+	}
+
+	expected := strings.Join([]string{
+		"Weekly report for 05/05/2025 - 05/11/2025",
+		"",
+		"Completed last week (2)",
+		"  Tue 05/06/2025  email +work",
+		"  Sat 05/10/2025  done, not finished",
+		"",
+		"Due this week (2)",
+		"  Fri 05/09/2025 (overdue)  rent",
+		"  Wed 05/14/2025  taxes +home",
+	}, "\n")
+	if got := formatWeeklyReport(weeklyReport(tasks, archive, now)); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+	if got := formatWeeklyReport(weeklyReport(nil, nil, now)); !strings.Contains(got, "Completed last week (0)\n  -") {
+		t.Fatalf("Expected empty sections, Got:\n%s", got)
+	}
+}
+
+func TestWeeklySchedule(t *testing.T) {
+	s, err := parseWeeklySchedule("Mon 8:30")
+	if err != nil || s != (WeeklySchedule{time.Monday, 8, 30}) {
+		t.Fatalf("Expected monday 08:30, Got %+v (%v)", s, err)
+	}
+	for _, invalid := range []string{"monday", "someday 08:00", "monday 25:00"} {
+		if _, err := parseWeeklySchedule(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+
+	var tests = []struct {
+		now      time.Time
+		expected time.Time
+	}{
+		// Wednesday
+		{time.Date(2025, 5, 14, 12, 0, 0, 0, time.Local), time.Date(2025, 5, 12, 8, 30, 0, 0, time.Local)},
+		{time.Date(2025, 5, 12, 8, 29, 0, 0, time.Local), time.Date(2025, 5, 5, 8, 30, 0, 0, time.Local)},
+		{time.Date(2025, 5, 12, 8, 30, 0, 0, time.Local), time.Date(2025, 5, 12, 8, 30, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		if got := s.last(tt.now); !got.Equal(tt.expected) {
+			t.Errorf("%v: Expected %v, Got %v", tt.now, tt.expected, got)
+		}
+	}
+}
+
+func TestSendScheduledReport(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	dir := t.TempDir()
+	os.WriteFile(configPath(dir), []byte(`{"email": {"server": "smtp.example.com:587", "username": "me", "password": "secret", "from": "me@example.com", "to": ["boss@example.com"], "weekly": "monday 08:00"}}`), 0600)
+
+	var sent []string
+	prev := sendMail
+	defer func() { sendMail = prev }()
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, addr+" "+from+" "+strings.Join(to, ",")+"\n"+string(msg))
+		return nil
+	}
+
+	// The first check only records the time
+	var buf bytes.Buffer
+	sunday := time.Date(2025, 5, 11, 20, 0, 0, 0, time.Local)
+	if err := sendScheduledReport(db, dir, sunday, &buf); err != nil || len(sent) != 0 {
+		t.Fatalf("Expected no email on the first check, Got %d (%v)", len(sent), err)
+	}
+	monday := time.Date(2025, 5, 12, 8, 0, 0, 0, time.Local)
+	for _, now := range []time.Time{monday.Add(-time.Minute), monday, monday.Add(time.Minute)} {
+		if err := sendScheduledReport(db, dir, now, &buf); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatalf("Expected the report to be sent once, Got %d", len(sent))
+	}
+	for _, expected := range []string{
+		"smtp.example.com:587 me@example.com boss@example.com\n",
+		"Subject: Weekly report for 05/05/2025 - 05/11/2025\r\n",
+		"\r\n\r\nWeekly report for 05/05/2025 - 05/11/2025\r\n\r\nCompleted last week (0)\r\n",
+	} {
+		if !strings.Contains(sent[0], expected) {
+			t.Errorf("Expected the email to contain %q, Got:\n%s", expected, sent[0])
+		}
+	}
+	if buf.String() != "Sent the weekly report to boss@example.com\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	os.WriteFile(configPath(dir), []byte(`{"email": {"server": "smtp.example.com", "from": "me@example.com", "to": ["boss@example.com"]}}`), 0600)
+	if err := sendScheduledReport(db, dir, monday.AddDate(0, 0, 7), &buf); err == nil || !strings.Contains(err.Error(), "must be host:port") {
+		t.Errorf("Expected an invalid server error, Got %v", err)
+	}
+}
+
+func TestFormatLeadTimes(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := func(lead time.Duration, tags ...string) TaskPosition {
+		return TaskPosition{task: Task{Created: created.Format(RFC3339), Completed: created.Add(lead).Format(RFC3339), Tags: tags}}
+	}
+	tasks := []TaskPosition{
+		task(30*time.Minute, "home"),
+		task(2*time.Hour, "work"),
+		task(3*24*time.Hour, "work"),
+		task(9*24*time.Hour, "work"),
+	}
+
+	expected := strings.Join([]string{
+		"       Tasks  Average  Median  p90",
+		"All    4      3.0d     2.0h    9.0d",
+		"+home  1      30m      30m     30m",
+		"+work  3      4.0d     3.0d    9.0d",
+	}, "\n")
+	if got := formatLeadTimes(tasks); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+	if got := formatLeadTimes(nil); got != "No completed tasks" {
+		t.Fatalf(`Expected "No completed tasks", Got %q`, got)
+	}
+}
+
+func TestForecast(t *testing.T) {
+	now := time.Date(2025, 1, 29, 12, 0, 0, 0, time.UTC)
+	completed := func(daysAgo int, tags ...string) TaskPosition {
+		return TaskPosition{task: Task{Status: STATUS.COMPLETE, Completed: now.AddDate(0, 0, -daysAgo).Format(RFC3339), Tags: tags}}
+	}
+	open := func(tags ...string) TaskPosition {
+		return TaskPosition{task: Task{Status: STATUS.INCOMPLETE, Tags: tags}}
+	}
+	archive := []TaskPosition{completed(1, "work"), completed(3, "work"), completed(6), completed(10, "home"), completed(40, "home")}
+	tasks := []TaskPosition{open("work"), open("work"), open("work"), open("home"), open(), open(), completed(0)}
+
+	var tests = []struct {
+		tag      string
+		velocity float64
+		backlog  int
+		clear    time.Time
+	}{
+		{"", 4.0 / 14, 6, now.AddDate(0, 0, 21)},
+		{"work", 2.0 / 14, 3, now.AddDate(0, 0, 21)},
+		{"home", 1.0 / 14, 1, now.AddDate(0, 0, 14)},
+		{"none", 0, 0, time.Time{}},
+	}
+	for _, tt := range tests {
+		f := forecast(tasks, archive, tt.tag, 14, now)
+		if f.Velocity != tt.velocity || f.Backlog != tt.backlog || !f.Clear.Round(time.Minute).Equal(tt.clear) {
+			t.Errorf("%s: Expected %v %d %v, Got %v %d %v", tt.tag, tt.velocity, tt.backlog, tt.clear, f.Velocity, f.Backlog, f.Clear)
+		}
+	}
+}
+
+func TestForecastPoints(t *testing.T) {
+	now := time.Date(2025, 1, 29, 12, 0, 0, 0, time.UTC)
+	task := func(status string, daysAgo, points int) TaskPosition {
+		t := Task{Status: status, Points: points}
+		if status == STATUS.COMPLETE {
+			t.Completed = now.AddDate(0, 0, -daysAgo).Format(RFC3339)
+		}
+		return TaskPosition{task: t}
+	}
+	archive := []TaskPosition{task(STATUS.COMPLETE, 1, 5), task(STATUS.COMPLETE, 2, 3), task(STATUS.COMPLETE, 20, 8)}
+	tasks := []TaskPosition{task(STATUS.INCOMPLETE, 0, 2), task(STATUS.INCOMPLETE, 0, 0), task(STATUS.CANCELLED, 0, 13)}
+
+	f := forecast(tasks, archive, "", 4, now)
+	if f.PointsVelocity != 2 || f.BacklogPoints != 2 {
+		t.Fatalf("Expected 2 points/day and 2 open points, Got %v and %d", f.PointsVelocity, f.BacklogPoints)
+	}
+	if n := sumPoints(archive); n != 16 {
+		t.Fatalf("Expected 16 points, Got %d", n)
+	}
+}
+
+func TestPointsFlags(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	aCmd, _ := setupCmd(newAddCmd, db)
+	aCmd.SetArgs([]string{"estimate", "me", "--points", "3"})
+	aCmd.Execute()
+	if task, _ := getTask(db, 1); task.Points != 3 {
+		t.Fatalf("Expected 3 points, Got %d", task.Points)
+	}
+
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+	uCmd.SetArgs([]string{"1", "--points", "0"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task, _ := getTask(db, 1); task.Points != 0 {
+		t.Fatalf("Expected the points to be removed, Got %d", task.Points)
+	}
+
+	uCmd, _ = setupCmd(newUpdateCmd, db)
+	uCmd.SetArgs([]string{"1", "--points", "-1"})
+	if err := uCmd.Execute(); err == nil {
+		t.Fatal("Expected an error for negative points")
+	}
+}
+
+func TestAssignee(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	for _, args := range [][]string{{"groceries", "--assign", "@alice"}, {"dishes", "--assign", "bob"}, {"taxes"}} {
+		aCmd, _ := setupCmd(newAddCmd, db)
+		aCmd.SetArgs(args)
+		aCmd.Execute()
+	}
+	if task, _ := getTask(db, 1); task.Assignee != "alice" {
+		t.Fatalf("Expected the task to be assigned to alice, Got %q", task.Assignee)
+	}
+
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+	uCmd.SetArgs([]string{"2", "--assign", "none"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task, _ := getTask(db, 2); task.Assignee != "" {
+		t.Fatalf("Expected the task to be unassigned, Got %q", task.Assignee)
+	}
+
+	var tests = []struct {
+		assignee string
+		expected string
+	}{
+		{"Alice", "1: groceries 🔴 @alice"},
+		{"none", "2: dishes 🔴\n3: taxes 🔴"},
+		{"carol", "No tasks"},
+	}
+	for _, tt := range tests {
+		lCmd, buf := setupCmd(newListCmd, db)
+		lCmd.SetArgs([]string{"--assignee", tt.assignee})
+		lCmd.Execute()
+		if got := strings.TrimSpace(buf.String()); got != tt.expected {
+			t.Errorf("--assignee %s: Expected %q, Got %q", tt.assignee, tt.expected, got)
+		}
+	}
+
+	f, err := parseFilter([]string{"assignee:ALICE"}, time.Now())
+	if err != nil || !f.Match(Task{Assignee: "alice"}) || f.Match(Task{}) {
+		t.Errorf("Expected the filter to match tasks assigned to alice (%v)", err)
+	}
+}
+
+func TestFormatArchiveSummary(t *testing.T) {
+	completed := func(d, h int, tags ...string) TaskPosition {
+		return TaskPosition{task: Task{Completed: time.Date(2025, 3, d, h, 0, 0, 0, time.Local).Format(RFC3339), Tags: tags}}
+	}
+	archive := []TaskPosition{
+		completed(14, 9, "work"),
+		completed(2, 9, "home"),
+		completed(14, 17, "work", "home"),
+		completed(20, 9, "work"),
+		{task: Task{Desc: "legacy, never completed"}},
+	}
+
+	expected := strings.Join([]string{
+		"Completions:       5",
+		"First completion:  03/02/2025",
+		"Last completion:   03/20/2025",
+		"Busiest day:       03/14/2025 (2 tasks)",
+		"Per tag:",
+		"  +work            3",
+		"  +home            2",
+	}, "\n")
+	if got := formatArchiveSummary(archive); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestFormatArchiveByDay(t *testing.T) {
+	completed := func(desc string, d, h int) Task {
+		return Task{Desc: desc, Completed: time.Date(2025, 3, d, h, 0, 0, 0, time.Local).Format(RFC3339)}
+	}
+	archive := []TaskPosition{
+		{task: completed("evening", 14, 18), dbKey: 1},
+		{task: completed("older", 2, 9), dbKey: 2},
+		{task: Task{Desc: "legacy"}, dbKey: 3},
+		{task: completed("morning", 14, 8), dbKey: 4},
+	}
+
+	expected := strings.Join([]string{
+		"03/14/2025",
+		"  4: morning",
+		"  1: evening",
+		"",
+		"03/02/2025",
+		"  2: older",
+		"",
+		"Unknown date",
+		"  3: legacy",
+	}, "\n")
+	if got := formatArchiveByDay(archive); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	resetGlobals()
+	now := time.Now()
+
+	var tests = []struct {
+		name     string
+		created  string
+		expected string
+	}{
+		{"Minutes", now.Add(-5 * time.Minute).Format(RFC3339), " 5m"},
+		{"Hours", now.Add(-3 * time.Hour).Format(RFC3339), " 3h"},
+		{"Days", now.Add(-2 * 24 * time.Hour).Format(RFC3339), " 2d"},
+		{"Past the threshold", now.Add(-10 * 24 * time.Hour).Format(RFC3339), " 10d ⚠️"},
+		{"Unknown creation date", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatAge(Task{Created: tt.created}, now)
+			if result != tt.expected {
+				t.Fatalf("Expected %q, Got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestOpenCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	oCmd, _ := setupCmd(newOpenCmd, db)
+
+	var opened []string
+	openExternal = func(target string) error {
+		opened = append(opened, target)
+		return nil
+	}
+
+	insert(db, TASKS_BUCKET, "review PR https://github.com/allmtz/task-cli/pull/1, then merge", nil)
+	insert(db, TASKS_BUCKET, "no link here", nil)
+
+	var input = []struct {
+		name        string
+		input       []string
+		expectError bool
+	}{
+		{"Task with URL", []string{"1"}, false},
+		{"Task without URL", []string{"2"}, true},
+		{"Missing task", []string{"10"}, true},
+		{"No task ID", []string{}, true},
+	}
+
+	for _, tc := range input {
+		t.Run(tc.name, func(t *testing.T) {
+			oCmd.SetArgs(tc.input)
+			err := oCmd.Execute()
+			if tc.expectError != (err != nil) {
+				t.Fatalf("Expected error: %v, Got: %v", tc.expectError, err)
+			}
+		})
+	}
+
+	expected := []string{"https://github.com/allmtz/task-cli/pull/1"}
+	if !reflect.DeepEqual(expected, opened) {
+		t.Fatalf("Expected %v to be opened, Got %v", expected, opened)
+	}
+}
+
+func TestAttachCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	aCmd, _ := setupCmd(newAttachCmd, db)
+	oCmd, _ := setupCmd(newOpenCmd, db)
+	resetGlobals()
+
+	var opened []string
+	openExternal = func(target string) error {
+		opened = append(opened, target)
+		return nil
+	}
+
+	doc := filepath.Join(t.TempDir(), "doc.pdf")
+	os.WriteFile(doc, []byte("pdf"), 0600)
+	insert(db, TASKS_BUCKET, "read the doc", nil)
+
+	aCmd.SetArgs([]string{"1", doc})
+	if err := aCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	task, _ := getTask(db, 1)
+	if !reflect.DeepEqual(task.Attachments, []string{doc}) {
+		t.Fatalf("Expected %v to be attached, Got %v", doc, task.Attachments)
+	}
+
+	aCmd.SetArgs([]string{"1", filepath.Join(t.TempDir(), "missing.pdf")})
+	if err := aCmd.Execute(); err == nil {
+		t.Fatalf("Failed to error when the file does not exist")
+	}
+
+	oCmd.SetArgs([]string{"1", "-a=1"})
+	if err := oCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(opened, []string{doc}) {
+		t.Fatalf("Expected %v to be opened, Got %v", doc, opened)
+	}
+
+	oCmd.SetArgs([]string{"1", "-a=2"})
+	if err := oCmd.Execute(); err == nil {
+		t.Fatalf("Failed to error when the attachment does not exist")
+	}
+}
+
+func TestFormatTaskCard(t *testing.T) {
+	task := Task{
+		Desc:    "buy milk",
+		Status:  STATUS.INCOMPLETE,
+		Created: "2024-03-01T09:30:00Z",
+		Tags:    []string{"groceries", "home"},
+		Due:     "2024-03-02T00:00:00Z",
+		UUID:    "0b7e5a3c-2f43-4c4e-9d8e-3f1a6f2c9b10",
+	}
+	expected := `Task 2
+Description: buy milk
+Tags:        groceries, home
+Status:      incomplete
+Created:     03/01/2024 09:30
+Completed:   -
+Due:         03/02/2024
+Priority:    -
+UUID:        0b7e5a3c-2f43-4c4e-9d8e-3f1a6f2c9b10
+Hash:        928b850`
+
+	result := formatTaskCard(2, task)
+	if result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestCommentCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insert(db, TASKS_BUCKET, "call the landlord", nil)
+	cCmd, _ := setupCmd(newCommentCmd, db)
+	cCmd.SetArgs([]string{"1", "no", "answer"})
+	if err := cCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cCmd.SetArgs([]string{"1"})
+	if err := cCmd.Execute(); err == nil {
+		t.Fatalf("Expected an error without a comment")
+	}
+	task, _ := getTask(db, 1)
+	if len(task.Comments) != 1 || task.Comments[0].Text != "no answer" || task.Comments[0].Time == "" {
+		t.Fatalf("Expected a timestamped comment, Got %v", task.Comments)
+	}
+
+	// Comments are shown oldest first, whatever the offset they were written with
+	task = Task{Desc: "a", Comments: []Comment{
+		{Time: "2024-03-02T10:00:00Z", Text: "third"},
+		{Time: "2024-03-02T11:00:00+03:00", Text: "second"},
+		{Time: "2024-03-01T10:00:00Z", Text: "first"},
+	}}
+	card := formatTaskCard(1, task)
+	if !strings.HasSuffix(card, "Comments:    03/01/2024 10:00 first\n             03/02/2024 08:00 second\n             03/02/2024 10:00 third") {
+		t.Fatalf("Expected the comments in order, Got:\n%s", card)
+	}
+}
+
+func TestTaskDir(t *testing.T) {
+	home := t.TempDir()
+	// os.UserHomeDir reads $HOME on Unix, %USERPROFILE% on Windows and $home on Plan 9
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("home", home)
+
+	dir, err := taskDir()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := filepath.Join(home, "task"); dir != expected {
+		t.Fatalf("Expected %s, Got %s", expected, dir)
+	}
+	if expected := filepath.Join(home, "task", "tasks.db"); dbPath(dir) != expected {
+		t.Fatalf("Expected %s, Got %s", expected, dbPath(dir))
+	}
+}
+
+func TestNewBoltConnection(t *testing.T) {
+	dir := t.TempDir()
+
+	// Hold the lock like another running command would
+	db, err := newBoltConnection(dir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = newBoltConnection(dir, false)
+	if err == nil || !strings.Contains(err.Error(), "locked by another process") {
+		t.Fatalf("Expected a lock error, Got %v", err)
+	}
+	db.Close()
+
+	// Read-only connections share the lock
+	first, err := newBoltConnection(dir, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer first.Close()
+	second, err := newBoltConnection(dir, true)
+	if err != nil {
+		t.Fatalf("Failed to open a second read-only connection: %v", err)
+	}
+	second.Close()
+}
+
+func TestDaemon(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+	mgr := &connectionManager{db: db}
+
+	sock := filepath.Join(t.TempDir(), "task.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	go serveDaemon(mgr, l)
+
+	// Errors are kept apart from the output, as they go to stderr
+	var tests = []struct {
+		args         []string
+		expectedOut  string
+		expectedErr  string
+		expectedCode int
+	}{
+		{[]string{"add", "from the daemon +ipc"}, "Added task: 'from the daemon'\n", "", 0},
+		{[]string{"+ipc"}, "1: from the daemon 🔴\n", "", 0},
+		{[]string{"count"}, "1 tasks\n", "", 0},
+		{[]string{"delete", "x"}, "", "Error: Invalid task ID \"x\"\n", 1},
+		// Searches exit like grep
+		{[]string{"+nothing"}, "No tasks\n", "", 1},
+		{[]string{"list", "-g", "x"}, "", "Error: Invalid grouping \"x\", must be one of tag, priority, due\n", 2},
+		{[]string{"due:x"}, "", "Error: Can't understand the date \"x\"\n", 2},
+	}
+
+	for _, tt := range tests {
+		res, err := sendToDaemon(sock, daemonRequest{Args: tt.args})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if res.Stdout != tt.expectedOut || res.Stderr != tt.expectedErr || res.Code != tt.expectedCode {
+			t.Errorf("%v: Expected %q, %q (%d), Got %q, %q (%d)", tt.args, tt.expectedOut, tt.expectedErr, tt.expectedCode, res.Stdout, res.Stderr, res.Code)
+		}
+	}
+}
+
+func TestDaemonStdin(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	mgr := &connectionManager{db: db}
+
+	sock, _ := socketPath()
+	os.MkdirAll(filepath.Dir(sock), 0777)
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	go serveDaemon(mgr, l)
+
+	// Input piped to the CLI reaches the commands reading stdin
+	var tests = []struct {
+		args     []string
+		in       string
+		expected []string
+	}{
+		{[]string{"batch"}, `{"op":"add","desc":"from batch"}`, []string{"from batch"}},
+		{[]string{"import", "-f", "csv"}, "description\nfrom import\n", []string{"from batch", "from import"}},
+		{[]string{"db", "load", "-", "-y"}, `{"format":"task-cli","version":1,"tasks":[{"ID":1,"Desc":"from load"}]}`, []string{"from load"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		code, ok := delegate(tt.args, strings.NewReader(tt.in), &buf)
+		if !ok || code != 0 {
+			t.Fatalf("%v: Expected the daemon to run the command, Got %v (%d) %q", tt.args, ok, code, buf.String())
+		}
+		var descs []string
+		for _, task := range getTasks(db, TASKS_BUCKET) {
+			descs = append(descs, task.task.Desc)
+		}
+		if strings.Join(descs, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("%v: Expected tasks %v, Got %v", tt.args, tt.expected, descs)
+		}
+	}
+}
+
+func TestDaemonCompletion(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	sock, _ := socketPath()
+	os.MkdirAll(filepath.Dir(sock), 0777)
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	go serveDaemon(&connectionManager{db: db}, l)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("buy milk", nil)})
+
+	// The daemon holds the db, so it completes the task IDs
+	var buf bytes.Buffer
+	code, ok := delegate([]string{cobra.ShellCompRequestCmd, "do", ""}, nil, &buf)
+	if !ok || code != 0 || buf.String() != "1\tbuy milk\n:4\n" {
+		t.Fatalf("Expected the daemon to complete the task IDs, Got %v (%d) %q", ok, code, buf.String())
+	}
+
+	// It has no daemon command of its own to complete
+	if _, ok := delegate([]string{cobra.ShellCompRequestCmd, "daemon", ""}, nil, &buf); ok {
+		t.Fatalf("Expected the daemon's subcommands to complete in the CLI")
+	}
+}
+
+func TestDaemonLostConnection(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	sock, _ := socketPath()
+	os.MkdirAll(filepath.Dir(sock), 0777)
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	// A daemon stopped while running the command closes the connection without a response
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		json.NewDecoder(conn).Decode(&daemonRequest{})
+		conn.Close()
+	}()
+
+	// The command may have run, so it isn't run again in the CLI. The error goes to stderr
+	var buf bytes.Buffer
+	code, ok := delegate([]string{"delete", "1"}, nil, &buf)
+	if !ok || code != 1 || buf.Len() > 0 {
+		t.Fatalf("Expected the lost connection to be reported, Got %v (%d) %q", ok, code, buf.String())
+	}
+}
+
+func TestDaemonEnv(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	// The daemon's own environment is ignored
+	t.Setenv("TASK_DEFAULTS", `{"list": ["-t"]}`)
+
+	sock := filepath.Join(t.TempDir(), "task.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	go serveDaemon(&connectionManager{db: db}, l)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", []string{"x"})})
+
+	var tests = []struct {
+		env      map[string]string
+		expected string
+	}{
+		{map[string]string{"TASK_DEFAULTS": `{"list": ["--ids"]}`}, "1\n"},
+		{nil, "1: a 🔴\n"},
+		{map[string]string{"TASK_TRACK_TIME": "yes"}, "Error: TASK_TRACK_TIME: Invalid value \"yes\" for track_time, must be JSON such as true, 10 or [\"a\", \"b\"]\n"},
+	}
+	for _, tt := range tests {
+		res, err := sendToDaemon(sock, daemonRequest{Args: []string{"list"}, Env: tt.env})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if out := res.Stdout + res.Stderr; out != tt.expected {
+			t.Errorf("%v: Expected %q, Got %q", tt.env, tt.expected, out)
+		}
+	}
+}
+
+func TestTaskDB(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	path := filepath.Join(t.TempDir(), "ci", "x.db")
+	t.Setenv("TASK_DB", path)
+
+	mgr := newBoltManager()
+	if err := mgr.Open(false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mgr.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected the database at $TASK_DB, Got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, "task", "tasks.db")); err == nil {
+		t.Fatal("Expected no database in the task directory")
+	}
+	// A daemon serves only the database it was started with
+	if sock, _ := socketPath(); sock != path+".sock" {
+		t.Fatalf("Expected the socket next to the database, Got %s", sock)
+	}
+}
+
+func TestDetectLocale(t *testing.T) {
+	var tests = []struct {
+		lcAll, lang, expected string
+	}{
+		{"", "es_MX.UTF-8", "es"},
+		{"", "ja_JP.UTF-8", "ja"},
+		{"ja_JP.UTF-8", "es_ES.UTF-8", "ja"},
+		{"", "C", "en"},
+		{"", "", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tt.lang)
+		if l := detectLocale(); l != tt.expected {
+			t.Errorf("LC_ALL=%q LANG=%q: Expected %s, Got %s", tt.lcAll, tt.lang, tt.expected, l)
+		}
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	// Translations must keep the format verbs of the original message, in order
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for lang, messages := range translations {
+		for msg, translated := range messages {
+			expected := verbs.FindAllString(msg, -1)
+			got := verbs.FindAllString(translated, -1)
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("%s: %q has verbs %v, expected %v", lang, translated, got, expected)
+			}
+		}
+	}
+
+	defer func() { locale = "en" }()
+	locale = "es"
+	if msg := tr("No tasks"); msg != "No hay tareas" {
+		t.Fatalf("Expected Spanish translation, Got %s", msg)
+	}
+	if msg := tr("An untranslated message"); msg != "An untranslated message" {
+		t.Fatalf("Expected untranslated message to fall back to English, Got %s", msg)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	var tests = []struct {
+		input,
+		tag,
+		output string
+	}{
+		{"no tag", "", "no tag"},
+		{"+start house", "start", "house"},
+		{"car +end", "end", "car"},
+		{"+shop milk eggs + stuff", "shop", "milk eggs + stuff"},
+		// pick the first tag from left, but still remove all tags
+		{"+a +b +c", "a", ""},
+		// trim whitespace
+		{"  +trim me  ", "trim", "me"},
+		// don't leave extra whitespace when removing tag
+		{"a +middle c", "middle", "a c"},
+		// only trim 1 whitespace preceding the tag
+		{"d  +middle e", "middle", "d  e"},
+	}
+
+	for _, tt := range tests {
+		testName := fmt.Sprintf("%v", tt.input)
+		t.Run(testName, func(t *testing.T) {
+			parsedTags, parsedStr := parseTags(tt.input)
+			var tag string
+			if len(parsedTags) >= 1 {
+				tag = parsedTags[0]
+			} else {
+				tag = ""
+			}
+
+			if tag != tt.tag {
+				t.Errorf("Wrong tag, Expected: %v, Got: %v", tt.tag, tag)
+			}
+			if parsedStr != tt.output {
+				t.Errorf("Wrong output, Expected: %v, Got: %v", tt.output, parsedStr)
+			}
+		})
+	}
+}
+
+func TestTagStats(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	open := []struct {
+		desc string
+		tags []string
+	}{{"a", []string{"work"}}, {"b", []string{"work", "home"}}, {"c", nil}}
+	for _, o := range open {
+		if err := insert(db, TASKS_BUCKET, o.desc, o.tags); err != nil {
+			t.Fatalf("Failed to insert into db: %v", err)
+		}
+	}
+	completed := time.Now().Format(RFC3339)
+	addToArchive(db, []Task{
+		{Desc: "e", Status: STATUS.COMPLETE, Completed: completed, Tags: []string{"work"}},
+		{Desc: "f", Status: STATUS.COMPLETE, Completed: completed, Tags: []string{"errands"}},
+		{Desc: "g", Status: STATUS.COMPLETE, Completed: completed, Tags: []string{"errands"}},
+	})
+
+	expected := []struct {
+		name           string
+		open, archived int
+	}{{"work", 2, 1}, {"errands", 0, 2}, {"home", 1, 0}}
+
+	stats := getTagStats(db)
+	if len(stats) != len(expected) {
+		t.Fatalf("Got %d tags, expected %d", len(stats), len(expected))
+	}
+	for i, e := range expected {
+		s := stats[i]
+		if s.Name != e.name || s.Open != e.open || s.Archived != e.archived {
+			t.Fatalf("Expected %s (open: %d, archived: %d), Got %s (open: %d, archived: %d)", e.name, e.open, e.archived, s.Name, s.Open, s.Archived)
+		}
+		if s.LastUsed.IsZero() {
+			t.Fatalf("Missing last used date for %s", s.Name)
+		}
+	}
+}
+
+func TestCountTasks(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	yesterday := time.Now().Add(-24 * time.Hour).Format(RFC3339)
+	tomorrow := time.Now().Add(24 * time.Hour).Format(RFC3339)
+	tasks := []Task{
+		{Desc: "a", Status: STATUS.INCOMPLETE, Tags: []string{"work"}, Due: yesterday},
+		{Desc: "b", Status: STATUS.COMPLETE, Tags: []string{"work"}, Due: yesterday},
+		{Desc: "c", Status: STATUS.INCOMPLETE, Tags: []string{"home"}, Due: tomorrow},
+		{Desc: "d", Status: STATUS.INCOMPLETE},
+	}
+	for _, task := range tasks {
+		if err := insertTask(db, TASKS_BUCKET, task); err != nil {
+			t.Fatalf("Failed to insert into db: %v", err)
+		}
+	}
+
+	var tests = []struct {
+		name     string
+		tag      string
+		status   string
+		overdue  bool
+		expected int
+	}{
+		{"Tag", "work", "", false, 2},
+		{"Status", "", STATUS.INCOMPLETE, false, 3},
+		{"Tag and status", "work", STATUS.INCOMPLETE, false, 1},
+		{"Overdue", "", "", true, 1},
+		{"Unused tag", "none", "", false, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			count := countTasks(db, TASKS_BUCKET, tc.tag, tc.status, tc.overdue, nil)
+			if count != tc.expected {
+				t.Fatalf("Got %d tasks, expected %d", count, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCountCmdJSON(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	cCmd, buf := setupCmd(newCountCmd, db)
+	resetGlobals()
+
+	for _, s := range []string{"a", "b", "c"} {
+		insert(db, TASKS_BUCKET, s, nil)
+	}
+	completeTask(1, db, io.Discard)
+	addToArchive(db, []Task{{Desc: "d", Status: STATUS.COMPLETE}})
+
+	cCmd.SetArgs([]string{"--json"})
+	if err := cCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `{"open":2,"completed":1,"archived":1}` + "\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected %s, Got %s", expected, buf.String())
+	}
+}
+
+func TestCountCmdTags(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", []string{"work"}), newTask("b", []string{"work", "urgent"}), newTask("c", nil)})
+	var tests = []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"+work"}, "2 tasks\n"},
+		{[]string{"+work", "+urgent"}, "1 tasks\n"},
+		{[]string{"+none"}, "0 tasks\n"},
+	}
+	for _, tt := range tests {
+		cCmd, buf := setupCmd(newCountCmd, db)
+		cCmd.SetArgs(tt.args)
+		cCmd.Execute()
+		if buf.String() != tt.expected {
+			t.Errorf("%v: Expected %q, Got %q", tt.args, tt.expected, buf.String())
+		}
+	}
+
+	// Other arguments aren't ignored
+	cCmd, _ := setupCmd(newCountCmd, db)
+	cCmd.SetArgs([]string{"work"})
+	if err := cCmd.Execute(); err == nil || err.Error() != `Tags must be in the form +tag, got "work"` {
+		t.Fatalf("Expected an error for an argument that isn't a tag, Got %v", err)
+	}
+}
+
+func TestStatsCmdTag(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+
+	sCmd, buf := setupCmd(newStatsCmd, db)
+	resetGlobals()
+
+	completed := time.Now().Add(-time.Hour).Format(RFC3339)
+	addToArchive(db, []Task{
+		{Desc: "a", Status: STATUS.COMPLETE, Completed: completed, Tags: []string{"work"}},
+		{Desc: "b", Status: STATUS.COMPLETE, Completed: completed, Tags: []string{"home"}},
+		{Desc: "c", Status: STATUS.COMPLETE, Completed: completed},
+	})
+
+	var tests = []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{}, "You completed 3 tasks"},
+		{[]string{"-t=work"}, "You completed 1 tasks"},
+		{[]string{"-t=+work,home"}, "You completed 2 tasks"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		StatsTags = ""
+		sCmd.SetArgs(tt.args)
+		if err := sCmd.Execute(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(buf.String(), tt.expected) {
+			t.Errorf("%v: Expected %q in %q", tt.args, tt.expected, buf.String())
+		}
+	}
+}
+
+func TestArchiveExportCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+	resetArchive(db)
+
+	eCmd, buf := setupCmd(newArchiveExportCmd, db)
+	completed := func(m time.Month, d int) string {
+		return time.Date(2025, m, d, 12, 0, 0, 0, time.Local).Format(RFC3339)
+	}
+	addToArchive(db, []Task{
+		{Desc: "april, with a comma", Status: STATUS.COMPLETE, Completed: completed(4, 30), Tags: []string{"a", "b"}},
+		{Desc: "may", Status: STATUS.COMPLETE, Completed: completed(5, 1)},
+	})
+
+	var tests = []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-f=csv"}, "description,status,created,completed,due,tags\n" +
+			`"april, with a comma",complete,,` + completed(4, 30) + ",,a b\n" +
+			"may,complete,," + completed(5, 1) + ",,\n"},
+		{[]string{"-f=csv", "-s=05/01/2025"}, "description,status,created,completed,due,tags\n" +
+			"may,complete,," + completed(5, 1) + ",,\n"},
+		{[]string{"-e=04/30/2025"}, "[\n  {\n    \"Desc\": \"april, with a comma\""},
+		{[]string{"-s=06/01/2025"}, "[]\n"},
+	}
+	for _, tt := range tests {
+		resetGlobals()
+		buf.Reset()
+		eCmd.SetArgs(tt.args)
+		if err := eCmd.Execute(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(buf.String(), tt.expected) {
+			t.Errorf("%v: Expected %q, Got %q", tt.args, tt.expected, buf.String())
+		}
+	}
+
+	resetGlobals()
+	eCmd.SetArgs([]string{"-f=xml"})
+	if err := eCmd.Execute(); err == nil {
+		t.Fatalf("Failed to error on an invalid format")
+	}
+}
+
+func TestArchiveRestoreCmd(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+	resetArchive(db)
+	resetTasks(db)
+
+	rCmd, _ := setupCmd(newArchiveRestoreCmd, db)
+	completed := func(desc string, d int) Task {
+		return Task{Desc: desc, Status: STATUS.COMPLETE, Completed: time.Date(2025, 5, d, 12, 0, 0, 0, time.Local).Format(RFC3339)}
+	}
+	insert(db, TASKS_BUCKET, "open", nil)
+	addToArchive(db, []Task{completed("april", -1), completed("may 1", 1), completed("may 3", 3), completed("may 9", 9)})
+
+	rCmd.SetArgs([]string{})
+	if err := rCmd.Execute(); err == nil {
+		t.Fatalf("Failed to error without --since")
+	}
+
+	resetGlobals()
+	rCmd.SetArgs([]string{"--since=05/01/2025", "--until=05/03/2025"})
+	if err := rCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, t := range getTasks(db, TASKS_BUCKET) {
+		got = append(got, t.task.Desc+" "+t.task.Status+" "+t.task.Completed)
+	}
+	expected := []string{"open incomplete ", "may 1 incomplete ", "may 3 incomplete "}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected tasks %v, Got %v", expected, got)
+	}
+
+	var archived []string
+	for _, t := range getTasks(db, ARCHIVE_BUCKET) {
+		archived = append(archived, fmt.Sprintf("%d: %s", t.dbKey, t.task.Desc))
+	}
+	if !reflect.DeepEqual(archived, []string{"1: april", "2: may 9"}) {
+		t.Fatalf("Expected april and may 9 to stay archived, Got %v", archived)
+	}
+}
+
+func TestDeleteCmdTag(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+
+	dCmd, buf := setupCmd(newDeleteCmd, db)
+
+	var tests = []struct {
+		args      []string
+		input     string
+		remaining int
+	}{
+		{[]string{"-t=spam"}, "n\n", 3},
+		{[]string{"-t=spam"}, "", 3},
+		{[]string{"-t=+spam"}, "y\n", 1},
+		{[]string{"-t=spam", "-y"}, "", 1},
+	}
+	for _, tt := range tests {
+		resetGlobals()
+		resetTasks(db)
+		insertTasks(db, TASKS_BUCKET, []Task{newTask("a", []string{"spam"}), newTask("b", nil), newTask("c", []string{"spam", "x"})})
+
+		buf.Reset()
+		dCmd.SetIn(strings.NewReader(tt.input))
+		dCmd.SetArgs(tt.args)
+		if err := dCmd.Execute(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// -y skips the prompt
+		if prompted := strings.Contains(buf.String(), "Delete 2 tasks tagged +spam? [y/N]"); prompted == DeleteYes {
+			t.Errorf("%v: Unexpected output %q", tt.args, buf.String())
+		}
+		if n := getCount(db, TASKS_BUCKET); n != tt.remaining {
+			t.Errorf("%v with input %q: Expected %d remaining tasks, Got %d", tt.args, tt.input, tt.remaining, n)
+		}
+	}
+
+	resetGlobals()
+	dCmd.SetArgs([]string{"1", "-t=spam"})
+	if err := dCmd.Execute(); err == nil {
+		t.Fatalf("Failed to error when IDs and a tag are both given")
+	}
+}
+
+func TestDeleteInteractive(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+
+	var tests = []struct {
+		name      string
+		args      []string
+		input     string
+		remaining []string
+	}{
+		{"Arrows and space", []string{"-i"}, "\x1b[B \x1b[B \r", []string{"a"}},
+		{"j and k", []string{"-i"}, "jjk \n", []string{"a", "c"}},
+		{"Toggled twice", []string{"-i"}, "  \r", []string{"a", "b", "c"}},
+		{"Quit", []string{"-i"}, " q", []string{"a", "b", "c"}},
+		{"Escape", []string{"-i"}, " \x1b", []string{"a", "b", "c"}},
+		{"No input", []string{"-i"}, "", []string{"a", "b", "c"}},
+		{"Tag", []string{"-i", "-t=spam"}, "j \r", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetGlobals()
+			resetTasks(db)
+			insertTasks(db, TASKS_BUCKET, []Task{newTask("a", []string{"spam"}), newTask("b", nil), newTask("c", []string{"spam"})})
+
+			dCmd, buf := setupCmd(newDeleteCmd, db)
+			dCmd.SetIn(strings.NewReader(tt.input))
+			dCmd.SetArgs(tt.args)
+			if err := dCmd.Execute(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var remaining []string
+			for _, tp := range getTasks(db, TASKS_BUCKET) {
+				remaining = append(remaining, tp.task.Desc)
+			}
+			if !reflect.DeepEqual(tt.remaining, remaining) {
+				t.Errorf("Expected %v to remain, Got %v. Output: %q", tt.remaining, remaining, buf.String())
+			}
+			if len(remaining) == 3 && !strings.Contains(buf.String(), "Aborted, nothing was deleted") {
+				t.Errorf("Expected the deletion to be aborted, Got %q", buf.String())
+			}
+		})
+	}
+
+	resetGlobals()
+	dCmd, buf := setupCmd(newDeleteCmd, db)
+	dCmd.SetIn(strings.NewReader("\r"))
+	dCmd.SetArgs([]string{"-i"})
+	dCmd.Execute()
+	if !strings.Contains(buf.String(), "> [ ] 1: a\n  [ ] 2: b") {
+		t.Errorf("Expected a checkbox list, Got %q", buf.String())
+	}
+
+	resetGlobals()
+	dCmd, _ = setupCmd(newDeleteCmd, db)
+	dCmd.SetArgs([]string{"-i", "1"})
+	if err := dCmd.Execute(); err == nil {
+		t.Error("Failed to error when IDs are given with -i")
+	}
+}
+
+func TestSortInteractive(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	defer resetGlobals()
+
+	var tests = []struct {
+		name     string
+		input    string
+		expected []Task
+		changed  string
+	}{
+		{"Priorities", "h\rm\rl", []Task{{Priority: "high"}, {Priority: "med"}, {Priority: "low", Tags: []string{"x"}}}, "Changed 3 of 3 tasks"},
+		{"No priority and back", "h\x1b[C0\x1b[Dl", []Task{{Priority: "low"}, {}, {Tags: []string{"x"}}}, "Changed 1 of 3 tasks"},
+		{"Due date", "d2026-03-04\r\rdnone\r", []Task{{Due: "2026-03-04"}, {}, {Tags: []string{"x"}}}, "Changed 1 of 3 tasks"},
+		{"Invalid due date", "dsoon\rq", []Task{{}, {}, {Tags: []string{"x"}}}, "Changed 0 of 3 tasks"},
+		{"Cancelled due date", "d2026\x1bq", []Task{{}, {}, {Tags: []string{"x"}}}, "Changed 0 of 3 tasks"},
+		{"Tags", "\r\rtwork +home -x\rtworx\x7fk\r", []Task{{}, {}, {Tags: []string{"work", "home"}}}, "Changed 1 of 3 tasks"},
+		{"Quit", "hqh", []Task{{Priority: "high"}, {}, {Tags: []string{"x"}}}, "Changed 1 of 3 tasks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetGlobals()
+			resetTasks(db)
+			insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil), newTask("c", []string{"x"})})
+
+			sCmd, buf := setupCmd(newSortCmd, db)
+			sCmd.SetIn(strings.NewReader(tt.input))
+			sCmd.SetArgs([]string{"-i"})
+			if err := sCmd.Execute(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.changed) {
+				t.Errorf("Expected %q, Got %q", tt.changed, buf.String())
+			}
+			for i, tp := range getTasks(db, TASKS_BUCKET) {
+				due := ""
+				if tp.task.Due != "" {
+					due = formatTimestamp(tp.task.Due, "2006-01-02")
+				}
+				if tp.task.Priority != tt.expected[i].Priority || due != tt.expected[i].Due || strings.Join(tp.task.Tags, ",") != strings.Join(tt.expected[i].Tags, ",") {
+					t.Errorf("Task %d: Expected %+v, Got %+v", tp.dbKey, tt.expected[i], tp.task)
+				}
+			}
+		})
+	}
+
+	// Every change is kept in the history
+	resetTasks(db)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil)})
+	sCmd, buf := setupCmd(newSortCmd, db)
+	sCmd.SetIn(strings.NewReader("hlt+x\r"))
+	sCmd.SetArgs([]string{"-i"})
+	sCmd.Execute()
+	if task, _ := getTask(db, 1); len(task.History) != 3 {
+		t.Errorf("Expected 3 changes in the history, Got %+v", task.History)
+	}
+	if !strings.Contains(buf.String(), "Task 1 of 1\n1: a 🔴\ntags: -  due: -  priority: -") {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	resetGlobals()
+	sCmd, _ = setupCmd(newSortCmd, db)
+	sCmd.SetArgs([]string{})
+	if err := sCmd.Execute(); err == nil {
+		t.Error("Failed to error without -i")
+	}
+}
+
+func TestSnoozeDue(t *testing.T) {
+	now := time.Date(2026, 5, 10, 15, 0, 0, 0, time.Local)
+	var tests = []struct {
+		due, expected string
+	}{
+		{"", "2026-05-17"},
+		{timestamp(time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local)), "2026-05-17"},
+		{timestamp(time.Date(2026, 5, 20, 0, 0, 0, 0, time.Local)), "2026-05-27"},
+	}
+	for _, tt := range tests {
+		if got := formatTimestamp(snoozeDue(Task{Due: tt.due}, now), "2006-01-02"); got != tt.expected {
+			t.Errorf("Snoozing %q: Expected %s, Got %s", tt.due, tt.expected, got)
+		}
+	}
+}
+
+func TestClearCmdFlags(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+
+	cCmd, _ := setupCmd(newClearCmd, db)
+
+	var input = []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{"-t", []string{"-t=errands"}, []string{"b", "d"}},
+		{"-c", []string{"-c"}, []string{"a", "d"}},
+		{"-t and -c", []string{"-t=errands", "-c"}, []string{"a", "b", "d"}},
+		{"No flag", []string{}, nil},
+	}
+
+	for _, tc := range input {
+		resetGlobals()
+		resetTasks(db)
+		insert(db, TASKS_BUCKET, "a", []string{"errands"})
+		insert(db, TASKS_BUCKET, "b", []string{"work"})
+		insert(db, TASKS_BUCKET, "c", []string{"errands"})
+		insert(db, TASKS_BUCKET, "d", nil)
+		completeTask(2, db, io.Discard)
+		completeTask(3, db, io.Discard)
+
+		t.Run(tc.name, func(t *testing.T) {
+			cCmd.SetArgs(tc.input)
+			if err := cCmd.Execute(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result []string
+			db.View(func(tx *bolt.Tx) error {
+				b := tx.Bucket(TASKS_BUCKET)
+				if b == nil {
+					return nil
+				}
+				return b.ForEach(func(k, v []byte) error {
+					result = append(result, bToTask(v).Desc)
+					return nil
+				})
+			})
+			if !reflect.DeepEqual(tc.expected, result) {
+				t.Fatalf("Expected %v, Got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFilterTasks(t *testing.T) {
+	tp := []TaskPosition{
+		{task: Task{Desc: "a", Tags: []string{"work"}}, dbKey: 1},
+		{task: Task{Desc: "b", Tags: []string{"work", "urgent"}}, dbKey: 2},
+		{task: Task{Desc: "c", Tags: []string{"home"}}, dbKey: 3},
+		{task: Task{Desc: "d"}, dbKey: 4},
+	}
+
+	var tests = []struct {
+		name     string
+		include  []string
+		exclude  []string
+		matchAll bool
+		expected []string
+	}{
+		{"No filter", nil, nil, false, []string{"a", "b", "c", "d"}},
+		{"Single tag", []string{"work"}, nil, false, []string{"a", "b"}},
+		{"Any tag", []string{"urgent", "home"}, nil, false, []string{"b", "c"}},
+		{"All tags", []string{"work", "urgent"}, nil, true, []string{"b"}},
+		{"None tag", []string{"none"}, nil, false, []string{"d"}},
+		{"Exclude", nil, []string{"urgent", "none"}, false, []string{"a", "c"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var result []string
+			for _, f := range filterTasks(tp, tc.include, tc.exclude, tc.matchAll) {
+				result = append(result, f.task.Desc)
+			}
+			if !reflect.DeepEqual(tc.expected, result) {
+				t.Fatalf("Expected %v, Got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGroupTasks(t *testing.T) {
+	tp := []TaskPosition{
+		{task: Task{Desc: "a", Tags: []string{"work"}, Status: STATUS.INCOMPLETE, Priority: "low"}, dbKey: 1},
+		{task: Task{Desc: "b", Status: STATUS.INCOMPLETE}, dbKey: 2},
+		{task: Task{Desc: "c", Tags: []string{"home", "work"}, Status: STATUS.COMPLETE, Priority: "high"}, dbKey: 3},
+		{task: Task{Desc: "d", Status: STATUS.INCOMPLETE, Priority: "low", Due: "2024-03-09T00:00:00Z"}, dbKey: 4},
+		{task: Task{Desc: "e", Status: STATUS.INCOMPLETE, Due: "2024-03-06T00:00:00Z"}, dbKey: 5},
+		{task: Task{Desc: "f", Status: STATUS.INCOMPLETE, Due: "2024-03-01T00:00:00Z"}, dbKey: 6},
+		{task: Task{Desc: "g", Status: STATUS.INCOMPLETE, Due: "2024-03-02T00:00:00Z"}, dbKey: 7},
+	}
+	now := time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		by       string
+		expected string
+	}{
+		{"tag", "+home (1)\n3: c ✅\n\n+work (2)\n1: a 🔴\n3: c ✅\n\nUntagged (5)\n2: b 🔴\n4: d 🔴\n5: e 🔴\n6: f 🔴\n7: g 🔴"},
+		{"priority", "High (1)\n3: c ✅\n\nLow (2)\n1: a 🔴\n4: d 🔴\n\nNo priority (4)\n2: b 🔴\n5: e 🔴\n6: f 🔴\n7: g 🔴"},
+		{"due", "Overdue (1)\n6: f 🔴\n\nToday (1)\n7: g 🔴\n\nThis week (1)\n5: e 🔴\n\nLater (1)\n4: d 🔴\n\nNo due date (3)\n1: a 🔴\n2: b 🔴\n3: c ✅"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			result := formatGroups(groupTasks(tp, tt.by, now))
+			if result != tt.expected {
+				t.Fatalf("Expected %q, Got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatTree(t *testing.T) {
+	tp := []TaskPosition{
+		{task: Task{Desc: "trip", Status: STATUS.INCOMPLETE, UUID: "a"}, dbKey: 1},
+		{task: Task{Desc: "flights", Status: STATUS.COMPLETE, UUID: "b", Parent: "a"}, dbKey: 2},
+		{task: Task{Desc: "milk", Status: STATUS.INCOMPLETE, UUID: "c"}, dbKey: 3},
+		{task: Task{Desc: "hotel", Status: STATUS.INCOMPLETE, UUID: "d", Parent: "a"}, dbKey: 4},
+		{task: Task{Desc: "prices", Status: STATUS.INCOMPLETE, UUID: "e", Parent: "d"}, dbKey: 5},
+		// The parent isn't listed
+		{task: Task{Desc: "orphan", Status: STATUS.INCOMPLETE, UUID: "f", Parent: "z"}, dbKey: 6},
+	}
+	expected := `1: trip 🔴 50%
+├── 2: flights ✅
+└── 4: hotel 🔴 0%
+    └── 5: prices 🔴
+3: milk 🔴
+6: orphan 🔴`
+
+	if result := formatTree(tp); result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	// Wrapped lines line up with the description and keep the branch going
+	terminalWidth = func() int { return 20 }
+	defer func() { terminalWidth = func() int { return 0 } }()
+	tp = []TaskPosition{
+		{task: Task{Desc: "plan the whole trip", Status: STATUS.INCOMPLETE, UUID: "a"}, dbKey: 1},
+		{task: Task{Desc: "book the flights", Status: STATUS.INCOMPLETE, UUID: "b", Parent: "a"}, dbKey: 2},
+	}
+	expected = `1: plan the whole
+│  trip 🔴 0%
+└── 2: book the
+       flights 🔴`
+	if result := formatTree(tp); result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestParentFlags(t *testing.T) {
+	resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", nil)})
+	aCmd, _ := setupCmd(newAddCmd, db)
+	aCmd.SetArgs([]string{"c", "--parent", "2"})
+	if err := aCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tasks := getTasks(db, TASKS_BUCKET)
+	if tasks[2].task.Parent != tasks[1].task.UUID {
+		t.Fatalf("Expected task 3 to be a subtask of task 2, Got parent %q", tasks[2].task.Parent)
+	}
+
+	// A missing parent is an error and adds nothing
+	aCmd.SetArgs([]string{"d", "--parent", "9"})
+	if err := aCmd.Execute(); err == nil {
+		t.Fatalf("Expected an error adding a subtask of a missing task")
+	}
+	AddParent = ""
+	if tasks = getTasks(db, TASKS_BUCKET); len(tasks) != 3 {
+		t.Fatalf("Expected 3 tasks, Got %d", len(tasks))
+	}
+
+	// 2 is the parent of 3, so 2 can't become a subtask of 3
+	uCmd, _ := setupCmd(newUpdateCmd, db)
+	uCmd.SetArgs([]string{"2", "--parent", "3"})
+	if err := uCmd.Execute(); err == nil {
+		t.Fatalf("Expected an error making a task a subtask of its subtask")
+	}
+	UpdateParent = ""
+
+	uCmd.SetArgs([]string{"2", "--parent", "1"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	UpdateParent = ""
+	uCmd.SetArgs([]string{"3", "--parent", "none"})
+	if err := uCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tasks = getTasks(db, TASKS_BUCKET)
+	if tasks[1].task.Parent != tasks[0].task.UUID || tasks[2].task.Parent != "" {
+		t.Fatalf("Expected task 2 under task 1 and task 3 at the top level, Got %q and %q", tasks[1].task.Parent, tasks[2].task.Parent)
+	}
+}
+
+func TestExpandShorthand(t *testing.T) {
+	var tests = []struct {
+		input, expected []string
+	}{
+		{[]string{"+work"}, []string{"list", "+work"}},
+		{[]string{"+work", "+home", "--all"}, []string{"list", "+work", "+home", "--all"}},
+		{[]string{"add", "+work", "x"}, []string{"add", "+work", "x"}},
+		{[]string{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		result := expandShorthand(tt.input)
+		if !reflect.DeepEqual(tt.expected, result) {
+			t.Fatalf("Expected %v, Got %v", tt.expected, result)
+		}
+	}
+}
+
+func TestParseCommandLine(t *testing.T) {
+	root := newRootCmd()
+	root.AddCommand(newSubcommands(&connectionManager{}, io.Discard)...)
+	var tests = []struct {
+		input, expected []string
+		filtered        bool
+	}{
+		{[]string{"+work", "priority:high", "do"}, []string{"do"}, true},
+		{[]string{"due.before:tomorrow", "list", "-t"}, []string{"list", "-t"}, true},
+		{[]string{"-home", "--tree"}, []string{"list", "--tree"}, true},
+		{[]string{"+work", "+home", "--all"}, []string{"list", "+work", "+home", "--all"}, false},
+		{[]string{"add", "+work", "x"}, []string{"add", "+work", "x"}, false},
+		{[]string{"-h"}, []string{"-h"}, false},
+	}
+
+	for _, tt := range tests {
+		args, filter, err := parseCommandLine(root, tt.input)
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(tt.expected, args) || (len(filter) > 0) != tt.filtered {
+			t.Fatalf("Expected %v (filtered: %v), Got %v (filtered: %v)", tt.expected, tt.filtered, args, len(filter) > 0)
+		}
+	}
+
+	if _, _, err := parseCommandLine(root, []string{"priority:urgent", "list"}); err == nil {
+		t.Fatal("Expected an error for an invalid priority")
+	}
+}
+
+func TestCompleteTaskIDs(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetGlobals()
+
+	for _, desc := range []string{"buy milk", "call the plumber about the leak under the kitchen sink", "tabs\tand\nnewlines"} {
+		aCmd, _ := setupCmd(newAddCmd, db)
+		aCmd.SetArgs([]string{desc})
+		aCmd.Execute()
+	}
+
+	complete := completeTaskIDs(&connectionManager{db})
+	ids, directive := complete(nil, nil, "")
+	expected := []string{"1\tbuy milk", "2\tcall the plumber about the leak under t…", "3\ttabs and newlines"}
+	if !reflect.DeepEqual(expected, ids) || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("Expected %q, Got %q", expected, ids)
+	}
+	// IDs already given are left out
+	if ids, _ := complete(nil, []string{"1", "3"}, ""); !reflect.DeepEqual([]string{expected[1]}, ids) {
+		t.Errorf("Expected only task 2, Got %q", ids)
+	}
+	if ids, _ := complete(nil, nil, "3"); !reflect.DeepEqual([]string{expected[2]}, ids) {
+		t.Errorf("Expected only task 3, Got %q", ids)
+	}
+
+	dCmd, _ := setupCmd(newDoCmd, db)
+	if dCmd.ValidArgsFunction == nil {
+		t.Error("Expected do to complete task IDs")
+	}
+	if ids, _ := completeTaskIDs(&connectionManager{})(nil, nil, ""); len(ids) != 0 {
+		t.Errorf("Expected no completions without a database, Got %q", ids)
+	}
+}
+
+func TestDefaultFlags(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	root := newRootCmd()
+	root.AddCommand(newSubcommands(&connectionManager{}, io.Discard)...)
+	config.Defaults = map[string][]string{
+		"list":          {"-t", "--group", "due"},
+		"report weekly": {"-e"},
+	}
+
+	var tests = []struct {
+		input, expected []string
+	}{
+		{[]string{"list"}, []string{"list", "-t", "--group", "due"}},
+		{[]string{"list", "--group", "tag"}, []string{"list", "-t", "--group", "due", "--group", "tag"}},
+		{[]string{"+work"}, []string{"list", "-t", "--group", "due", "+work"}},
+		{[]string{"priority:high", "list", "-s"}, []string{"list", "-t", "--group", "due", "-s"}},
+		{[]string{"report", "weekly"}, []string{"report", "weekly", "-e"}},
+		{[]string{"report"}, []string{"report"}},
+		{[]string{"do", "1"}, []string{"do", "1"}},
+		{[]string{}, []string{}},
+	}
+	for _, tt := range tests {
+		args, _, err := parseCommandLine(root, tt.input)
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(tt.expected, args) {
+			t.Errorf("Expected %q, Got %q", tt.expected, args)
+		}
+	}
+
+	// Flags given on the command line win
+	lCmd := newListCmd(&connectionManager{}, io.Discard)
+	lCmd.ParseFlags([]string{"--group", "due", "--group", "tag"})
+	if ListGroup != "tag" {
+		t.Errorf("Expected the last --group to win, Got %s", ListGroup)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(configPath(dir), []byte(`{"defaults": {"list": ["due"]}}`), 0600)
+	if _, err := loadConfig(dir); err == nil || !strings.Contains(err.Error(), `the defaults of "list" must start with a flag`) {
+		t.Errorf("Expected an invalid defaults error, Got %v", err)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	task := newTask("Write the Report", []string{"work"})
+	task.Priority = "high"
+	task.Due = "2024-03-07T00:00:00Z"
+	task.Created = "2024-03-01T09:00:00Z"
+
+	var tests = []struct {
+		terms    []string
+		expected bool
+	}{
+		{[]string{"+work"}, true},
+		{[]string{"+work", "-work"}, false},
+		{[]string{"-home"}, true},
+		{[]string{"priority:high"}, true},
+		{[]string{"priority:low"}, false},
+		{[]string{"status:todo"}, true},
+		{[]string{"status.not:incomplete"}, false},
+		{[]string{"due:tomorrow"}, true},
+		{[]string{"due:none"}, false},
+		{[]string{"due.before:friday"}, true},
+		{[]string{"due.after:tomorrow"}, false},
+		{[]string{"created.before:today", "created.after:2024-02-28"}, true},
+		{[]string{"desc:report"}, true},
+		{[]string{"description:slides"}, false},
+	}
+
+	for _, tt := range tests {
+		f, err := parseFilter(tt.terms, now)
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", tt.terms, err)
+		}
+		if f.Match(task) != tt.expected {
+			t.Fatalf("Expected %v to match: %v", tt.terms, tt.expected)
+		}
+	}
+
+	if _, err := parseFilter([]string{"due.before:someday"}, now); err == nil {
+		t.Fatal("Expected an error for an invalid date")
+	}
+}
+
+func TestFilterCmds(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	resetArchive(db)
+
+	high := newTask("a", []string{"work"})
+	high.Priority = "high"
+	insertTasks(db, TASKS_BUCKET, []Task{high, newTask("b", []string{"work"}), newTask("c", nil)})
+	CommandFilter, _ = parseFilter([]string{"+work", "priority:high"}, time.Now())
+
+	cCmd, buf := setupCmd(newCountCmd, db)
+	if err := cCmd.Execute(); err != nil || buf.String() != "1 tasks\n" {
+		t.Fatalf("Expected 1 matching task, Got %q (%v)", buf.String(), err)
+	}
+
+	sCmd, _ := setupCmd(newStartCmd, db)
+	sCmd.SetArgs([]string{"2"})
+	if err := sCmd.Execute(); err == nil {
+		t.Fatal("Expected an error for task IDs used with a filter")
+	}
+
+	dCmd, _ := setupCmd(newDoCmd, db)
+	if err := dCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if task, _ := getTask(db, 1); task.Status != STATUS.COMPLETE {
+		t.Fatalf("Expected task 1 to be complete, Got %s", task.Status)
+	}
+	if task, _ := getTask(db, 2); task.Status != STATUS.INCOMPLETE {
+		t.Fatalf("Expected task 2 to stay incomplete, Got %s", task.Status)
+	}
+
+	// Deleting asks first
+	CommandFilter, _ = parseFilter([]string{"+work"}, time.Now())
+	delCmd, buf := setupCmd(newDeleteCmd, db)
+	delCmd.SetIn(strings.NewReader("n\n"))
+	if err := delCmd.Execute(); err != nil || getCount(db, TASKS_BUCKET) != 3 {
+		t.Fatalf("Expected nothing to be deleted without confirmation, Got %q (%v)", buf.String(), err)
+	}
+	delCmd.SetArgs([]string{"-y"})
+	if err := delCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tp := getTasks(db, TASKS_BUCKET); len(tp) != 1 || tp[0].task.Desc != "c" {
+		t.Fatalf("Expected only task c to be left, Got %v", tp)
+	}
+}
+
+func TestDefaultFilter(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("a", nil), newTask("b", []string{"someday"})})
+	config.DefaultFilter = "-someday"
+
+	var tests = []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"Bare list", []string{}, "1: a 🔴\n"},
+		{"Bypassed", []string{"--no-default-filter"}, "1: a 🔴\n2: b 🔴\n"},
+		{"Tags given", []string{"+someday"}, "2: b 🔴\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			NoDefaultFilter = false
+			lCmd, buf := setupCmd(newListCmd, db)
+			lCmd.SetArgs(tt.args)
+			lCmd.Execute()
+			if buf.String() != tt.expected {
+				t.Fatalf("Expected %q, Got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	tasks := []Task{newTask("a", []string{"work"}), newTask("b", []string{"work"}), newTask("c", nil), newTask("d", []string{"work"})}
+	tasks[1].Priority = "high"
+	tasks[2].Priority = "high"
+	tasks[3].Due = "2024-03-07T00:00:00Z"
+	var tp []TaskPosition
+	for i, task := range tasks {
+		tp = append(tp, TaskPosition{task, i + 1})
+	}
+
+	r := CustomReport{Filter: "+work", Columns: []string{"id", "priority", "description"}, Sort: "priority"}
+	groups, err := runReport(r, tp, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "ID  Priority  Description\n2   high      b\n1             a\n4             d"
+	if result := formatReportTable(groups, r.Columns, now); result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	r = CustomReport{Columns: []string{"id", "due"}, Sort: "-id", Group: "priority"}
+	groups, _ = runReport(r, tp, now)
+	expected = "High (2)\nID  Due\n3\n2\n\nNo priority (2)\nID  Due\n4   03/07/2024\n1"
+	if result := formatReportTable(groups, r.Columns, now); result != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	// 03/06/2024 was a Wednesday
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	open := []Task{newTask("a", []string{"work"}), newTask("b", []string{"work", "home"}), newTask("c", nil)}
+	open[0].Due = "2024-03-05T00:00:00Z"
+	open[1].Due = "2024-03-06T00:00:00Z"
+	open[2].Status = STATUS.BLOCKED
+	done := newTask("d", nil)
+	done.Status = STATUS.COMPLETE
+	done.Completed = "2024-03-06T09:00:00Z"
+	cancelled := newTask("e", nil)
+	cancelled.Status = STATUS.CANCELLED
+	cancelled.Completed = "2024-03-03T09:00:00Z"
+
+	var tp, archive []TaskPosition
+	for i, task := range append(open, done) {
+		tp = append(tp, TaskPosition{task, i + 1})
+	}
+	// Completions on the 4th and 5th continue the streak, the one on the 2nd is too far back
+	for i, day := range []string{"2024-03-05T20:00:00Z", "2024-03-04T08:00:00Z", "2024-03-02T08:00:00Z"} {
+		task := newTask("archived", nil)
+		task.Status = STATUS.COMPLETE
+		task.Completed = day
+		archive = append(archive, TaskPosition{task, i + 1})
+	}
+	archive = append(archive, TaskPosition{cancelled, 4})
+
+	s := summarize(tp, archive, now)
+	expected := Summary{
+		ByStatus:          map[string]int{STATUS.INCOMPLETE: 2, STATUS.BLOCKED: 1},
+		ByTag:             map[string]int{"work": 2, "home": 1},
+		Overdue:           1,
+		DueToday:          1,
+		CompletedToday:    1,
+		CompletedThisWeek: 3,
+		Streak:            3,
+	}
+	if !reflect.DeepEqual(expected, s) {
+		t.Fatalf("Expected %+v, Got %+v", expected, s)
+	}
+
+	// Without a completion today, the streak ends yesterday
+	if s := summarize(tp[:3], archive, now); s.Streak != 2 || s.CompletedToday != 0 {
+		t.Fatalf("Expected a streak of 2 days and no completion today, Got %+v", s)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	completed := func(d, h int) TaskPosition {
+		return TaskPosition{task: Task{Completed: time.Date(2024, 3, d, h, 0, 0, 0, time.Local).Format(RFC3339)}}
+	}
+	tasks := []TaskPosition{completed(1, 9), completed(1, 23), completed(3, 0), completed(9, 12), {task: Task{}}}
+	counts := completionsByDay(tasks, time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local), time.Date(2024, 3, 4, 8, 0, 0, 0, time.Local))
+	if !reflect.DeepEqual(counts, []int{2, 0, 1, 0}) {
+		t.Fatalf("Expected [2 0 1 0], Got %v", counts)
+	}
+
+	var tests = []struct {
+		counts   []int
+		expected string
+	}{
+		{[]int{2, 0, 1, 0}, "█▁▂▁"},
+		{[]int{0, 1, 0}, "▁█▁"},
+		{[]int{0, 0}, "▁▁"},
+		{[]int{1, 4, 7, 10}, "▂▄▆█"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.counts); got != tt.expected {
+			t.Errorf("%v: Expected %s, Got %s", tt.counts, tt.expected, got)
+		}
+	}
+}
+
+func TestBurnup(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	task := func(created, completed, status string) TaskPosition {
+		return TaskPosition{task: Task{Status: status, Created: created, Completed: completed}}
+	}
+	tasks := []TaskPosition{
+		task("2024-03-01T09:00:00Z", "2024-03-02T09:00:00Z", STATUS.COMPLETE),
+		task("2024-03-01T10:00:00Z", "", STATUS.INCOMPLETE),
+		task("2024-03-02T10:00:00Z", "", STATUS.INCOMPLETE),
+		task("2024-03-02T11:00:00Z", "2024-03-03T09:00:00Z", STATUS.CANCELLED),
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	points := burnup(tasks, start, time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC))
+	expected := []BurnupPoint{{start, 2, 0}, {start.AddDate(0, 0, 1), 3, 1}, {start.AddDate(0, 0, 2), 3, 1}}
+	if !reflect.DeepEqual(expected, points) {
+		t.Fatalf("Expected %v, Got %v", expected, points)
+	}
+
+	// Longer periods are charted per week, starting on Monday
+	points = burnup(tasks, start, start.AddDate(0, 2, 0))
+	if len(points) != 10 || !points[0].Start.Equal(time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected 10 weeks starting on 02/26, Got %v", points)
+	}
+
+	chart := formatBurnup(expected)
+	if !strings.HasSuffix(chart, "03/03/2024 | ██████████░░░░░░░░░░░░░░░░░░░░ 1/3") {
+		t.Fatalf("Expected the last day to be a third done, Got:\n%s", chart)
+	}
+}
+
+func TestEisenhower(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	task := func(id int, priority, due string) TaskPosition {
+		t := newTask(fmt.Sprintf("task %d", id), nil)
+		t.Priority = priority
+		t.Due = due
+		return TaskPosition{t, id}
+	}
+	tp := []TaskPosition{
+		task(1, "high", "2024-03-08T00:00:00Z"),
+		task(2, "med", "2024-03-09T00:00:00Z"),
+		task(3, "low", "2024-03-01T00:00:00Z"),
+		task(4, "", ""),
+	}
+	done := task(5, "high", "")
+	done.task.Status = STATUS.COMPLETE
+	tp = append(tp, done)
+
+	quadrants := eisenhower(tp, 2, now)
+	for q, expected := range [4][]int{{1}, {2}, {3}, {4}} {
+		var ids []int
+		for _, t := range quadrants[q] {
+			ids = append(ids, t.dbKey)
+		}
+		if !reflect.DeepEqual(expected, ids) {
+			t.Fatalf("Quadrant %d: Expected %v, Got %v", q, expected, ids)
+		}
+	}
+
+	matrix := formatMatrix(quadrants, 50)
+	if !strings.Contains(matrix, "│ 1: task 1             │ 2: task 2             │") {
+		t.Fatalf("Expected tasks 1 and 2 side by side, Got:\n%s", matrix)
+	}
+	for _, line := range strings.Split(matrix, "\n") {
+		if textWidth(line) != 49 {
+			t.Fatalf("Expected every line to be 49 columns wide, Got %d: %q", textWidth(line), line)
+		}
+	}
+}
+
+func TestTimewIntervals(t *testing.T) {
+	task := newTask(`Write "the" report`, []string{"work"})
+	task.Intervals = []Interval{
+		{Start: "2024-03-01T09:00:00Z", End: "2024-03-01T10:30:00Z"},
+		{Start: "2024-04-02T11:00:00+02:00"},
+	}
+	other := newTask("email", nil)
+	other.Intervals = []Interval{{Start: "2024-03-01T08:00:00Z", End: "2024-03-01T08:15:00Z"}}
+
+	intervals := timewIntervals([]TaskPosition{{task, 1}, {other, 2}})
+	var lines []string
+	for _, i := range intervals {
+		lines = append(lines, i.String())
+	}
+	expected := []string{
+		"inc 20240301T080000Z - 20240301T081500Z # email",
+		`inc 20240301T090000Z - 20240301T103000Z # "Write \"the\" report" work`,
+		`inc 20240402T090000Z # "Write \"the\" report" work`,
+	}
+	if !reflect.DeepEqual(expected, lines) {
+		t.Fatalf("Expected %q, Got %q", expected, lines)
+	}
+
+	// Only finished intervals are written, once
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "2024-03.data"), []byte(expected[1]+"\n"), 0644)
+	added, err := writeTimewData(dir, intervals)
+	if err != nil || added != 1 {
+		t.Fatalf("Expected 1 interval to be added, Got %d (%v)", added, err)
+	}
+	buf, _ := os.ReadFile(filepath.Join(dir, "2024-03.data"))
+	if string(buf) != expected[0]+"\n"+expected[1]+"\n" {
+		t.Fatalf("Expected the data file to be sorted, Got:\n%s", buf)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2024-04.data")); err == nil {
+		t.Fatal("Expected the open interval not to be written")
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+
+	// Columns mapped by position
+	in := "Title,Owner,Labels,Opened,Size\nFix bug,me,\"work, +urgent\",2024-03-01T09:00:00Z,3\nWrite docs,you,,,\n"
+	tasks, err := importCSV(strings.NewReader(in), "desc=1,tag=3,created=4,points=5", true, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Task{
+		{Desc: "Fix bug", Status: STATUS.INCOMPLETE, Created: "2024-03-01T09:00:00Z", Tags: []string{"work", "urgent"}, Points: 3},
+		{Desc: "Write docs", Status: STATUS.INCOMPLETE, Created: now.Format(RFC3339)},
+	}
+	if !reflect.DeepEqual(expected, tasks) {
+		t.Fatalf("Expected %+v, Got %+v", expected, tasks)
+	}
+
+	// Columns matched by the header, as written by `archive export -f csv`
+	var buf bytes.Buffer
+	done := Task{Desc: "shipped", Status: STATUS.COMPLETE, Created: "2024-03-01T09:00:00Z", Completed: "2024-03-02T09:00:00Z", Tags: []string{"a", "b"}}
+	exportTasks(&buf, []Task{done}, "csv")
+	tasks, err = importCSV(&buf, "", true, now)
+	if err != nil || !reflect.DeepEqual([]Task{done}, tasks) {
+		t.Fatalf("Expected %+v, Got %+v (%v)", done, tasks, err)
+	}
+
+	var errTests = []struct {
+		in, mapping string
+	}{
+		{"a,b\n", ""},
+		{"x\n,\n", "desc=1"},
+		{"x\nfix,someday\n", "desc=1,due=2"},
+		{"x\nfix\n", "desc=0"},
+		{"x\nfix\n", "size=1"},
+	}
+	for _, tt := range errTests {
+		if _, err := importCSV(strings.NewReader(tt.in), tt.mapping, true, now); err == nil {
+			t.Errorf("%q with %q: Expected an error", tt.in, tt.mapping)
+		}
+	}
+}
+
+func TestImportMarkdown(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	created := now.Format(RFC3339)
+	in := `# Notes
+- [ ] call the bank
+Some text
+- not a checklist item
+
+## Next Week!
+- [x] ship it +release
+  * [ ] nested item
+- [ ]
+### Later ###
++ [X] done  +later
+`
+	tasks, err := importMarkdown(strings.NewReader(in), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Task{
+		{Desc: "call the bank", Status: STATUS.INCOMPLETE, Created: created, Tags: []string{"notes"}},
+		{Desc: "ship it", Status: STATUS.COMPLETE, Created: created, Completed: created, Tags: []string{"next-week", "release"}},
+		{Desc: "nested item", Status: STATUS.INCOMPLETE, Created: created, Tags: []string{"next-week"}},
+		{Desc: "done", Status: STATUS.COMPLETE, Created: created, Completed: created, Tags: []string{"later"}},
+	}
+	if !reflect.DeepEqual(expected, tasks) {
+		t.Fatalf("Expected %+v, Got %+v", expected, tasks)
+	}
+
+	open, done := splitDone(tasks)
+	if len(open) != 2 || len(done) != 2 || done[0].Desc != "ship it" {
+		t.Errorf("Expected 2 open and 2 done tasks, Got %+v and %+v", open, done)
+	}
+}
+
+func TestImportDuplicates(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	db, path := setup()
+	defer teardown(db, path)
+	resetTasks(db)
+	insertTasks(db, TASKS_BUCKET, []Task{newTask("call the bank", []string{"errands"})})
+
+	file := filepath.Join(t.TempDir(), "tasks.csv")
+	os.WriteFile(file, []byte("desc,tags,priority\ncall the bank,home,high\nwater plants,,\n"), 0600)
+
+	var tests = []struct {
+		flags    []string
+		expected string
+		tags     string
+	}{
+		{[]string{"--dry-run"}, "Would create 2 tasks, update 0 and skip 0", "errands"},
+		{[]string{"--dry-run", "--skip-duplicates"}, "Would create 1 tasks, update 0 and skip 1", "errands"},
+		{[]string{"--skip-duplicates"}, "Created 1 tasks, updated 0 and skipped 1", "errands"},
+		{[]string{"--overwrite"}, "Created 0 tasks, updated 1 and skipped 1", "home"},
+		{[]string{"--skip-duplicates"}, "Created 0 tasks, updated 0 and skipped 2", "home"},
+	}
+	for _, tt := range tests {
+		iCmd, buf := setupCmd(newImportCmd, db)
+		iCmd.SetArgs(append([]string{file}, tt.flags...))
+		if err := iCmd.Execute(); err != nil {
+			t.Fatalf("%v: Unexpected error: %v", tt.flags, err)
+		}
+		if !strings.Contains(buf.String(), tt.expected) {
+			t.Errorf("%v: Expected %q, Got %q", tt.flags, tt.expected, buf.String())
+		}
+		if task, _ := getTask(db, 1); strings.Join(task.Tags, " ") != tt.tags {
+			t.Errorf("%v: Expected the tags %s, Got %v", tt.flags, tt.tags, task.Tags)
+		}
+	}
+	if n := len(getTasks(db, TASKS_BUCKET)); n != 2 {
+		t.Fatalf("Expected 2 tasks, Got %d", n)
+	}
+	if task, _ := getTask(db, 1); task.Priority != "high" || len(task.History) != 2 {
+		t.Fatalf("Expected the overwrite in the history, Got %+v", task)
+	}
+
+	iCmd, _ := setupCmd(newImportCmd, db)
+	iCmd.SetArgs([]string{file, "--skip-duplicates", "--overwrite"})
+	if err := iCmd.Execute(); err == nil {
+		t.Fatalf("Expected an error with both --skip-duplicates and --overwrite")
+	}
+}
+
+func TestPlanReminderSync(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	open := Task{Desc: "open\nmore", Status: STATUS.INCOMPLETE, UUID: "a1", Due: "2024-03-08T00:00:00Z"}
+	completedInApp := Task{Desc: "completed in app", Status: STATUS.INCOMPLETE, UUID: "b2"}
+	unchanged := Task{Desc: "unchanged", Status: STATUS.INCOMPLETE, UUID: "c3"}
+	unlinked := Task{Desc: "unlinked", Status: STATUS.INCOMPLETE, UUID: "d4"}
+	done := Task{Desc: "done", Status: STATUS.COMPLETE, UUID: "e5"}
+	noLongerDue := Task{Desc: "no longer due", Status: STATUS.INCOMPLETE, UUID: "7a"}
+	tp := []TaskPosition{{open, 1}, {completedInApp, 2}, {unchanged, 3}, {unlinked, 4}, {done, 5}, {noLongerDue, 6}}
+	archive := []TaskPosition{{Task{Desc: "archived", Status: STATUS.COMPLETE, UUID: "f6"}, 1}}
+
+	reminders := []Reminder{
+		{ID: "r1", Name: "renamed", Body: "task:a1"},
+		{ID: "r2", Name: "completed in app", Body: "task:b2", Completed: true},
+		{ID: "r3", Name: "unchanged", Body: "notes\ntask:c3"},
+		{ID: "r6", Name: "archived", Body: "task:f6"},
+		{ID: "r7", Name: "deleted", Body: "task:07"},
+		{ID: "r8", Name: "siri", Body: "from my phone", Due: "2024-03-07T09:00:00.000Z"},
+		{ID: "r9", Name: "old", Completed: true},
+		{ID: "r10", Name: "no longer due", Body: "task:7a", Due: "2024-03-07T09:00:00.000Z"},
+	}
+	s := planReminderSync(tp, archive, reminders, now)
+
+	if len(s.NewTasks) != 1 || s.NewTasks[0].Desc != "siri" || s.NewTasks[0].Due != "2024-03-07T09:00:00Z" || s.NewTasks[0].UUID == "" {
+		t.Fatalf("Expected a task for the reminder added in the app, Got %+v", s.NewTasks)
+	}
+	if !reflect.DeepEqual([]int{2}, s.Complete) {
+		t.Errorf("Expected task 2 to be completed, Got %v", s.Complete)
+	}
+	expectedCreate := []Reminder{{Name: "unlinked", Body: "task:d4"}}
+	if !reflect.DeepEqual(expectedCreate, s.Create) {
+		t.Errorf("Expected %+v, Got %+v", expectedCreate, s.Create)
+	}
+	expectedUpdate := []Reminder{
+		{ID: "r1", Name: "open", Body: "task:a1", Due: "2024-03-08T00:00:00Z"},
+		{ID: "r6", Name: "archived", Body: "task:f6", Completed: true},
+		{ID: "r8", Name: "siri", Body: "from my phone\ntask:" + s.NewTasks[0].UUID, Due: "2024-03-07T09:00:00.000Z"},
+		// The due date removed from the task is cleared
+		{ID: "r10", Name: "no longer due", Body: "task:7a"},
+	}
+	if !reflect.DeepEqual(expectedUpdate, s.Update) {
+		t.Errorf("Expected %+v, Got %+v", expectedUpdate, s.Update)
+	}
+	if !reflect.DeepEqual([]string{"r7"}, s.Delete) {
+		t.Errorf("Expected the reminder of the deleted task to be deleted, Got %v", s.Delete)
+	}
+}
+
+func TestDueAlerts(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.Local)
+	due := func(d time.Time) string { return d.Format(RFC3339) }
+	today := time.Date(2024, 3, 6, 0, 0, 0, 0, time.Local)
+	tp := []TaskPosition{
+		{Task{Desc: "overdue", Status: STATUS.INCOMPLETE, Due: due(today.AddDate(0, 0, -2))}, 1},
+		{Task{Desc: "today\nnotes", Status: STATUS.INCOMPLETE, Due: due(today)}, 2},
+		{Task{Desc: "tomorrow", Status: STATUS.INCOMPLETE, Due: due(today.AddDate(0, 0, 1))}, 3},
+		{Task{Desc: "done", Status: STATUS.COMPLETE, Due: due(today)}, 4},
+		{Task{Desc: "no due date", Status: STATUS.INCOMPLETE}, 5},
+	}
+
+	var tests = []struct {
+		lead     time.Duration
+		expected []string
+	}{
+		{0, []string{"Overdue task: overdue", "Task due today: today"}},
+		{11 * time.Hour, []string{"Overdue task: overdue", "Task due today: today"}},
+		{12 * time.Hour, []string{"Overdue task: overdue", "Task due today: today", "Task due 03/07/2024: tomorrow"}},
+	}
+	for _, tt := range tests {
+		var alerts []string
+		for _, a := range dueAlerts(tp, tt.lead, now) {
+			title, message := alertText(a.task, now)
+			alerts = append(alerts, title+": "+message)
+		}
+		if !reflect.DeepEqual(tt.expected, alerts) {
+			t.Errorf("Lead %s: Expected %q, Got %q", tt.lead, tt.expected, alerts)
+		}
+	}
+}
+
+func TestRemindOnce(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetGlobals()
+	defer resetGlobals()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("home", home)
+	dir, _ := taskDir()
+	os.MkdirAll(dir, 0777)
+
+	var notified []string
+	var notifyErr error
+	prev := notify
+	defer func() { notify = prev }()
+	notify = func(title, message string) error {
+		if notifyErr != nil {
+			return notifyErr
+		}
+		notified = append(notified, title+": "+message)
+		return nil
+	}
+
+	today := time.Now().Format(RFC3339)
+	insertTasks(db, TASKS_BUCKET, []Task{
+		{Desc: "pay rent", Status: STATUS.INCOMPLETE, Due: today, UUID: "a1"},
+		{Desc: "someday", Status: STATUS.INCOMPLETE, UUID: "b2"},
+	})
+	rCmd, buf := setupCmd(newRemindCmd, db)
+	rCmd.SetArgs([]string{"--once"})
+	if err := rCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != "Task due today: pay rent\n" {
+		t.Fatalf("Expected the due task to be alerted, Got %q", buf.String())
+	}
+
+	// Alerted tasks aren't alerted again
+	buf.Reset()
+	if err := rCmd.Execute(); err != nil || buf.String() != "" || len(notified) != 1 {
+		t.Fatalf("Expected no new alerts, Got %q %v (%v)", buf.String(), notified, err)
+	}
+
+	// A new due date is alerted again, and failed alerts are tried again on the next run
+	updateTasks(db, []int{1}, func(t *Task) error {
+		t.Due = time.Now().AddDate(0, 0, -1).Format(RFC3339)
+		return nil
+	})
+	notifyErr = fmt.Errorf("no notification daemon")
+	if err := rCmd.Execute(); err == nil {
+		t.Fatal("Expected the notification error")
+	}
+	notifyErr = nil
+	if err := rCmd.Execute(); err != nil || len(notified) != 2 || notified[1] != "Overdue task: pay rent" {
+		t.Fatalf("Expected the overdue task to be alerted, Got %v (%v)", notified, err)
+	}
+	alerted, err := loadAlerted(dir)
+	if err != nil || len(alerted) != 1 {
+		t.Fatalf("Expected only the current due date to be recorded, Got %v (%v)", alerted, err)
+	}
+}
+
+func TestRemindDaemon(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetGlobals()
+	defer resetGlobals()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("home", home)
+
+	var notified []string
+	prev := notify
+	defer func() { notify = prev }()
+	notify = func(title, message string) error {
+		notified = append(notified, title+": "+message)
+		return nil
+	}
+
+	sock, _ := socketPath()
+	os.MkdirAll(filepath.Dir(sock), 0777)
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	go serveDaemon(&connectionManager{db: db}, l)
+
+	// The daemon holds the db, so it checks for due tasks in place of remind
+	insertTasks(db, TASKS_BUCKET, []Task{{Desc: "pay rent", Status: STATUS.INCOMPLETE, Due: time.Now().Format(RFC3339), UUID: "a1"}})
+	var buf bytes.Buffer
+	rCmd := newRemindCmd(&connectionManager{}, &buf)
+	rCmd.SetArgs([]string{"--once"})
+	if err := rCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != "Task due today: pay rent\n" || len(notified) != 1 {
+		t.Fatalf("Expected the daemon to alert the due task, Got %q %v", buf.String(), notified)
+	}
+}
+
+func TestRemindWeeklyReport(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir, _ := taskDir()
+	mgr := &connectionManager{}
+	if err := mgr.Open(false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mgr.Close()
+	os.WriteFile(configPath(dir), []byte(`{"email": {"server": "smtp.example.com:587", "from": "me@example.com", "to": ["boss@example.com"], "weekly": "monday 08:00"}}`), 0600)
+	saveLastSent(dir, time.Now().AddDate(0, 0, -8))
+
+	var sent int
+	prev := sendMail
+	defer func() { sendMail = prev }()
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent++
+		return nil
+	}
+
+	// Without a daemon, remind emails the weekly report when it's due
+	var buf bytes.Buffer
+	rCmd := newRemindCmd(mgr, &buf)
+	rCmd.SetArgs([]string{"--once"})
+	if err := rCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sent != 1 || buf.String() != "Sent the weekly report to boss@example.com\n" {
+		t.Fatalf("Expected the weekly report to be sent, Got %d %q", sent, buf.String())
+	}
+}
+
+func TestBackup(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	insert(db, TASKS_BUCKET, "a", nil)
+	dir := t.TempDir()
+
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if _, err := writeBackup(db, dir, now.AddDate(0, 0, i)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	deleted, err := pruneBackups(dir, 2)
+	if err != nil || len(deleted) != 1 || filepath.Base(deleted[0]) != "tasks-20240306-120000.db" {
+		t.Fatalf("Expected the oldest backup to be deleted, Got %v (%v)", deleted, err)
+	}
+
+	backup, err := bolt.Open(filepath.Join(dir, "tasks-20240308-120000.db"), 0600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer backup.Close()
+	if tasks := getTasks(backup, TASKS_BUCKET); len(tasks) != 1 || tasks[0].task.Desc != "a" {
+		t.Fatalf("Expected the backup to hold the tasks, Got %+v", tasks)
+	}
+}
+
+func TestInstallServices(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("systemd units are only installed on Linux")
+	}
+	var commands []string
+	prev := runServiceManager
+	defer func() { runServiceManager = prev }()
+	runServiceManager = func(name string, args ...string) ([]byte, error) {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return nil, nil
+	}
+
+	dir := t.TempDir()
+	env := map[string]string{"TASK_DB": "/data/my tasks.db", "TASK_DEFAULTS": `{"list": ["-t"]}`, "TASK_DATE_FORMAT": "%d"}
+	if err := installServices(dir, systemdUnits("/opt/my apps/task", env)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The jobs keep the TASK_* variables of the shell they were installed from
+	environment := `Environment="TASK_DATE_FORMAT=%%d"
+Environment="TASK_DB=/data/my tasks.db"
+Environment="TASK_DEFAULTS={\"list\": [\"-t\"]}"
+`
+	buf, _ := os.ReadFile(filepath.Join(dir, "task-remind.service"))
+	if !strings.Contains(string(buf), environment+"ExecStart=\"/opt/my apps/task\" remind\n") {
+		t.Errorf("Expected the remind service to run remind, Got %s", buf)
+	}
+	buf, _ = os.ReadFile(filepath.Join(dir, "task-backup.service"))
+	if !strings.Contains(string(buf), environment+"ExecStart=\"/opt/my apps/task\" backup\n") {
+		t.Errorf("Expected the backup service to run backup, Got %s", buf)
+	}
+	expected := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now task-remind.service task-backup.timer",
+		"systemctl --user restart task-remind.service",
+	}
+	if !reflect.DeepEqual(expected, commands) {
+		t.Errorf("Expected %q, Got %q", expected, commands)
+	}
+
+	commands = nil
+	removed, err := uninstallServices(dir, serviceFiles())
+	if err != nil || len(removed) != 3 {
+		t.Fatalf("Expected the 3 units to be removed, Got %v (%v)", removed, err)
+	}
+	if len(commands) != 3 || commands[2] != "systemctl --user daemon-reload" {
+		t.Errorf("Expected the units to be disabled, Got %q", commands)
+	}
+	if status := serviceStatus(dir, serviceFiles()[0]); status != "not installed" {
+		t.Errorf("Expected not installed, Got %s", status)
+	}
+}
+
+func TestLaunchdAgents(t *testing.T) {
+	agents := launchdAgents("/opt/task", "/Users/me/task", map[string]string{"TASK_DB": "/data/a&b.db"})
+	expected := `	<key>EnvironmentVariables</key>
+	<dict>
+		<key>TASK_DB</key>
+		<string>/data/a&amp;b.db</string>
+	</dict>
+`
+	for _, agent := range agents {
+		if !strings.Contains(agent.Content, expected) {
+			t.Errorf("Expected %s to pass TASK_DB on, Got %s", agent.Name, agent.Content)
+		}
+	}
+	if agents := launchdAgents("/opt/task", "/Users/me/task", nil); strings.Contains(agents[0].Content, "EnvironmentVariables") {
+		t.Errorf("Expected no environment, Got %s", agents[0].Content)
+	}
+}
+
+func TestFormatStatusline(t *testing.T) {
+	s := Summary{ByStatus: map[string]int{"incomplete": 5, "blocked": 2}, Overdue: 1, DueToday: 2, CompletedToday: 2}
+	var tests = []struct {
+		format   string
+		s        Summary
+		tmux     bool
+		expected string
+	}{
+		{defaultStatusline, s, false, "3 due · 7 open · ✅2 today"},
+		{"{overdue}/{due} {unknown}", s, false, "1/3 {unknown}"},
+		{"{due} due {open} open {done} done", s, true, "#[fg=red]3#[default] due 7 open #[fg=green]2#[default] done"},
+		{"{due} due {overdue} late", Summary{DueToday: 1}, true, "#[fg=yellow]1#[default] due 0 late"},
+	}
+	for _, tt := range tests {
+		if got := formatStatusline(tt.format, tt.s, tt.tmux); got != tt.expected {
+			t.Errorf("%q: Expected %q, Got %q", tt.format, tt.expected, got)
+		}
+	}
+	if err := checkStatusline("{due} {todo}"); err == nil {
+		t.Error("Expected an error for an unknown placeholder")
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	tp := []TaskPosition{
+		{Task{Desc: "late", Status: STATUS.INCOMPLETE, Due: "2024-03-01T00:00:00Z"}, 1},
+		{Task{Desc: "write the release notes for 2.0\ndetails", Status: STATUS.IN_PROGRESS}, 2},
+		{Task{Desc: "second", Status: STATUS.IN_PROGRESS}, 3},
+		{Task{Desc: "done", Status: STATUS.COMPLETE, Due: "2024-03-01T00:00:00Z"}, 4},
+	}
+	p := promptInfo(tp, now)
+	expected := PromptInfo{Open: 3, Overdue: 1, Active: "write the release notes for 2.0"}
+	if p != expected {
+		t.Fatalf("Expected %+v, Got %+v", expected, p)
+	}
+
+	var tests = []struct {
+		p        PromptInfo
+		expected string
+	}{
+		{p, "3 !1 ▶ write the release n…"},
+		{PromptInfo{Open: 2}, "2"},
+		{PromptInfo{}, ""},
+	}
+	for _, tt := range tests {
+		if got := formatPrompt(tt.p); got != tt.expected {
+			t.Errorf("%+v: Expected %q, Got %q", tt.p, tt.expected, got)
+		}
+	}
+}
+
+func TestServeAPI(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetGlobals()
+	insert(db, TASKS_BUCKET, "existing", []string{"home"})
+
+	requests := []string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"description": "write docs", "tags": ["+work"], "priority": "high"}}`,
+		`{"jsonrpc": "2.0", "id": "a", "method": "update", "params": {"id": 2, "description": "write the docs", "points": 3}}`,
+		`{"jsonrpc": "2.0", "method": "complete", "params": {"id": 1}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "list", "params": {"filter": "+work"}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "complete", "params": {"id": 9}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "remove"}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "add", "params": {"description": "x", "due": "someday"}}`,
+		`not json`,
+	}
+	var out bytes.Buffer
+	err := serveAPI(strings.NewReader(strings.Join(requests, "\n")), &out, func(run func(*bolt.DB) error) error {
+		return run(db)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var responses []struct {
+		ID     any
+		Result []APITask
+		Error  *rpcError
+	}
+	for i, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var res struct {
+			ID     any
+			Result json.RawMessage
+			Error  *rpcError
+		}
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatalf("Response %d: %v", i, err)
+		}
+		// Single tasks are read as a list of one
+		result := res.Result
+		if len(result) > 0 && result[0] == '{' {
+			result = append(append(json.RawMessage("["), result...), ']')
+		}
+		var tasks []APITask
+		json.Unmarshal(result, &tasks)
+		responses = append(responses, struct {
+			ID     any
+			Result []APITask
+			Error  *rpcError
+		}{res.ID, tasks, res.Error})
+	}
+
+	// The notification gets no response
+	if len(responses) != 7 {
+		t.Fatalf("Expected 7 responses, Got %s", out.String())
+	}
+	if r := responses[0].Result; len(r) != 1 || r[0].ID != 2 || r[0].Desc != "write docs" || r[0].Priority != "high" || !reflect.DeepEqual(r[0].Tags, []string{"work"}) {
+		t.Errorf("Expected the added task, Got %+v", responses[0])
+	}
+	if r := responses[1]; r.ID != "a" || len(r.Result) != 1 || r.Result[0].Desc != "write the docs" || r.Result[0].Points != 3 {
+		t.Errorf("Expected the updated task, Got %+v", r)
+	}
+	if r := responses[2].Result; len(r) != 1 || r[0].Desc != "write the docs" {
+		t.Errorf("Expected the listed task, Got %+v", responses[2])
+	}
+	if task, _ := getTask(db, 1); task.Status != STATUS.COMPLETE {
+		t.Errorf("Expected the notification to complete task 1, Got %+v", task)
+	}
+	for i, code := range []int{rpcCommandError, rpcMethodNotFound, rpcInvalidParams, rpcParseError} {
+		if r := responses[3+i]; r.Error == nil || r.Error.Code != code {
+			t.Errorf("Response %d: Expected error %d, Got %+v", 3+i, code, r)
+		}
+	}
+}
+
+func TestAPIDaemon(t *testing.T) {
+	db, path := setup()
+	defer teardown(db, path)
+	resetGlobals()
+	defer resetGlobals()
+	resetTasks(db)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	sock, _ := socketPath()
+	os.MkdirAll(filepath.Dir(sock), 0777)
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+	go serveDaemon(&connectionManager{db: db}, l)
+
+	// The daemon holds the db, so each request is handled by it
+	requests := []string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "add", "params": {"description": "from the editor"}}`,
+		`{"jsonrpc": "2.0", "method": "complete", "params": {"id": 1}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "list"}`,
+	}
+	var out bytes.Buffer
+	aCmd := newAPICmd(&connectionManager{}, &out)
+	aCmd.SetIn(strings.NewReader(strings.Join(requests, "\n")))
+	aCmd.SetArgs([]string{"--stdio"})
+	if err := aCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"jsonrpc":"2.0","id":1,"result":{"ID":1,"Desc":"from the editor"`) || !strings.HasPrefix(lines[1], `{"jsonrpc":"2.0","id":2,"result":[{"ID":1,"Desc":"from the editor"`) {
+		t.Fatalf("Expected a response to each request with an ID, Got %s", out.String())
+	}
+	if task, _ := getTask(db, 1); task.Status != STATUS.COMPLETE {
+		t.Fatalf("Expected the task to be completed in the daemon's db, Got %q", task.Status)
+	}
+}
+
+func TestWindowsToastScript(t *testing.T) {
+	script := windowsToastScript("Task <due> today", "Bob's & Ann's")
+	if !strings.Contains(script, `CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe')`) {
+		t.Errorf("Expected the toast to be shown as PowerShell, Got %s", script)
+	}
+	if !strings.Contains(script, "<text>Task &lt;due&gt; today</text><text>Bob&#39;s &amp; Ann&#39;s</text>") {
+		t.Errorf("Expected the text to be escaped, Got %s", script)
+	}
+
+	// Typographic single quotes end PowerShell strings too, so they're doubled like '
+	var tests = []struct {
+		message  string
+		expected string
+	}{
+		{"Bob\u2019s rent", "Bob\u2019\u2019s rent"},
+		{"\u2018quoted\u2019", "\u2018\u2018quoted\u2019\u2019"},
+		{"\u201alow\u201b", "\u201a\u201alow\u201b\u201b"},
+		{"\u2019); Remove-Item C:\\ #", "\u2019\u2019); Remove-Item C:\\ #"},
+	}
+	for _, tt := range tests {
+		script := windowsToastScript("Task due today", tt.message)
+		if !strings.Contains(script, "<text>"+tt.expected+"</text></binding></visual></toast>')") {
+			t.Errorf("%q: Expected %q inside the quoted XML, Got %s", tt.message, tt.expected, script)
+		}
+	}
+}
+
+func TestViewCmd(t *testing.T) {
+	resetGlobals()
+	defer resetGlobals()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("home", home)
+	dir, _ := taskDir()
+	os.MkdirAll(dir, 0777)
+	os.WriteFile(configPath(dir), []byte(`{"track_time": false}`), 0600)
+
+	vCmd, buf := setupCmd(newViewCmd, nil)
+	vCmd.SetArgs([]string{"save", "urgent", "+work priority:high", "due.before:eow"})
+	if err := vCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vCmd.SetArgs([]string{"save", "bad", "work"})
+	if err := vCmd.Execute(); err == nil {
+		t.Fatal("Expected an error for a term that isn't a filter term")
+	}
+
+	// Other settings are kept
+	c, err := loadConfig(dir)
+	if err != nil || c.TrackTime || c.Views["urgent"] != "+work priority:high due.before:eow" {
+		t.Fatalf("Expected the view to be saved next to the other settings, Got %+v (%v)", c, err)
+	}
+	expanded := expandView([]string{"view", "urgent", "do"})
+	if !reflect.DeepEqual(expanded, []string{"+work", "priority:high", "due.before:eow", "do"}) {
+		t.Fatalf("Expected the view to be expanded, Got %v", expanded)
+	}
+
+	buf.Reset()
+	vCmd.SetArgs([]string{})
+	if err := vCmd.Execute(); err != nil || buf.String() != "urgent  +work priority:high due.before:eow\n" {
+		t.Fatalf("Expected the view to be listed, Got %q (%v)", buf.String(), err)
+	}
+
+	vCmd.SetArgs([]string{"delete", "urgent"})
+	if err := vCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c, _ := loadConfig(dir); len(c.Views) != 0 {
+		t.Fatalf("Expected the view to be deleted, Got %v", c.Views)
+	}
+}
+
+func TestLegacyTag(t *testing.T) {
+	task := bToTask([]byte(`{"Desc":"old","Status":"incomplete","Tag":"legacy"}`))
+	if !reflect.DeepEqual(task.Tags, []string{"legacy"}) {
+		t.Fatalf("Expected legacy tag to be migrated, Got %v", task.Tags)
+	}
+}
+
+// Creates and connects to a temporary file to serve as the db.
+// Also initializes the task and archive buckets.
+// Returns the db and its path
+func setup() (*bolt.DB, string) {
+	path := filepath.Join(os.TempDir(), "task-test.db")
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	check(err)
+	db.Update(func(tx *bolt.Tx) error {
+		tx.CreateBucketIfNotExists(TASKS_BUCKET)
+		tx.CreateBucketIfNotExists(ARCHIVE_BUCKET)
+		return nil
+	})
+	return db, path
+}
+
+// Deletes the db at the designated path
+func teardown(db *bolt.DB, path string) {
+	db.Close()
+	os.Remove(path)
+}
+
+// Reset global values such as flags to their default values.
+// Helps avoid bugs when running tests in a loop
+func resetGlobals() {
+	UpdateStatus = false
+	UpdatedDesc = ""
+	UpdateAddTags = ""
+	UpdateRemoveTags = ""
+	UpdateDue = ""
+	UpdateNoDue = false
+	UpdatePriority = ""
+	AddPriority = ""
+	DeleteOnDo = false
+	TagCounts = false
+	MatchAnyTag = false
+	MatchAllTags = false
+	DueDate = ""
+	CountTag = ""
+	CountStatus = ""
+	CountOverdue = false
+	CountArchive = false
+	CountJSON = false
+	ShowPattern = false
+	CompareWith = ""
+	ShowLeadTime = false
+	StatsTags = ""
+	ReportFormat = "text"
+	ReportOut = ""
+	ReportStart = ""
+	ReportEnd = ""
+	ReportMonth = ""
+	ReportEmail = false
+	ReportMarkdown = false
+	ForecastTag = ""
+	ForecastWindow = 28
+	ExportFormat = "json"
+	GroupByDay = false
+	DeleteTag = ""
+	DeleteYes = false
+	DeleteInteractive = false
+	SortInteractive = false
+	EditAll = false
+	LoadYes = false
+	RestoreSince = ""
+	RestoreUntil = ""
+	ExportStart = ""
+	TaskExportFormat = "json"
+	ExportPrint = false
+	ExportPageLength = 60
+	ExportTags = ""
+	ExportStatus = ""
+	ExportSince = ""
+	ExportEnd = ""
+	ClearTag = ""
+	ClearCompleted = false
+	FinishTag = ""
+	DoAll = false
+	DoTag = ""
+	DupCount = 1
+	ShowAge = false
+	AgeThreshold = 7
+	ListGroup = ""
+	ListTree = false
+	ListIDs = false
+	ListPrint0 = false
+	ListQuiet = false
+	AddParent = ""
+	UpdateParent = ""
+	ListStatus = ""
+	ListBlocked = false
+	BlockReason = ""
+	FinishOnCancel = false
+	ShowAnnotations = false
+	CommandFilter = nil
+	NoDefaultFilter = false
+	ShowBurnup = false
+	ShowSparkline = false
+	MatrixDays = 2
+	AddPoints = 0
+	UpdatePoints = 0
+	TimewWrite = false
+	ImportFormat = ""
+	ImportMap = ""
+	ImportHeader = true
+	ImportArchiveDone = false
+	RemindLead = 0
+	RemindInterval = time.Minute
+	RemindOnce = false
+	BackupKeep = 10
+	StatuslineFormat = ""
+	StatuslineTmux = false
+	PromptJSON = false
+	APIStdio = false
+	AddAssignee = ""
+	UpdateAssignee = ""
+	ListAssignee = ""
+	ImportDryRun = false
+	ImportSkipDuplicates = false
+	ImportOverwrite = false
+	MergeDryRun = false
+	MergeSkipDuplicates = false
+	MergeOverwrite = false
+	config = defaultConfig()
+	NoColor = false
+	RelativeDates = false
+	ShowHistory = false
+	time.Local = systemLocation
+	OpenAttachment = 0
+	CopyAttachment = false
+	AddFromClipboard = false
+	AddWithEditor = false
+	ShowTags = false
+}
+
+func resetArchive(db *bolt.DB) {
+	db.Update(func(tx *bolt.Tx) error {
+		tx.DeleteBucket(ARCHIVE_BUCKET)
+		tx.CreateBucket(ARCHIVE_BUCKET)
+		return nil
+	})
+}
+
+func resetTasks(db *bolt.DB) {
+	db.Update(func(tx *bolt.Tx) error {
+		tx.DeleteBucket(TASKS_BUCKET)
+		tx.CreateBucket(TASKS_BUCKET)
+		return nil
 	})
 }
 
@@ -531,3 +5391,17 @@ func setupCmd(cmdToCreate func(*connectionManager, io.Writer) *cobra.Command, db
 	cmd.SetErr(buf)
 	return cmd, buf
 }
+
+func TestCopyAttachmentFailure(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Opening a directory succeeds but reading it fails, like a copy cut short
+	if _, err := copyAttachment(t.TempDir()); err == nil {
+		t.Fatalf("Failed to error when the copy fails")
+	}
+	entries, _ := os.ReadDir(filepath.Join(home, "task", "attachments"))
+	if len(entries) != 0 {
+		t.Fatalf("Expected the partial copy to be removed, Got %v", entries)
+	}
+}
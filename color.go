@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Row states `colors` in the config file sets the color of. An overdue task is colored as
+// overdue whatever its priority
+var COLOR_STATES = []string{"overdue", "high", "med", "low"}
+
+// Colors of list rows the config file doesn't set
+var defaultColors = map[string]string{"overdue": "red", "high": "yellow", "low": "dim"}
+
+// ANSI codes of the words a color is made of, such as "bold red"
+var ansiCodes = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4",
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"bright-black": "90", "bright-red": "91", "bright-green": "92", "bright-yellow": "93",
+	"bright-blue": "94", "bright-magenta": "95", "bright-cyan": "96", "bright-white": "97",
+}
+
+// Ends a color started by the sequence of parseColor
+const ansiReset = "\x1b[0m"
+
+// Reports whether output is a terminal that should be colored. Following no-color.org, setting
+// $NO_COLOR turns colors off. A variable so tests and the daemon can decide
+var colorOutput = func() bool {
+	return isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+}
+
+// Returns the escape sequence starting `color`, words of ansiCodes such as "bold red". Empty if
+// `color` is empty
+func parseColor(color string) (string, error) {
+	var codes []string
+	for _, word := range strings.Fields(strings.ToLower(color)) {
+		code, ok := ansiCodes[word]
+		if !ok {
+			return "", fmt.Errorf(tr(`Unknown color "%s"`), word)
+		}
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return "", nil
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m", nil
+}
+
+// Returns the escape sequence of the color of `t`'s row in lists as of `now`, from the colors
+// of the config file or defaultColors. Empty if the row isn't colored
+func rowColor(t Task, now time.Time) string {
+	state := t.Priority
+	if isOverdue(t, now) {
+		state = "overdue"
+	} else if isDone(t) {
+		return ""
+	}
+	color, ok := config.Colors[state]
+	if !ok {
+		color = defaultColors[state]
+	}
+	seq, _ := parseColor(color)
+	return seq
+}
+
+// Color each line of `s` with the escape sequence `seq`, so every line of a wrapped row is
+// colored even when a pager shows them separately
+func colorize(s, seq string) string {
+	if seq == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = seq + line + ansiReset
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,634 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Settings read from config.json in the task directory. Missing settings keep their defaults
+type Config struct {
+	// States a task can be in, in the order they are listed in. Must include "incomplete"
+	// and "complete", the states of new tasks and of tasks completed with `do`
+	Statuses []Status `json:"statuses"`
+	// Log the time tasks spend in progress
+	TrackTime bool `json:"track_time"`
+	// Filter applied to `list` when no tags, filter or status are given, e.g. "-someday"
+	DefaultFilter string `json:"default_filter,omitempty"`
+	// Filters saved under a name with `view save`
+	Views map[string]string `json:"views,omitempty"`
+	// Reports printed by `report <name>`
+	Reports map[string]CustomReport `json:"reports,omitempty"`
+	// Icons shown in place of tags by `list -t`, keyed by tag, e.g. {"work": "💼"}
+	TagIcons map[string]string `json:"tag_icons,omitempty"`
+	// Colors of list rows by state, one of COLOR_STATES, such as {"high": "bold red"}. An empty
+	// color leaves the rows uncolored
+	Colors map[string]string `json:"colors,omitempty"`
+	// Layout of the dates shown to users, such as "2006-01-02" or "Jan 2", or "locale" for the
+	// usual layout of the user's language. Defaults to mm/dd/yyyy
+	DateFormat string `json:"date_format,omitempty"`
+	// Timezone dates are shown and days are counted in, an IANA name such as "Europe/Madrid".
+	// Defaults to the system's
+	Timezone string `json:"timezone,omitempty"`
+	// Format of `statusline`, with placeholders such as {due}
+	Statusline string `json:"statusline,omitempty"`
+	// Flags added to the commands they're keyed by, before the flags given on the command
+	// line, e.g. {"list": ["-t", "--group", "due"]}. Subcommands are keyed by their full
+	// name, such as "report weekly"
+	Defaults map[string][]string `json:"defaults,omitempty"`
+	// Settings of `do`
+	Do DoConfig `json:"do"`
+	// Limits on the size of the archive, enforced by `finish`
+	Archive ArchiveConfig `json:"archive"`
+	// SMTP server `report weekly --email` sends with, and the weekly schedule of `daemon`
+	Email *EmailConfig `json:"email,omitempty"`
+}
+
+// Settings of `do`, the do section of the config file
+type DoConfig struct {
+	// Finish completed tasks straight away, as with `do -f`
+	AutoFinish bool `json:"auto_finish"`
+}
+
+// A state a task can be in
+type Status struct {
+	Name string `json:"name"`
+	// Printed next to tasks in this state
+	Icon string `json:"icon"`
+	// Other names the state can be given by on the command line
+	Aliases []string `json:"aliases,omitempty"`
+	// Tasks in a done state are no longer open: `finish` archives them and they are never overdue
+	Done bool `json:"done,omitempty"`
+	// States the `status` command can move a task in this state to. Any state if empty
+	Next []string `json:"next,omitempty"`
+}
+
+// The settings in use, replaced by the config file when the database is opened
+var config = defaultConfig()
+
+// Returns the settings used when there's no config file
+func defaultConfig() Config {
+	return Config{
+		Statuses: []Status{
+			{Name: STATUS.INCOMPLETE, Icon: "🔴", Aliases: []string{"todo"}},
+			{Name: "in-progress", Icon: "🟡"},
+			{Name: "blocked", Icon: "⛔"},
+			{Name: STATUS.COMPLETE, Icon: "✅", Aliases: []string{"done"}, Done: true},
+			{Name: "cancelled", Icon: "❌", Done: true},
+		},
+		TrackTime: true,
+	}
+}
+
+// Returns the path of the config file inside `dir`
+func configPath(dir string) string {
+	return filepath.Join(dir, "config.json")
+}
+
+// Read the config file in `dir`, with the settings overridden by TASK_* environment variables,
+// see settingEnv. Returns the default settings if there is no config file and no override
+func loadConfig(dir string) (Config, error) {
+	return loadConfigEnv(dir, os.LookupEnv)
+}
+
+// Read the config file in `dir` like loadConfig, looking the environment variables up with
+// `lookupEnv`, e.g. those of the CLI a command was sent by
+func loadConfigEnv(dir string, lookupEnv func(string) (string, bool)) (Config, error) {
+	c := defaultConfig()
+	buf, err := os.ReadFile(configPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		buf = []byte("{}")
+	} else if err != nil {
+		return c, err
+	}
+
+	settings := map[string]any{}
+	if err := json.Unmarshal(buf, &settings); err != nil {
+		return c, fmt.Errorf(tr("Invalid config file %s: %v"), configPath(dir), err)
+	}
+	applied, err := applyEnvSettings(settings, lookupEnv)
+	if err != nil {
+		return c, err
+	}
+	if !applied {
+		return parseConfig(buf, configPath(dir))
+	}
+	if buf, err = json.Marshal(settings); err != nil {
+		return c, err
+	}
+	// Invalid overrides may be to blame for errors
+	return parseConfig(buf, fmt.Sprintf(tr("%s with the TASK_* environment variables"), configPath(dir)))
+}
+
+// Parse and check the contents of the config file at `path`. Missing settings keep their
+// defaults
+func parseConfig(buf []byte, path string) (Config, error) {
+	c := defaultConfig()
+	if err := json.Unmarshal(buf, &c); err != nil {
+		return c, fmt.Errorf(tr("Invalid config file %s: %v"), path, err)
+	}
+
+	for _, name := range []string{STATUS.INCOMPLETE, STATUS.COMPLETE} {
+		if _, ok := c.status(name); !ok {
+			return c, fmt.Errorf(tr(`Invalid config file %s: the "%s" status is missing`), path, name)
+		}
+	}
+	for _, term := range strings.Fields(c.DefaultFilter) {
+		if !isFilterTerm(term) {
+			return c, fmt.Errorf(tr(`Invalid config file %s: "%s" isn't a filter term`), path, term)
+		}
+	}
+	if err := checkDateFormat(c.DateFormat); err != nil {
+		return c, fmt.Errorf(tr("Invalid config file %s: %v"), path, err)
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return c, fmt.Errorf(tr(`Invalid config file %s: unknown timezone "%s"`), path, c.Timezone)
+	}
+	if err := checkStatusline(c.Statusline); err != nil {
+		return c, fmt.Errorf(tr("Invalid config file %s: %v"), path, err)
+	}
+	for name, flags := range c.Defaults {
+		if len(flags) > 0 && !strings.HasPrefix(flags[0], "-") {
+			return c, fmt.Errorf(tr(`Invalid config file %s: the defaults of "%s" must start with a flag, not "%s"`), path, name, flags[0])
+		}
+	}
+	if err := c.Archive.validate(); err != nil {
+		return c, fmt.Errorf(tr("Invalid config file %s: %v"), path, err)
+	}
+	if c.Email != nil {
+		if err := c.Email.validate(); err != nil {
+			return c, fmt.Errorf(tr("Invalid config file %s: %v"), path, err)
+		}
+	}
+	for state, color := range c.Colors {
+		if !slices.Contains(COLOR_STATES, state) {
+			return c, fmt.Errorf(tr(`Invalid config file %s: unknown color state "%s", must be one of %s`), path, state, strings.Join(COLOR_STATES, ", "))
+		}
+		if _, err := parseColor(color); err != nil {
+			return c, fmt.Errorf(tr("Invalid config file %s: %v"), path, err)
+		}
+	}
+	for name, r := range c.Reports {
+		if err := r.validate(name); err != nil {
+			return c, fmt.Errorf(tr("Invalid config file %s: %v"), path, err)
+		}
+	}
+	// Transitions may name a state by an alias
+	for _, s := range c.Statuses {
+		for i, next := range s.Next {
+			n, ok := c.status(next)
+			if !ok {
+				return c, fmt.Errorf(tr(`Invalid config file %s: unknown status "%s"`), path, next)
+			}
+			s.Next[i] = n.Name
+		}
+	}
+	return c, nil
+}
+
+// Set the top level setting `key` of the config file in `dir` to `value`, leaving the rest of
+// the file as is. The file is created if it doesn't exist
+func setConfigValue(dir, key string, value any) error {
+	settings := map[string]json.RawMessage{}
+	buf, err := os.ReadFile(configPath(dir))
+	if err == nil {
+		if err := json.Unmarshal(buf, &settings); err != nil {
+			return fmt.Errorf(tr("Invalid config file %s: %v"), configPath(dir), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if settings[key], err = json.Marshal(value); err != nil {
+		return err
+	}
+	if buf, err = json.MarshalIndent(settings, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(configPath(dir), append(buf, '\n'), 0600)
+}
+
+// Returns the status called `name`, or with `name` as an alias
+func (c Config) status(name string) (Status, bool) {
+	for _, s := range c.Statuses {
+		if s.Name == name || slices.Contains(s.Aliases, name) {
+			return s, true
+		}
+	}
+	return Status{}, false
+}
+
+// Returns the tags of `t` as `list -t` shows them, with the icons of tag_icons in place of the
+// tags that have one
+func displayTags(t Task) string {
+	var tags []string
+	for _, tag := range t.Tags {
+		if icon, ok := config.TagIcons[tag]; ok {
+			tag = icon
+		}
+		tags = append(tags, tag)
+	}
+	return strings.Join(tags, ",")
+}
+
+// Reports whether `t` is in a done state, such as complete or cancelled
+func isDone(t Task) bool {
+	s, ok := config.status(t.Status)
+	return ok && s.Done
+}
+
+// Reports whether `t` is in a done state other than complete, i.e. it was closed without
+// being completed, such as a cancelled task
+func closedIncomplete(t Task) bool {
+	return t.Status != STATUS.COMPLETE && isDone(t)
+}
+
+// Returns the icon of the state `t` is in
+func statusIcon(t Task) string {
+	if s, ok := config.status(t.Status); ok {
+		return s.Icon
+	}
+	// The status was removed from the config
+	return "❔"
+}
+
+// Returns an error if the config doesn't allow `t` to move to the status `to`
+func canMove(t Task, to Status) error {
+	if from, ok := config.status(t.Status); ok && t.Status != to.Name && len(from.Next) > 0 && !slices.Contains(from.Next, to.Name) {
+		return fmt.Errorf(tr("Can't move a task from %s to %s"), t.Status, to.Name)
+	}
+	return nil
+}
+
+// Move `t` to the status `to` at `now`. Moving to a done status records when the task was
+// completed and, while time tracking is on, the time spent in progress is logged
+func setTaskStatus(t *Task, to Status, now time.Time) {
+	if t.Status == to.Name {
+		return
+	}
+	stamp := timestamp(now)
+	if !to.Done {
+		t.Completed = ""
+	} else if !isDone(*t) {
+		t.Completed = stamp
+	}
+	if n := len(t.Intervals); n > 0 && t.Intervals[n-1].End == "" {
+		t.Intervals[n-1].End = stamp
+	}
+	if to.Name != STATUS.BLOCKED {
+		t.BlockedReason = ""
+	}
+	if to.Name == STATUS.IN_PROGRESS && config.TrackTime {
+		t.Intervals = append(t.Intervals, Interval{Start: stamp})
+	}
+	t.Status = to.Name
+}
+
+// Returns the time spent on `t`, counting an interval still open up to `now`
+func timeSpent(t Task, now time.Time) time.Duration {
+	var total time.Duration
+	for _, i := range t.Intervals {
+		start, err := parseTimestamp(i.Start)
+		if err != nil {
+			continue
+		}
+		end := now
+		if i.End != "" {
+			if end, err = parseTimestamp(i.End); err != nil {
+				continue
+			}
+		}
+		total += end.Sub(start)
+	}
+	return total
+}
+
+func newConfigCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	cCmd := &cobra.Command{
+		Use:   "config",
+		Short: tr("Read and change the settings of config.json"),
+		Args:  cobra.NoArgs,
+	}
+	cCmd.AddCommand(newConfigGetCmd(mgr, out), newConfigSetCmd(mgr, out), newConfigListCmd(mgr, out))
+	return cCmd
+}
+
+func newConfigGetCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "get [key]",
+		Short:        tr("Print a setting, such as do.auto_finish. Prints nothing for settings that aren't set"),
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := settingType(args[0]); err != nil {
+				return err
+			}
+			settings, err := flattenConfig(config)
+			if err != nil {
+				return err
+			}
+			if value, ok := settings[args[0]]; ok {
+				fmt.Fprintln(out, value)
+				return nil
+			}
+			// A section such as "email" is printed whole
+			value, err := configValue(config, args[0])
+			if err != nil {
+				return err
+			}
+			if value != "" {
+				fmt.Fprintln(out, value)
+			}
+			return nil
+		},
+	}
+}
+
+func newConfigSetCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "set [key] [value]",
+		Short:        tr("Change a setting, such as do.auto_finish, checking the key and the value"),
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := taskDir()
+			if err != nil {
+				return err
+			}
+			if config, err = setSetting(dir, args[0], args[1]); err != nil {
+				return err
+			}
+			value := args[1]
+			if holdsSecret(args[0]) {
+				if value, err = configValue(config, args[0]); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(out, tr("Set %s to %s\n"), args[0], value)
+			return nil
+		},
+	}
+}
+
+func newConfigListCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        tr("Print every setting in use with its value"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := flattenConfig(config)
+			if err != nil {
+				return err
+			}
+			var keys []string
+			for key := range settings {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			var rows [][]string
+			for _, key := range keys {
+				rows = append(rows, []string{key, settings[key]})
+			}
+			fmt.Fprintln(out, formatTable(rows))
+			return nil
+		},
+	}
+}
+
+// Returns the type of the setting `key`, a path of JSON names separated by dots such as
+// "do.auto_finish", "email.server" or "views.work". Returns an error if there's no such setting
+func settingType(key string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for _, name := range strings.Split(key, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		found := false
+		switch t.Kind() {
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+				if tag == name {
+					t, found = t.Field(i).Type, true
+					break
+				}
+			}
+		case reflect.Map:
+			t, found = t.Elem(), name != ""
+		}
+		if !found {
+			return nil, fmt.Errorf(tr(`Unknown setting "%s"`), key)
+		}
+	}
+	return t, nil
+}
+
+// Returns the settings of `c` by key, such as "do.auto_finish", with their values as
+// formatSetting writes them. Lists, such as statuses, are a single setting
+func flattenConfig(c Config) (map[string]string, error) {
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(buf, &tree); err != nil {
+		return nil, err
+	}
+	maskSecrets(tree)
+	settings := map[string]string{}
+	var flatten func(prefix string, v any)
+	flatten = func(prefix string, v any) {
+		if m, ok := v.(map[string]any); ok {
+			for k, sub := range m {
+				flatten(prefix+k+".", sub)
+			}
+			return
+		}
+		settings[strings.TrimSuffix(prefix, ".")] = formatSetting(v)
+	}
+	flatten("", tree)
+	return settings, nil
+}
+
+// Returns the value of the setting or section `key` of `c`, empty if it isn't set
+func configValue(c Config, key string) (string, error) {
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	var v any
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return "", err
+	}
+	maskSecrets(v)
+	for _, name := range strings.Split(key, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return "", nil
+		}
+		if v, ok = m[name]; !ok {
+			return "", nil
+		}
+	}
+	return formatSetting(v), nil
+}
+
+// Settings that hold secrets, which are never printed
+var secretSettings = []string{"email.password"}
+
+// Printed in place of the value of a secret setting
+const maskedSetting = "********"
+
+// Replace the values of the secretSettings that are set in `tree`, the config file decoded as
+// JSON, with maskedSetting
+func maskSecrets(tree any) {
+	for _, key := range secretSettings {
+		names := strings.Split(key, ".")
+		m, _ := tree.(map[string]any)
+		for _, name := range names[:len(names)-1] {
+			m, _ = m[name].(map[string]any)
+		}
+		if _, ok := m[names[len(names)-1]]; ok {
+			m[names[len(names)-1]] = maskedSetting
+		}
+	}
+}
+
+// Reports whether the setting or section `key` is or holds one of the secretSettings
+func holdsSecret(key string) bool {
+	return slices.ContainsFunc(secretSettings, func(secret string) bool {
+		return secret == key || strings.HasPrefix(secret, key+".")
+	})
+}
+
+// Format the value of a setting: strings as they are and anything else as JSON
+func formatSetting(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	buf, _ := json.Marshal(v)
+	return string(buf)
+}
+
+// Set the setting `key` of the config file in `dir`, see settingType, to `value`, leaving the
+// rest of the file as is. `value` is taken as it is for text settings and read as JSON for the
+// others, such as true or 5000. Nothing is written if the config would be invalid. Returns the
+// new config
+func setSetting(dir, key, value string) (Config, error) {
+	v, err := parseSetting(key, value)
+	if err != nil {
+		return config, err
+	}
+
+	settings := map[string]any{}
+	buf, err := os.ReadFile(configPath(dir))
+	if err == nil {
+		if err := json.Unmarshal(buf, &settings); err != nil {
+			return config, fmt.Errorf(tr("Invalid config file %s: %v"), configPath(dir), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return config, err
+	}
+	putSetting(settings, key, v)
+
+	if buf, err = json.MarshalIndent(settings, "", "  "); err != nil {
+		return config, err
+	}
+	c, err := parseConfig(buf, configPath(dir))
+	if err != nil {
+		return config, err
+	}
+	return c, os.WriteFile(configPath(dir), append(buf, '\n'), 0600)
+}
+
+// Returns `value` as the setting `key` takes it: as it is for text settings and read as JSON
+// for the others
+func parseSetting(key, value string) (any, error) {
+	t, err := settingType(key)
+	if err != nil {
+		return nil, err
+	}
+	var v any = value
+	if t.Kind() != reflect.String {
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf(tr(`Invalid value "%s" for %s, must be JSON such as true, 10 or ["a", "b"]`), value, key)
+		}
+	}
+	return v, nil
+}
+
+// Set the setting `key` of the decoded config file `settings` to `v`, adding the sections
+// it's in if they're missing
+func putSetting(settings map[string]any, key string, v any) {
+	names := strings.Split(key, ".")
+	section := settings
+	for _, name := range names[:len(names)-1] {
+		sub, ok := section[name].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			section[name] = sub
+		}
+		section = sub
+	}
+	section[names[len(names)-1]] = v
+}
+
+// Returns the keys of the settings that aren't sections, such as "track_time" or
+// "do.auto_finish". Maps, such as views, are a single setting
+func settingKeys() []string {
+	var keys []string
+	var walk func(prefix string, t reflect.Type)
+	walk = func(prefix string, t reflect.Type) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		for i := 0; i < t.NumField(); i++ {
+			tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			ft := t.Field(i).Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				walk(prefix+tag+".", ft)
+			} else {
+				keys = append(keys, prefix+tag)
+			}
+		}
+	}
+	walk("", reflect.TypeOf(Config{}))
+	return keys
+}
+
+// Returns the environment variable overriding the setting `key`: TASK_ followed by the key in
+// upper case with dots replaced by underscores, such as TASK_DO_AUTO_FINISH
+func settingEnv(key string) string {
+	return "TASK_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Override the settings of the decoded config file `settings` set in the environment read
+// by `lookupEnv`, see settingEnv. Returns whether any setting was overridden
+func applyEnvSettings(settings map[string]any, lookupEnv func(string) (string, bool)) (bool, error) {
+	applied := false
+	for _, key := range settingKeys() {
+		value, ok := lookupEnv(settingEnv(key))
+		if !ok {
+			continue
+		}
+		v, err := parseSetting(key, value)
+		if err != nil {
+			return applied, fmt.Errorf("%s: %v", settingEnv(key), err)
+		}
+		putSetting(settings, key, v)
+		applied = true
+	}
+	return applied, nil
+}
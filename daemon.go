@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// A command sent to the daemon by the CLI
+type daemonRequest struct {
+	Args []string `json:"args"`
+	// Working directory of the CLI, so relative paths resolve as they would locally
+	Dir    string `json:"dir"`
+	Locale string `json:"locale"`
+	Width  int    `json:"width"`
+	// Whether the CLI's output is a terminal that takes colors
+	Color bool `json:"color"`
+	// Input piped to the CLI, for the commands in stdinCommands
+	Stdin string `json:"stdin"`
+	// TASK_* environment variables of the CLI, which override settings as they would locally
+	Env map[string]string `json:"env"`
+}
+
+// What the CLI prints and exits with once the daemon ran the command
+type daemonResponse struct {
+	Stdout string `json:"stdout"`
+	// Errors, kept apart so the CLI prints them to stderr as a local command would
+	Stderr string `json:"stderr"`
+	Code   int    `json:"code"`
+}
+
+// Commands that have to run in the CLI's own process. remind and api run until interrupted,
+// asking a running daemon to check for due tasks or handle each request
+var localCommands = []string{"daemon", "remind", "api", "completion"}
+
+// Commands that can read their data from stdin, which the CLI forwards to the daemon when
+// it isn't a terminal. api forwards its requests one at a time
+var stdinCommands = [][]string{{"batch"}, {"import"}, {"db", "load"}, {"api"}}
+
+// Reports whether `args` run one of the stdinCommands
+func readsStdin(args []string) bool {
+	return slices.ContainsFunc(stdinCommands, func(name []string) bool {
+		return len(args) >= len(name) && slices.Equal(args[:len(name)], name)
+	})
+}
+
+// Held while the daemon runs a command or sends a scheduled report, since commands share the
+// flag variables
+var daemonMu sync.Mutex
+
+// Returns the path of the socket the daemon listens on
+func socketPath() (string, error) {
+	dir, err := taskDir()
+	if err != nil {
+		return "", err
+	}
+	// Each database set with $TASK_DB has a daemon of its own
+	if os.Getenv("TASK_DB") != "" {
+		return dbPath(dir) + ".sock", nil
+	}
+	return filepath.Join(dir, "task.sock"), nil
+}
+
+func newDaemonCmd(mgr *connectionManager) *cobra.Command {
+	dCmd := &cobra.Command{
+		Use:          "daemon",
+		Short:        tr("Keep the database open and run commands sent by the CLI"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := socketPath()
+			if err != nil {
+				return err
+			}
+			// The db is locked by this process, so a socket left behind is from a daemon that
+			// didn't shut down cleanly
+			os.Remove(path)
+			l, err := net.Listen("unix", path)
+			if err != nil {
+				return err
+			}
+
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sig
+				l.Close()
+			}()
+
+			fmt.Fprintf(cmd.OutOrStdout(), tr("Listening on %s\n"), path)
+			done := make(chan struct{})
+			defer close(done)
+			go scheduleReports(mgr, filepath.Dir(path), cmd.OutOrStdout(), cmd.ErrOrStderr(), done)
+			serveDaemon(mgr, l)
+			return nil
+		},
+	}
+	dCmd.AddCommand(newDaemonInstallCmd(), newDaemonUninstallCmd(), newDaemonStatusCmd())
+	return dCmd
+}
+
+// Accept connections on `l` until it is closed, running one command per connection.
+// Commands run one at a time since they share the flag variables
+func serveDaemon(mgr *connectionManager, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			var req daemonRequest
+			if err := json.NewDecoder(conn).Decode(&req); err != nil {
+				return
+			}
+			daemonMu.Lock()
+			res := runDaemonRequest(mgr, req)
+			daemonMu.Unlock()
+			json.NewEncoder(conn).Encode(res)
+		}()
+	}
+}
+
+// Run the command described by `req` against the daemon's db
+func runDaemonRequest(mgr *connectionManager, req daemonRequest) (res daemonResponse) {
+	var stdout, stderr bytes.Buffer
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintln(&stderr, "Error:", r)
+			res = daemonResponse{Stdout: stdout.String(), Stderr: stderr.String(), Code: 1}
+		}
+	}()
+
+	// Present the command as if it ran in the CLI's process
+	wd, _ := os.Getwd()
+	if req.Dir != "" {
+		os.Chdir(req.Dir)
+		defer os.Chdir(wd)
+	}
+	prevLocale, prevWidth, prevColor, prevEdit, prevInteractive := locale, terminalWidth, colorOutput, editText, startInteractive
+	prevConfig, prevLocation := config, time.Local
+	defer func() {
+		locale, terminalWidth, colorOutput, editText, startInteractive = prevLocale, prevWidth, prevColor, prevEdit, prevInteractive
+		config, time.Local = prevConfig, prevLocation
+	}()
+	// Settings are read again with the CLI's environment in place of the daemon's
+	dir, err := taskDir()
+	if err == nil {
+		config, err = loadConfigEnv(dir, func(key string) (string, bool) {
+			value, ok := req.Env[key]
+			return value, ok
+		})
+	}
+	if err == nil {
+		err = setTimezone(config.Timezone)
+	}
+	if err != nil {
+		return daemonResponse{Stderr: fmt.Sprintln("Error:", err), Code: 1}
+	}
+	if req.Locale != "" {
+		locale = req.Locale
+	}
+	terminalWidth = func() int { return req.Width }
+	colorOutput = func() bool { return req.Color }
+	editText = func(string) (string, error) {
+		return "", errors.New(tr("The editor can't be opened while the daemon is running, pass the task as an argument instead"))
+	}
+	startInteractive = func(*cobra.Command, io.Writer) (*bufio.Reader, io.Writer, func(), error) {
+		return nil, nil, nil, errors.New(tr("Interactive mode can't be used while the daemon is running"))
+	}
+
+	root := newRootCmd()
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return checkCommand(cmd)
+	}
+	root.AddCommand(newSubcommands(mgr, &stdout)...)
+	args, filter, err := parseCommandLine(root, req.Args)
+	if err != nil {
+		return daemonResponse{Stderr: fmt.Sprintln("Error:", err), Code: exitCode(filteredCommand(root, req.Args), err)}
+	}
+	CommandFilter = filter
+	root.SetArgs(args)
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	// Only piped input is forwarded, so prompts read no answer
+	root.SetIn(strings.NewReader(req.Stdin))
+	if cmd, err := root.ExecuteC(); err != nil {
+		return daemonResponse{Stdout: stdout.String(), Stderr: stderr.String(), Code: exitCode(cmd, err)}
+	}
+	return daemonResponse{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Returns the TASK_* environment variables, sent along with commands to the daemon
+func taskEnv() map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		if key, value, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "TASK_") {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// Connect to the daemon listening at `path`
+func dialDaemon(path string) (net.Conn, error) {
+	return net.DialTimeout("unix", path, 100*time.Millisecond)
+}
+
+// Send `req` to the daemon listening at `path`
+func sendToDaemon(path string, req daemonRequest) (daemonResponse, error) {
+	conn, err := dialDaemon(path)
+	if err != nil {
+		return daemonResponse{}, err
+	}
+	defer conn.Close()
+	return exchangeRequest(conn, req)
+}
+
+// Send `req` to the daemon over `conn` and read its response
+func exchangeRequest(conn net.Conn, req daemonRequest) (daemonResponse, error) {
+	var res daemonResponse
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return res, err
+	}
+	err := json.NewDecoder(conn).Decode(&res)
+	return res, err
+}
+
+// Run `args` through a running daemon, printing its output to `out` and its errors to stderr.
+// Input piped to `in` is sent along for the commands that read it. Returns false if there is
+// no daemon to delegate to, in which case the command should run in this process
+func delegate(args []string, in io.Reader, out io.Writer) (int, bool) {
+	if len(args) > 0 && slices.Contains(localCommands, args[0]) {
+		return 0, false
+	}
+	// Completions are handed over too, since task IDs are read from the db. The daemon has
+	// all the commands but its own
+	if completesDaemon(args) {
+		return 0, false
+	}
+	return runOnDaemon(args, in, out)
+}
+
+// Reports whether `args` ask the shell completion for the arguments of the daemon command
+func completesDaemon(args []string) bool {
+	return len(args) > 1 && (args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd) && args[1] == "daemon"
+}
+
+// Run `args` through a running daemon like delegate, local commands included, e.g. for
+// remind to check for due tasks while the daemon holds the db
+func runOnDaemon(args []string, in io.Reader, out io.Writer) (int, bool) {
+	path, err := socketPath()
+	if err != nil {
+		return 0, false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return 0, false
+	}
+
+	// Connect before reading stdin, so the command can still run here with its input when
+	// the socket was left behind by a daemon that is gone
+	conn, err := dialDaemon(path)
+	if err != nil {
+		return 0, false
+	}
+	defer conn.Close()
+
+	dir, _ := os.Getwd()
+	req := daemonRequest{
+		Args:   args,
+		Dir:    dir,
+		Locale: locale,
+		Width:  terminalWidth(),
+		Color:  colorOutput(),
+		Env:    taskEnv(),
+	}
+	// A terminal is left alone, reading it would wait for the user to type a whole input
+	if f, ok := in.(*os.File); readsStdin(args) && !(ok && isTerminal(f)) {
+		buf, err := io.ReadAll(in)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1, true
+		}
+		req.Stdin = string(buf)
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		// The daemon never got the command, so it can run here unless its input was used up
+		if req.Stdin != "" {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1, true
+		}
+		return 0, false
+	}
+	var res daemonResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		// The daemon may have run the command before the connection was lost, so running it
+		// here could apply it twice
+		fmt.Fprintln(os.Stderr, "Error:", fmt.Sprintf(tr("Lost the connection to the daemon, the command may have run: %v"), err))
+		return 1, true
+	}
+	fmt.Fprint(out, res.Stdout)
+	fmt.Fprint(os.Stderr, res.Stderr)
+	return res.Code, true
+}
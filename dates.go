@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The timezone of the system, used when the config file doesn't set one
+var systemLocation = time.Local
+
+// Count days and show dates in the timezone `name`, an IANA name such as "Europe/Madrid", or
+// the system's if it's empty
+func setTimezone(name string) error {
+	if name == "" {
+		time.Local = systemLocation
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf(tr(`Unknown timezone "%s"`), name)
+	}
+	time.Local = loc
+	return nil
+}
+
+// Format `t` as the RFC3339 timestamp it's stored as, in UTC whatever timezone it was
+// recorded in
+func timestamp(t time.Time) string {
+	return t.UTC().Format(RFC3339)
+}
+
+// Parse an RFC3339 timestamp, in the local timezone so its day is the user's day
+func parseTimestamp(ts string) (time.Time, error) {
+	t, err := time.Parse(RFC3339, ts)
+	return t.Local(), err
+}
+
+// Layout of the dates shown to users when date_format isn't set
+const defaultDateLayout = "01/02/2006"
+
+// Layouts of `"date_format": "locale"`, by language. Languages missing here use
+// defaultDateLayout
+var localeDateLayouts = map[string]string{
+	"en": "01/02/2006",
+	"es": "02/01/2006",
+	"ja": "2006/01/02",
+}
+
+// Returns the layout dates are shown in: date_format from the config file, the layout of the
+// user's language if it's "locale", or defaultDateLayout if it isn't set
+func dateLayout() string {
+	switch config.DateFormat {
+	case "":
+		return defaultDateLayout
+	case "locale":
+		if layout, ok := localeDateLayouts[locale]; ok {
+			return layout
+		}
+		return defaultDateLayout
+	}
+	return config.DateFormat
+}
+
+// Returns an error if `layout` isn't a date_format: "locale" or a Go time layout
+func checkDateFormat(layout string) error {
+	// A layout without any element formats to itself. The date differs from the reference date
+	// in every element, so a layout made of elements doesn't
+	if layout != "" && layout != "locale" && time.Date(2001, 11, 22, 13, 14, 15, 0, time.UTC).Format(layout) == layout {
+		return fmt.Errorf(tr(`invalid date_format "%s", must be "locale" or a layout such as "2006-01-02" or "Jan 2"`), layout)
+	}
+	return nil
+}
+
+// Format the day of `t` in the layout of dateLayout
+func formatDate(t time.Time) string {
+	return t.Format(dateLayout())
+}
+
+// Format the RFC3339 timestamp `ts` as a day in the layout of dateLayout, with the time of day
+// if `withTime` is set. Returns "-" for empty or invalid timestamps
+func formatDateStamp(ts string, withTime bool) string {
+	if withTime {
+		return formatTimestamp(ts, dateLayout()+" 15:04")
+	}
+	return formatTimestamp(ts, dateLayout())
+}
+
+// Format the RFC3339 timestamp `ts` of when a task was created, completed or is due. With
+// --relative it's described relative to now, such as "2d ago" or "in 3h", otherwise it's
+// formatted as formatDateStamp does. Returns "-" for empty or invalid timestamps
+func formatWhen(ts string, withTime bool) string {
+	if !RelativeDates {
+		return formatDateStamp(ts, withTime)
+	}
+	t, err := parseTimestamp(ts)
+	if err != nil {
+		return "-"
+	}
+	return formatRelative(t, time.Now(), withTime)
+}
+
+// Describe `t` relative to `now`. With `withTime`, times less than a day away are described
+// in minutes or hours, such as "5m ago" or "in 3h". Otherwise `t` is a day, such as a due date,
+// described as "today", "yesterday", "tomorrow" or in days, weeks, months or years such as
+// "3d ago" or "in 2w"
+func formatRelative(t, now time.Time, withTime bool) string {
+	ago := func(s string, past bool) string {
+		if past {
+			return fmt.Sprintf(tr("%s ago"), s)
+		}
+		return fmt.Sprintf(tr("in %s"), s)
+	}
+
+	if withTime {
+		t = t.In(now.Location())
+		d := now.Sub(t)
+		past := d >= 0
+		if !past {
+			d = -d
+		}
+		switch {
+		case d < time.Minute:
+			return tr("just now")
+		case d < time.Hour:
+			return ago(fmt.Sprintf("%dm", int(d.Minutes())), past)
+		case d < 24*time.Hour:
+			return ago(fmt.Sprintf("%dh", int(d.Hours())), past)
+		}
+	}
+
+	// Calendar days apart, rounded since days around DST changes aren't 24 hours long
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	y, m, d = now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	days := int(math.Round(day.Sub(today).Hours() / 24))
+	switch days {
+	case 0:
+		return tr("today")
+	case -1:
+		return tr("yesterday")
+	case 1:
+		return tr("tomorrow")
+	}
+
+	n := days
+	if n < 0 {
+		n = -n
+	}
+	var s string
+	switch {
+	case n < 14:
+		s = fmt.Sprintf("%dd", n)
+	case n < 60:
+		s = fmt.Sprintf("%dw", n/7)
+	case n < 365:
+		s = fmt.Sprintf("%dmo", n/30)
+	default:
+		s = fmt.Sprintf("%dy", n/365)
+	}
+	return ago(s, days < 0)
+}
+
+// Matches periods such as "30d", "2w" or "6m"
+var agoRegex = regexp.MustCompile(`^(\d+)([dwm])$`)
+
+// Returns the start of the day a period such as "30d", "2w" or "6m", a number of days, weeks
+// or months, before `now`
+func parseAgo(s string, now time.Time) (time.Time, error) {
+	match := agoRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if match == nil {
+		return time.Time{}, fmt.Errorf(tr(`Invalid period "%s", must be a number of days, weeks or months such as 30d, 2w or 6m`), s)
+	}
+	n, _ := strconv.Atoi(match[1])
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch match[2] {
+	case "w":
+		return today.AddDate(0, 0, -7*n), nil
+	case "m":
+		return today.AddDate(0, -n, 0), nil
+	}
+	return today.AddDate(0, 0, -n), nil
+}
+
+// Matches relative dates such as "in 3 days" or "in 1 week"
+var relativeDateRegex = regexp.MustCompile(`^in (\d+) (day|week|month)s?$`)
+
+// Parse a date relative to `now`, in the local timezone of `now`. Accepts mm/dd/yyyy and
+// yyyy-mm-dd formated dates as well as "today", "tomorrow", "yesterday", weekday names
+// such as "friday" or "next fri" for the next such day, "eow" and "eom" for the last day of the
+// week and of the month, and "in N days|weeks|months".
+// The returned time is the start of the day
+func parseDate(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, layout := range []string{"01/02/2006", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch s {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "eow":
+		// Weeks end on Sunday
+		return today.AddDate(0, 0, (7-int(now.Weekday()))%7), nil
+	case "eom":
+		return time.Date(y, m+1, 0, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	if match := relativeDateRegex.FindStringSubmatch(s); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err == nil {
+			switch match[2] {
+			case "day":
+				return today.AddDate(0, 0, n), nil
+			case "week":
+				return today.AddDate(0, 0, 7*n), nil
+			case "month":
+				return today.AddDate(0, n, 0), nil
+			}
+		}
+	}
+
+	day := strings.TrimPrefix(s, "next ")
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if day == name || day == name[:3] {
+			// Always a day in the future, a week from today if today is that day
+			ahead := (int(wd)-int(now.Weekday())+6)%7 + 1
+			return today.AddDate(0, 0, ahead), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf(tr(`Can't understand the date "%s"`), s)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+func newDBCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	dCmd := &cobra.Command{
+		Use:   "db",
+		Short: tr("Manage the database file"),
+		Args:  cobra.NoArgs,
+	}
+	dCmd.AddCommand(newDBMergeCmd(mgr, out), newDBDumpCmd(mgr, out), newDBLoadCmd(mgr, out))
+	return dCmd
+}
+
+func newDBMergeCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	mCmd := &cobra.Command{
+		Use:          "merge [file] [--dry-run]",
+		Short:        tr("Merge the tasks and archive of another database file into this one"),
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := taskDir()
+			if err != nil {
+				return err
+			}
+			if a, err := os.Stat(args[0]); err != nil {
+				return err
+			} else if b, err := os.Stat(dbPath(dir)); err == nil && os.SameFile(a, b) {
+				return errors.New(tr("Can't merge the database into itself"))
+			}
+
+			other, err := bolt.Open(args[0], 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+			if err == bolt.ErrTimeout {
+				return fmt.Errorf(tr("The database at %s is locked by another process. Close it and try again"), args[0])
+			}
+			if err != nil {
+				return fmt.Errorf(tr("Could not open %s: %v"), args[0], err)
+			}
+			var tasks, archive []Task
+			for _, t := range getTasks(other, TASKS_BUCKET) {
+				tasks = append(tasks, t.task)
+			}
+			for _, t := range getTasks(other, ARCHIVE_BUCKET) {
+				archive = append(archive, t.task)
+			}
+			other.Close()
+
+			policy := duplicatePolicy(DuplicatesMerge, MergeSkipDuplicates, MergeOverwrite)
+			// Tasks from before UUIDs are matched by description and creation time
+			same := func(existing, t Task) bool {
+				if t.UUID != "" && existing.UUID == t.UUID {
+					return true
+				}
+				return existing.Desc == t.Desc && existing.Created == t.Created
+			}
+			var overwrite []string
+			for _, f := range historyFields {
+				overwrite = append(overwrite, f.name)
+			}
+			var r ImportResult
+			err = mgr.db.Update(func(tx *bolt.Tx) error {
+				if r, err = addImported(tx, tasks, archive, policy, same, overwrite); err != nil {
+					return err
+				}
+				if MergeDryRun {
+					return errDryRun
+				}
+				return nil
+			})
+			if err != nil && err != errDryRun {
+				return err
+			}
+			fmt.Fprintln(out, formatImportResult(r, MergeDryRun))
+			return nil
+		},
+	}
+	mCmd.Flags().BoolVar(&MergeDryRun, "dry-run", false, "Print what would be merged without changing anything")
+	mCmd.Flags().BoolVar(&MergeSkipDuplicates, "skip-duplicates", false, "Only add the missing tasks, leaving the tasks you already have as they are")
+	mCmd.Flags().BoolVar(&MergeOverwrite, "overwrite", false, "Replace the tasks you already have with their version in the other database instead of merging them")
+	mCmd.MarkFlagsMutuallyExclusive("skip-duplicates", "overwrite")
+	return mCmd
+}
+
+func newDBDumpCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "dump",
+		Short:        tr("Print the whole database as text that db load reads back"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			buf, err := json.MarshalIndent(dumpDatabase(mgr.db), "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, string(buf))
+			return nil
+		},
+	}
+}
+
+func newDBLoadCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	lCmd := &cobra.Command{
+		Use:          "load [file] -[y]",
+		Short:        tr("Replace the whole database with a file written by db dump, - for stdin"),
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var buf []byte
+			var err error
+			if args[0] == "-" {
+				// The dump uses up stdin, so it can't answer the question too
+				if !LoadYes {
+					return errors.New(tr("Use -y to load a dump from stdin"))
+				}
+				buf, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				buf, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			dump, err := parseDump(buf)
+			if err != nil {
+				return fmt.Errorf(tr("Invalid dump %s: %v"), args[0], err)
+			}
+
+			db := mgr.db
+			prompt := fmt.Sprintf(tr("Replace the %d tasks and %d archived tasks of the database with the %d tasks and %d archived tasks of %s?"),
+				len(getTasks(db, TASKS_BUCKET)), len(getTasks(db, ARCHIVE_BUCKET)), len(dump.Tasks), len(dump.Archive), args[0])
+			if !LoadYes && !confirm(cmd.InOrStdin(), out, prompt) {
+				fmt.Fprintln(out, tr("Aborted, nothing was changed"))
+				return nil
+			}
+			// The tasks being replaced can be recovered from the backup
+			dir, err := taskDir()
+			if err != nil {
+				return err
+			}
+			path, err := writeBackup(db, backupDir(dir), time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Saved a backup to %s\n"), path)
+
+			if err := loadDump(db, dump); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Loaded %d tasks and %d archived tasks\n"), len(dump.Tasks), len(dump.Archive))
+			return nil
+		},
+	}
+	lCmd.Flags().BoolVarP(&LoadYes, "yes", "y", false, "Don't ask for confirmation")
+	return lCmd
+}
+
+// Name and version of the format written by `db dump`. The version goes up when a change
+// to the format needs `db load` to convert older dumps
+const (
+	dumpFormat  = "task-cli"
+	dumpVersion = 1
+)
+
+// The whole database as written by `db dump`: the tasks of the list and of the archive with
+// their IDs, in ID order
+type databaseDump struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Tasks   []APITask `json:"tasks"`
+	Archive []APITask `json:"archive"`
+}
+
+// Returns the contents of `db` to dump
+func dumpDatabase(db *bolt.DB) databaseDump {
+	d := databaseDump{Format: dumpFormat, Version: dumpVersion, Tasks: []APITask{}, Archive: []APITask{}}
+	db.View(func(tx *bolt.Tx) error {
+		for _, section := range []struct {
+			bucket []byte
+			tasks  *[]APITask
+		}{{TASKS_BUCKET, &d.Tasks}, {ARCHIVE_BUCKET, &d.Archive}} {
+			if b := tx.Bucket(section.bucket); b != nil {
+				b.ForEach(func(k, v []byte) error {
+					*section.tasks = append(*section.tasks, APITask{btoi(k), bToTask(v)})
+					return nil
+				})
+			}
+		}
+		return nil
+	})
+	return d
+}
+
+// Parse and check a dump written by `db dump`
+func parseDump(buf []byte) (databaseDump, error) {
+	var d databaseDump
+	if err := json.Unmarshal(buf, &d); err != nil {
+		return d, err
+	}
+	if d.Format != dumpFormat {
+		return d, fmt.Errorf(tr(`format must be "%s"`), dumpFormat)
+	}
+	if d.Version < 1 || d.Version > dumpVersion {
+		return d, fmt.Errorf(tr("unsupported version %d, this version of task reads version %d"), d.Version, dumpVersion)
+	}
+	for _, tasks := range [][]APITask{d.Tasks, d.Archive} {
+		seen := map[int]bool{}
+		for _, t := range tasks {
+			if t.ID < 1 {
+				return d, fmt.Errorf(tr(`invalid ID %d of "%s", must be 1 or more`), t.ID, t.Desc)
+			}
+			if seen[t.ID] {
+				return d, fmt.Errorf(tr("ID %d is used twice"), t.ID)
+			}
+			seen[t.ID] = true
+		}
+	}
+	return d, nil
+}
+
+// Replace the tasks and archive of `db` with those of `d`, all at once. Tasks keep their order
+// but are numbered from 1 without gaps, which a hand-edited dump may have
+func loadDump(db *bolt.DB, d databaseDump) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, section := range []struct {
+			bucket []byte
+			tasks  []APITask
+		}{{TASKS_BUCKET, d.Tasks}, {ARCHIVE_BUCKET, d.Archive}} {
+			if err := tx.DeleteBucket(section.bucket); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			b, err := tx.CreateBucket(section.bucket)
+			if err != nil {
+				return err
+			}
+			tasks := slices.Clone(section.tasks)
+			slices.SortFunc(tasks, func(a, b APITask) int { return a.ID - b.ID })
+			for i, t := range tasks {
+				buf, err := json.Marshal(t.Task)
+				if err != nil {
+					return err
+				}
+				if err := b.Put(itob(i+1), buf); err != nil {
+					return err
+				}
+			}
+			// New tasks are added after the loaded ones
+			if err := b.SetSequence(uint64(len(tasks))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newEditCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	eCmd := &cobra.Command{
+		Use:          "edit [taskID...] [--all]",
+		Short:        tr("Edit the descriptions, tags and due dates of tasks as text in your $EDITOR"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			var tp []TaskPosition
+			if EditAll {
+				if len(args) > 0 || len(CommandFilter) > 0 {
+					return errors.New(tr("Can't use task IDs or a filter in combination with --all"))
+				}
+				for _, t := range getTasks(db, TASKS_BUCKET) {
+					if !isDone(t.task) {
+						tp = append(tp, t)
+					}
+				}
+			} else {
+				ids, err := parseTaskIDs(db, args)
+				if err != nil {
+					return err
+				}
+				for _, t := range getTasks(db, TASKS_BUCKET) {
+					if slices.Contains(ids, t.dbKey) {
+						tp = append(tp, t)
+					}
+				}
+			}
+			if len(tp) == 0 {
+				fmt.Fprintln(out, tr("No tasks"))
+				return nil
+			}
+
+			text, err := editText(formatEditable(tp))
+			if err != nil {
+				return err
+			}
+			edits, err := parseEditable(text, tp, time.Now())
+			if err != nil {
+				return err
+			}
+			if len(edits) == 0 {
+				fmt.Fprintln(out, tr("No changes"))
+				return nil
+			}
+
+			var keys []int
+			for _, e := range edits {
+				keys = append(keys, e.key)
+			}
+			// updateTasks goes through the keys in order
+			i := 0
+			err = updateTasks(db, keys, func(t *Task) error {
+				t.Desc, t.Tags, t.Due = edits[i].desc, edits[i].tags, edits[i].due
+				i++
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				fmt.Fprintf(out, tr("Updated task %d\n"), key)
+			}
+			return nil
+		},
+	}
+	eCmd.Flags().BoolVarP(&EditAll, "all", "a", false, "Edit every open task")
+	return eCmd
+}
+
+// The description, tags and due date of a task after editing it as text
+type editedTask struct {
+	key  int
+	desc string
+	tags []string
+	due  string
+}
+
+// Layout of due dates in the text edited by `edit`, which has to read them back
+const editDateLayout = "2006-01-02"
+
+// Format `tp` as text to edit, one line per task: its ID, description, +tags and due date
+func formatEditable(tp []TaskPosition) string {
+	lines := []string{
+		tr("# Edit the descriptions, +tags and due:yyyy-mm-dd dates of the tasks below, then save and"),
+		tr("# close the editor. Lines starting with # are ignored, removing a line leaves its task as it is"),
+	}
+	for _, t := range tp {
+		fields := []string{strconv.Itoa(t.dbKey), strings.Join(strings.Fields(t.task.Desc), " ")}
+		for _, tag := range t.task.Tags {
+			fields = append(fields, "+"+tag)
+		}
+		if t.task.Due != "" {
+			fields = append(fields, "due:"+formatTimestamp(t.task.Due, editDateLayout))
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Parse the text written by formatEditable for `tp` once edited. Due dates may be written in
+// any form `add -d` accepts, relative to `now`, with due:none clearing them. Returns the tasks
+// that changed, in the order of `tp`, or an error naming the first invalid line
+func parseEditable(text string, tp []TaskPosition, now time.Time) ([]editedTask, error) {
+	edited := map[int]editedTask{}
+	for n, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key, err := strconv.Atoi(fields[0])
+		if err != nil || !slices.ContainsFunc(tp, func(t TaskPosition) bool { return t.dbKey == key }) {
+			return nil, fmt.Errorf(tr(`Line %d: "%s" isn't the ID of a task being edited`), n+1, fields[0])
+		}
+		if _, ok := edited[key]; ok {
+			return nil, fmt.Errorf(tr("Line %d: task %d is listed twice"), n+1, key)
+		}
+
+		e := editedTask{key: key}
+		var words []string
+		for _, field := range fields[1:] {
+			if tag, ok := strings.CutPrefix(field, "+"); ok && tag != "" {
+				if !slices.Contains(e.tags, tag) {
+					e.tags = append(e.tags, tag)
+				}
+			} else if due, ok := strings.CutPrefix(field, "due:"); ok {
+				if due == "" || strings.ToLower(due) == "none" {
+					e.due = ""
+					continue
+				}
+				d, err := parseDate(due, now)
+				if err != nil {
+					return nil, fmt.Errorf(tr("Line %d: %v"), n+1, err)
+				}
+				e.due = d.Format(RFC3339)
+			} else {
+				words = append(words, field)
+			}
+		}
+		if e.desc = strings.Join(words, " "); e.desc == "" {
+			return nil, fmt.Errorf(tr("Line %d: %v"), n+1, tr("Must provide a task description"))
+		}
+		edited[key] = e
+	}
+
+	var changed []editedTask
+	for _, t := range tp {
+		e, ok := edited[t.dbKey]
+		if !ok {
+			continue
+		}
+		// Descriptions were written with their spaces collapsed and dates without their time
+		if e.desc == strings.Join(strings.Fields(t.task.Desc), " ") {
+			e.desc = t.task.Desc
+		}
+		if e.due != "" && t.task.Due != "" && formatTimestamp(e.due, editDateLayout) == formatTimestamp(t.task.Due, editDateLayout) {
+			e.due = t.task.Due
+		}
+		if e.desc != t.task.Desc || e.due != t.task.Due || !slices.Equal(e.tags, t.task.Tags) {
+			changed = append(changed, e)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// The SMTP server and addresses reports are emailed with, the email section of the config file
+type EmailConfig struct {
+	// host:port of the SMTP server, e.g. "smtp.example.com:587". STARTTLS is used when the
+	// server supports it
+	Server string `json:"server"`
+	// Login of the server, no authentication if empty
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	// When `daemon` emails the weekly report, a weekday and a 24-hour time such as
+	// "monday 08:00". The report is only sent by hand if empty
+	Weekly string `json:"weekly,omitempty"`
+}
+
+// Send an email through an SMTP server. A variable so tests can capture the email instead
+var sendMail = smtp.SendMail
+
+// Returns an error if `e` is missing the server or an address, or has an invalid schedule
+func (e EmailConfig) validate() error {
+	if _, _, err := net.SplitHostPort(e.Server); err != nil {
+		return fmt.Errorf(tr(`email: invalid server "%s", must be host:port`), e.Server)
+	}
+	if e.From == "" || len(e.To) == 0 {
+		return errors.New(tr("email: from and to must be set"))
+	}
+	if e.Weekly != "" {
+		if _, err := parseWeeklySchedule(e.Weekly); err != nil {
+			return fmt.Errorf("email: %v", err)
+		}
+	}
+	return nil
+}
+
+// Email `body` with `subject` from and to the addresses of `e`, dated `now`
+func sendEmail(e EmailConfig, subject, body string, now time.Time) error {
+	host, _, _ := net.SplitHostPort(e.Server)
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	return sendMail(e.Server, auth, e.From, e.To, emailMessage(e, subject, body, now))
+}
+
+// Build a plain text email of `body` with its headers. Non-ASCII subjects are encoded
+func emailMessage(e EmailConfig, subject, body string, now time.Time) []byte {
+	headers := []string{
+		"From: " + e.From,
+		"To: " + strings.Join(e.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(body, "\n", "\r\n") + "\r\n"
+	return []byte(msg)
+}
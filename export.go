@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Width of the printable layout, in characters
+const printWidth = 80
+
+// Fewest lines a printed page can have: the page header and a few tasks
+const minPageLength = 10
+
+func newExportCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	eCmd := &cobra.Command{
+		Use:          "export -[fp] [--page-length n] [--tag tags] [--status status] [--since date]",
+		Short:        tr("Print the task list as JSON or CSV, or laid out for printing"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			filter, err := exportFilter(ExportTags, ExportStatus, ExportSince, now)
+			if err != nil {
+				return err
+			}
+			tp := filter.Apply(getTasks(mgr.db, TASKS_BUCKET))
+			if ExportPrint {
+				if ExportPageLength < minPageLength {
+					return fmt.Errorf(tr("Invalid page length %d, must be at least %d lines"), ExportPageLength, minPageLength)
+				}
+				fmt.Fprint(out, formatPrintable(tp, now, ExportPageLength))
+				return nil
+			}
+			var tasks []Task
+			for _, t := range tp {
+				tasks = append(tasks, t.task)
+			}
+			return exportTasks(out, tasks, TaskExportFormat)
+		},
+	}
+	eCmd.Flags().StringVarP(&TaskExportFormat, "format", "f", "json", "Output format, json or csv")
+	eCmd.Flags().BoolVarP(&ExportPrint, "print", "p", false, "Lay out the open tasks for printing, in pages separated by form feeds. Pipe it to lpr or save it to a file")
+	eCmd.Flags().IntVar(&ExportPageLength, "page-length", 60, "Number of lines on a printed page")
+	eCmd.Flags().StringVarP(&ExportTags, "tag", "t", "", "Only export tasks carrying all of these comma separated tags")
+	eCmd.Flags().StringVarP(&ExportStatus, "status", "s", "", "Only export tasks in this status")
+	eCmd.Flags().StringVar(&ExportSince, "since", "", "Only export tasks created or changed since this date, or in the last 30d, 2w or 6m")
+	eCmd.MarkFlagsMutuallyExclusive("format", "print")
+	eCmd.RegisterFlagCompletionFunc("status", completeStatuses)
+	return eCmd
+}
+
+// Returns the filter of the tasks `export` exports: those carrying every tag of the comma
+// separated `tags`, in `status` and created or changed since `since`, the start of a day
+// parseDate or parseAgo accepts. Empty conditions match every task
+func exportFilter(tags, status, since string, now time.Time) (Filter, error) {
+	var f Filter
+	for _, tag := range splitTags(tags) {
+		f = append(f, func(t Task) bool { return slices.Contains(t.Tags, tag) })
+	}
+	if status != "" {
+		name, err := parseStatus(status)
+		if err != nil {
+			return nil, err
+		}
+		f = append(f, func(t Task) bool { return t.Status == name })
+	}
+	if since == "" {
+		return f, nil
+	}
+
+	start, err := parseAgo(since, now)
+	if err != nil {
+		if start, err = parseDate(since, now); err != nil {
+			return nil, err
+		}
+	}
+	f = append(f, func(t Task) bool {
+		last := t.Created
+		for _, ts := range []string{t.Completed, lastChange(t)} {
+			if compareTimestamps(ts, last) > 0 {
+				last = ts
+			}
+		}
+		changed, err := parseTimestamp(last)
+		return err == nil && !changed.Before(start)
+	})
+	return f, nil
+}
+
+// Lay out the open tasks of `tp` for printing, in pages of `pageLength` lines separated by form
+// feeds. Each page starts with the date of `now` and its page number, and tasks are listed with
+// a checkbox in the due sections of `list --group due`. A task is never split across pages
+func formatPrintable(tp []TaskPosition, now time.Time, pageLength int) string {
+	var open []TaskPosition
+	idWidth := 0
+	for _, t := range tp {
+		if !isDone(t.task) {
+			open = append(open, t)
+			idWidth = max(idWidth, len(strconv.Itoa(t.dbKey)))
+		}
+	}
+
+	// The header takes a title line, a rule and a blank line
+	bodyLength := pageLength - 3
+	var pages [][]string
+	var page []string
+	add := func(block []string) {
+		if len(page) > 0 && len(page)+len(block) > bodyLength {
+			pages = append(pages, page)
+			page = nil
+		}
+		if len(page) == 0 && block[0] == "" {
+			block = block[1:]
+		}
+		page = append(page, block...)
+	}
+	for i, g := range groupTasks(open, "due", now) {
+		for j, t := range g.Tasks {
+			block := printEntry(t, idWidth)
+			// A heading stays on the page of its first task
+			if j == 0 {
+				block = append([]string{fmt.Sprintf("%s (%d)", g.Name, len(g.Tasks))}, block...)
+				if i > 0 {
+					block = append([]string{""}, block...)
+				}
+			}
+			add(block)
+		}
+	}
+	if len(open) == 0 {
+		page = []string{tr("No tasks")}
+	}
+	pages = append(pages, page)
+
+	title := fmt.Sprintf(tr("Tasks for %s"), now.Format("Monday")+" "+formatDate(now))
+	var builder strings.Builder
+	for i, lines := range pages {
+		if i > 0 {
+			builder.WriteString("\f")
+		}
+		number := fmt.Sprintf(tr("Page %d of %d"), i+1, len(pages))
+		builder.WriteString(title + strings.Repeat(" ", max(1, printWidth-textWidth(title)-textWidth(number))) + number + "\n")
+		builder.WriteString(strings.Repeat("=", printWidth) + "\n\n")
+		for _, line := range lines {
+			builder.WriteString(line + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// Returns the lines of `t` in the printable layout: a checkbox, its ID padded to `idWidth` and
+// its description wrapped to the page, followed by a line with its status, due date,
+// priority, tags and assignee when it has any
+func printEntry(t TaskPosition, idWidth int) []string {
+	prefix := "[ ] " + padRight(strconv.Itoa(t.dbKey), idWidth) + "  "
+	indent := strings.Repeat(" ", textWidth(prefix))
+
+	var lines []string
+	for i, line := range wrapText(t.task.Desc, printWidth-len(prefix)) {
+		if i == 0 {
+			lines = append(lines, prefix+line)
+		} else {
+			lines = append(lines, indent+line)
+		}
+	}
+
+	var details []string
+	if t.task.Status != STATUS.INCOMPLETE {
+		details = append(details, t.task.Status)
+	}
+	if t.task.Due != "" {
+		details = append(details, fmt.Sprintf(tr("due %s"), formatDateStamp(t.task.Due, false)))
+	}
+	if t.task.Priority != "" {
+		details = append(details, t.task.Priority)
+	}
+	if len(t.task.Tags) > 0 {
+		details = append(details, "+"+strings.Join(t.task.Tags, " +"))
+	}
+	if t.task.Assignee != "" {
+		details = append(details, "@"+t.task.Assignee)
+	}
+	if len(details) > 0 {
+		lines = append(lines, indent+strings.Join(details, " · "))
+	}
+	return lines
+}
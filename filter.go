@@ -0,0 +1,354 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+// Conditions a task must all meet, parsed from the filter terms given before a command
+type Filter []func(Task) bool
+
+// The filter given on the command line, applied by the commands in filterCommands
+var CommandFilter Filter
+
+// Commands that act on the tasks matching CommandFilter
+var filterCommands = []string{"list", "count", "do", "update", "delete", "status", "start", "stop", "block", "cancel", "timew", "sort", "edit"}
+
+// Keys of the `key:value` filter terms
+var filterKeys = []string{"status", "status.not", "priority", "due", "due.before", "due.after", "created.before", "created.after", "description", "desc", "assignee"}
+
+// Reports whether `t` meets every condition of `f`. An empty filter matches every task
+func (f Filter) Match(t Task) bool {
+	for _, cond := range f {
+		if !cond(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the tasks in `tp` matching `f`
+func (f Filter) Apply(tp []TaskPosition) []TaskPosition {
+	var matching []TaskPosition
+	for _, t := range tp {
+		if f.Match(t.task) {
+			matching = append(matching, t)
+		}
+	}
+	return matching
+}
+
+// Reports whether `s` is a filter term: `+tag`, `-tag` or `key:value`
+func isFilterTerm(s string) bool {
+	switch {
+	case len(s) > 1 && s[0] == '+':
+		return true
+	case len(s) > 1 && s[0] == '-' && s[1] != '-' && s != "-h":
+		return true
+	}
+	key, _, ok := strings.Cut(s, ":")
+	return ok && slices.Contains(filterKeys, key)
+}
+
+// Parse filter `terms` such as `+work`, `-home`, `priority:high` or `due.before:tomorrow`.
+// Dates are relative to `now`, see parseDate
+func parseFilter(terms []string, now time.Time) (Filter, error) {
+	var f Filter
+	for _, term := range terms {
+		if tag, ok := strings.CutPrefix(term, "+"); ok {
+			f = append(f, func(t Task) bool { return slices.Contains(t.Tags, tag) })
+			continue
+		}
+		if tag, ok := strings.CutPrefix(term, "-"); ok {
+			f = append(f, func(t Task) bool { return !slices.Contains(t.Tags, tag) })
+			continue
+		}
+
+		key, value, _ := strings.Cut(term, ":")
+		switch key {
+		case "status", "status.not":
+			status, err := parseStatus(value)
+			if err != nil {
+				return nil, err
+			}
+			want := key == "status"
+			f = append(f, func(t Task) bool { return (t.Status == status) == want })
+		case "priority":
+			priority, err := parsePriority(value)
+			if err != nil {
+				return nil, err
+			}
+			f = append(f, func(t Task) bool { return t.Priority == priority })
+		case "due", "due.before", "due.after", "created.before", "created.after":
+			field, op, _ := strings.Cut(key, ".")
+			if field == "due" && op == "" && strings.ToLower(value) == "none" {
+				f = append(f, func(t Task) bool { return t.Due == "" })
+				continue
+			}
+			day, err := parseDate(value, now)
+			if err != nil {
+				return nil, err
+			}
+			f = append(f, func(t Task) bool {
+				ts := t.Due
+				if field == "created" {
+					ts = t.Created
+				}
+				d, err := parseTimestamp(ts)
+				if err != nil {
+					return false
+				}
+				y, m, dd := d.In(day.Location()).Date()
+				d = time.Date(y, m, dd, 0, 0, 0, 0, day.Location())
+				switch op {
+				case "before":
+					return d.Before(day)
+				case "after":
+					return d.After(day)
+				default:
+					return d.Equal(day)
+				}
+			})
+		case "assignee":
+			assignee := parseAssignee(value)
+			f = append(f, func(t Task) bool { return strings.EqualFold(t.Assignee, assignee) })
+		case "description", "desc":
+			text := strings.ToLower(value)
+			f = append(f, func(t Task) bool { return strings.Contains(strings.ToLower(t.Desc), text) })
+		}
+	}
+	return f, nil
+}
+
+// Split the filter terms given before the command from `args`, e.g. `task +work priority:high do`.
+// Returns the arguments left for `root` and the parsed filter. Without a command the filter
+// lists the matching tasks, while `task +tag ...` stays shorthand for `task list +tag ...`.
+// Saved views are expanded to their filter first, and the default flags of the command are
+// added, see addDefaultFlags
+func parseCommandLine(root *cobra.Command, args []string) ([]string, Filter, error) {
+	args = expandView(args)
+	n := 0
+	for n < len(args) && isFilterTerm(args[n]) {
+		n++
+	}
+	if n == 0 {
+		return addDefaultFlags(root, args), nil, nil
+	}
+	terms, rest := args[:n], args[n:]
+
+	verb := false
+	if len(rest) > 0 {
+		if cmd, _, err := root.Find(rest[:1]); err == nil && cmd != root {
+			verb = true
+		}
+	}
+	onlyTags := !slices.ContainsFunc(terms, func(s string) bool { return !strings.HasPrefix(s, "+") })
+	if !verb && onlyTags {
+		return addDefaultFlags(root, expandShorthand(args)), nil, nil
+	}
+
+	filter, err := parseFilter(terms, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !verb {
+		rest = append([]string{"list"}, rest...)
+	}
+	return addDefaultFlags(root, rest), filter, nil
+}
+
+// Add the default flags the config file sets for the command of `args`, such as
+// {"list": ["-t"]} or {"report weekly": ["-e"]}, right after the command name so flags given
+// on the command line come later and take precedence
+func addDefaultFlags(root *cobra.Command, args []string) []string {
+	cmd, n := root, 0
+	for n < len(args) {
+		i := slices.IndexFunc(cmd.Commands(), func(c *cobra.Command) bool {
+			return c.Name() == args[n] || c.HasAlias(args[n])
+		})
+		if i < 0 {
+			break
+		}
+		cmd = cmd.Commands()[i]
+		n++
+	}
+	var names []string
+	for c := cmd; c != root; c = c.Parent() {
+		names = append([]string{c.Name()}, names...)
+	}
+	flags, ok := config.Defaults[strings.Join(names, " ")]
+	if !ok || n == 0 {
+		return args
+	}
+	return append(append(slices.Clone(args[:n]), flags...), args[n:]...)
+}
+
+// Returns an error if a filter was given to `cmd` but it doesn't act on filtered tasks
+func checkFilter(cmd *cobra.Command) error {
+	if len(CommandFilter) > 0 && !slices.Contains(filterCommands, cmd.Name()) {
+		return fmt.Errorf(tr("%s can't be used with a filter"), cmd.Name())
+	}
+	return nil
+}
+
+// Returns the keys of the tasks matching CommandFilter. Returns an error if no task matches
+func filteredKeys(db *bolt.DB) ([]int, error) {
+	var keys []int
+	for _, t := range CommandFilter.Apply(getTasks(db, TASKS_BUCKET)) {
+		keys = append(keys, t.dbKey)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New(tr("No tasks match the filter"))
+	}
+	return keys, nil
+}
+
+// Replace `view name` at the start of `args` with the filter saved as the view `name`, so
+// `task view urgent do` runs like `task +work priority:high do`
+func expandView(args []string) []string {
+	if len(args) < 2 || args[0] != "view" {
+		return args
+	}
+	terms, ok := config.Views[args[1]]
+	if !ok {
+		return args
+	}
+	return append(strings.Fields(terms), args[2:]...)
+}
+
+func newViewCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	vCmd := &cobra.Command{
+		Use:          "view [name] [command]",
+		Short:        tr("Run a saved filter, or list the saved filters"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Saved views were expanded before the command ran
+			if len(args) > 0 {
+				return fmt.Errorf(tr(`No view named "%s", save one with "task view save"`), args[0])
+			}
+			if len(config.Views) == 0 {
+				fmt.Fprintln(out, tr(`No saved views, save one with "task view save"`))
+				return nil
+			}
+			fmt.Fprintln(out, formatViews(config.Views))
+			return nil
+		},
+		ValidArgsFunction: completeViews,
+	}
+	vCmd.AddCommand(newViewSaveCmd(mgr, out), newViewDeleteCmd(mgr, out))
+	return vCmd
+}
+
+func newViewSaveCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "save [name] [filter]",
+		Short:        tr("Save a filter as a view"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New(tr("Must provide a name and a filter"))
+			}
+			name := args[0]
+			if name == "" || strings.HasPrefix(name, "-") || slices.ContainsFunc(cmd.Parent().Commands(), func(c *cobra.Command) bool { return c.Name() == name }) {
+				return fmt.Errorf(tr(`Invalid view name "%s"`), name)
+			}
+			// The filter can be given quoted or as separate arguments
+			terms := strings.Fields(strings.Join(args[1:], " "))
+			for _, term := range terms {
+				if !isFilterTerm(term) {
+					return fmt.Errorf(tr(`"%s" isn't a filter term`), term)
+				}
+			}
+			if _, err := parseFilter(terms, time.Now()); err != nil {
+				return err
+			}
+
+			views := maps.Clone(config.Views)
+			if views == nil {
+				views = map[string]string{}
+			}
+			views[name] = strings.Join(terms, " ")
+			if err := saveViews(views); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Saved view %s, run it with \"task view %s\"\n"), name, name)
+			return nil
+		},
+	}
+}
+
+func newViewDeleteCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "delete [name]",
+		Short:        tr("Delete a saved view"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New(tr("Must provide the name of a view"))
+			}
+			if _, ok := config.Views[args[0]]; !ok {
+				return fmt.Errorf(tr(`No view named "%s"`), args[0])
+			}
+			views := maps.Clone(config.Views)
+			delete(views, args[0])
+			if err := saveViews(views); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Deleted view %s\n"), args[0])
+			return nil
+		},
+		ValidArgsFunction: completeViews,
+	}
+}
+
+// Write `views` to the config file and start using them
+func saveViews(views map[string]string) error {
+	dir, err := taskDir()
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue(dir, "views", views); err != nil {
+		return err
+	}
+	config.Views = views
+	return nil
+}
+
+// Returns the saved `views` one per line, sorted by name
+func formatViews(views map[string]string) string {
+	names := viewNames(views)
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, name, views[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the names of `views` in sorted order
+func viewNames(views map[string]string) []string {
+	var names []string
+	for name := range views {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func completeViews(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return viewNames(config.Views), cobra.ShellCompDirectiveNoFileComp
+}
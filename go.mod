@@ -4,7 +4,10 @@ go 1.21.4
 
 require (
 	github.com/boltdb/bolt v1.3.1
+	github.com/mattn/go-runewidth v0.0.15
+	github.com/rivo/uniseg v0.2.0
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/term v0.15.0
 )
 
 require (
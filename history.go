@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A change made to a task by an update or a status change, recorded in its History
+type Change struct {
+	Time  string
+	Field string
+	// Values before and after the change, empty when the field wasn't set
+	Old string
+	New string
+}
+
+// A field of a task recorded in its history, with its value as text and how to copy it from
+// another version of the task
+type historyField struct {
+	name  string
+	value func(t Task) string
+	copy  func(t *Task, from Task)
+}
+
+// Fields of a task recorded in its history
+var historyFields = []historyField{
+	{"description", func(t Task) string { return t.Desc }, func(t *Task, from Task) { t.Desc = from.Desc }},
+	// The completion time goes along with the status
+	{"status", func(t Task) string { return t.Status }, func(t *Task, from Task) { t.Status, t.Completed = from.Status, from.Completed }},
+	{"due", func(t Task) string { return t.Due }, func(t *Task, from Task) { t.Due = from.Due }},
+	{"tags", func(t Task) string { return strings.Join(t.Tags, " ") }, func(t *Task, from Task) { t.Tags = slices.Clone(from.Tags) }},
+	{"priority", func(t Task) string { return t.Priority }, func(t *Task, from Task) { t.Priority = from.Priority }},
+	{"points", func(t Task) string {
+		if t.Points == 0 {
+			return ""
+		}
+		return strconv.Itoa(t.Points)
+	}, func(t *Task, from Task) { t.Points = from.Points }},
+	{"assignee", func(t Task) string { return t.Assignee }, func(t *Task, from Task) { t.Assignee = from.Assignee }},
+	{"parent", func(t Task) string { return t.Parent }, func(t *Task, from Task) { t.Parent = from.Parent }},
+	{"blocked", func(t Task) string { return t.BlockedReason }, func(t *Task, from Task) { t.BlockedReason = from.BlockedReason }},
+	{"attachments", func(t Task) string { return strings.Join(t.Attachments, " ") }, func(t *Task, from Task) { t.Attachments = slices.Clone(from.Attachments) }},
+}
+
+// Returns a copy of `t` that keeps the values of the history fields when `t` is modified
+func snapshot(t Task) Task {
+	s := t
+	s.Tags = slices.Clone(t.Tags)
+	s.Attachments = slices.Clone(t.Attachments)
+	return s
+}
+
+// Add the fields of `t` that changed from `old` at `now` to its history
+func recordChanges(old Task, t *Task, now time.Time) {
+	for _, f := range historyFields {
+		if before, after := f.value(old), f.value(*t); before != after {
+			t.History = append(t.History, Change{Time: timestamp(now), Field: f.name, Old: before, New: after})
+		}
+	}
+}
+
+// Returns when `t` was last changed according to its history, empty if it never was
+func lastChange(t Task) string {
+	if len(t.History) == 0 {
+		return ""
+	}
+	return t.History[len(t.History)-1].Time
+}
+
+// Format the history of `t`, one change per line with its time, oldest first
+func formatHistory(t Task) string {
+	if len(t.History) == 0 {
+		return tr("No changes recorded")
+	}
+	value := func(field, v string) string {
+		if v == "" {
+			return "-"
+		}
+		if field == "due" {
+			return formatWhen(v, false)
+		}
+		return v
+	}
+
+	var rows [][]string
+	for _, c := range t.History {
+		rows = append(rows, []string{formatWhen(c.Time, true), c.Field + ":", value(c.Field, c.Old), "→", value(c.Field, c.New)})
+	}
+	return fmt.Sprintf(tr("History (%d changes)"), len(t.History)) + "\n" + formatTable(rows)
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Formats `report` can print in
+var REPORT_FORMATS = []string{"text", "html"}
+
+// Columns of the open tasks in an HTML report that isn't based on a custom report
+var htmlReportColumns = []string{"id", "status", "description", "tags", "priority", "due"}
+
+// Number of days the HTML report covers when no start is given
+const htmlReportDays = 7
+
+// Write the HTML report for `report --format html`, based on the custom report named in `args`
+// if there's one, to the --out file or to `out`
+func runHTMLReport(mgr *connectionManager, args []string, out io.Writer) error {
+	now := time.Now()
+	period, err := reportPeriod(ReportStart, ReportEnd, now)
+	if err != nil {
+		return err
+	}
+	title := tr("Task report")
+	r := CustomReport{Columns: htmlReportColumns, Sort: "due"}
+	if len(args) > 0 {
+		var ok bool
+		if r, ok = config.Reports[args[0]]; !ok {
+			return fmt.Errorf(tr(`No report named "%s"`), args[0])
+		}
+		title = args[0]
+	}
+	report, err := htmlReport(title, r, getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET), period, now)
+	if err != nil {
+		return err
+	}
+
+	if ReportOut == "" {
+		return writeHTMLReport(out, report, now)
+	}
+	var buf bytes.Buffer
+	if err := writeHTMLReport(&buf, report, now); err != nil {
+		return err
+	}
+	if err := os.WriteFile(ReportOut, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, tr("Saved the report to %s\n"), ReportOut)
+	return nil
+}
+
+// Build the period of an HTML report from the mm/dd/yyyy formated `start` and `end` days. The
+// period ends at `now` without an end, and covers htmlReportDays days without a start
+func reportPeriod(start, end string, now time.Time) (Period, error) {
+	p, err := parsePeriod(start, end)
+	if err != nil {
+		return p, err
+	}
+	if end == "" {
+		p.End = now
+	}
+	if start == "" {
+		y, m, d := p.End.Date()
+		p.Start = time.Date(y, m, d-(htmlReportDays-1), 0, 0, 0, 0, p.End.Location())
+	}
+	if p.End.Before(p.Start) {
+		return p, errors.New(tr("The end date is before the start date"))
+	}
+	return p, nil
+}
+
+// Data of an HTML report: the open tasks, the tasks completed in a period and charts of
+// those completions
+type HTMLReport struct {
+	Title  string
+	Period Period
+	// Counts shown at the top of the page
+	Open      int
+	Overdue   int
+	Completed int
+	Created   int
+	// Open tasks as selected by the report, in sections when it's grouped
+	Columns []string
+	Groups  []TaskGroup
+	// Tasks completed in the period, in the order they were completed
+	Completions []TaskPosition
+	// Completions per day of the period, or per week for periods over a month, and per tag
+	PerDay []ChartBar
+	PerTag []ChartBar
+}
+
+// A bar of a chart in an HTML report
+type ChartBar struct {
+	Label string
+	Count int
+	// Length of the bar relative to the longest one, from 0 to 100
+	Percent int
+}
+
+// Build an HTML report of the open `tasks` selected by `r` and of the tasks in `tasks` and
+// `archive` completed in `period`. `now` is used for relative dates and overdue tasks
+func htmlReport(title string, r CustomReport, tasks, archive []TaskPosition, period Period, now time.Time) (HTMLReport, error) {
+	open := slices.DeleteFunc(slices.Clone(tasks), func(t TaskPosition) bool { return isDone(t.task) })
+	groups, err := runReport(r, open, now)
+	if err != nil {
+		return HTMLReport{}, err
+	}
+	report := HTMLReport{Title: title, Period: period, Columns: r.Columns, Groups: groups}
+	if len(report.Columns) == 0 {
+		report.Columns = []string{"id", "status", "description"}
+	}
+
+	report.Open = len(open)
+	for _, t := range open {
+		if isOverdue(t.task, now) {
+			report.Overdue++
+		}
+	}
+	for _, t := range append(slices.Clone(tasks), archive...) {
+		if created, err := parseTimestamp(t.task.Created); err == nil && period.Contains(created) {
+			report.Created++
+		}
+		completed, err := parseTimestamp(t.task.Completed)
+		if err == nil && t.task.Status == STATUS.COMPLETE && period.Contains(completed) {
+			report.Completions = append(report.Completions, t)
+		}
+	}
+	report.Completed = len(report.Completions)
+	slices.SortStableFunc(report.Completions, func(a, b TaskPosition) int {
+		return compareTimestamps(a.task.Completed, b.task.Completed)
+	})
+
+	report.PerDay = completionsPerDay(report.Completions, period)
+	tagCounts := map[string]int{}
+	for _, t := range report.Completions {
+		for _, tag := range t.task.Tags {
+			tagCounts[tag]++
+		}
+	}
+	for _, t := range sortTagCounts(tagCounts) {
+		report.PerTag = append(report.PerTag, ChartBar{Label: "+" + t.Name, Count: t.Count})
+	}
+	scaleBars(report.PerTag)
+	return report, nil
+}
+
+// Count the `completions` on each day of `period`, or each week starting on Monday when the
+// period is longer than a month
+func completionsPerDay(completions []TaskPosition, period Period) []ChartBar {
+	start := period.Start.Local()
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	step := 1
+	if period.Days() > 31 {
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		step = 7
+	}
+
+	var bars []ChartBar
+	for day := start; day.Before(period.End); day = day.AddDate(0, 0, step) {
+		next := day.AddDate(0, 0, step)
+		bar := ChartBar{Label: formatDate(day)}
+		if step == 1 {
+			bar.Label = day.Format("Mon") + " " + bar.Label
+		}
+		for _, t := range completions {
+			if c, _ := parseTimestamp(t.task.Completed); !c.Before(day) && c.Before(next) {
+				bar.Count++
+			}
+		}
+		bars = append(bars, bar)
+	}
+	scaleBars(bars)
+	return bars
+}
+
+// Set the Percent of `bars` so the longest bar is 100
+func scaleBars(bars []ChartBar) {
+	most := 0
+	for _, b := range bars {
+		most = max(most, b.Count)
+	}
+	for i := range bars {
+		if most > 0 {
+			bars[i].Percent = bars[i].Count * 100 / most
+		}
+	}
+}
+
+// Write `r` to `w` as a standalone HTML page, with its styles inline so it can be attached to
+// an email or printed
+func writeHTMLReport(w io.Writer, r HTMLReport, now time.Time) error {
+	type cell struct {
+		Value string
+		Class string
+	}
+	type group struct {
+		Name string
+		Rows [][]cell
+	}
+	var headings []string
+	for _, c := range r.Columns {
+		headings = append(headings, columnHeading(c))
+	}
+	var groups []group
+	for _, g := range r.Groups {
+		if len(g.Tasks) == 0 {
+			continue
+		}
+		out := group{Name: g.Name}
+		for _, t := range g.Tasks {
+			var row []cell
+			for _, c := range r.Columns {
+				class := c
+				if c == "due" && isOverdue(t.task, now) {
+					class += " overdue"
+				}
+				row = append(row, cell{columnValue(t, c, now), class})
+			}
+			out.Rows = append(out.Rows, row)
+		}
+		groups = append(groups, out)
+	}
+	type completion struct {
+		Date string
+		Desc string
+		Tags string
+	}
+	var completions []completion
+	for _, t := range r.Completions {
+		desc, _, _ := strings.Cut(t.task.Desc, "\n")
+		completions = append(completions, completion{
+			formatDateStamp(t.task.Completed, false),
+			desc,
+			strings.Join(t.task.Tags, ", "),
+		})
+	}
+
+	perDay := tr("Completions per day")
+	if r.Period.Days() > 31 {
+		perDay = tr("Completions per week")
+	}
+	// The end of a period is the first moment after it
+	end := r.Period.End.Add(-time.Nanosecond)
+	data := map[string]any{
+		"Lang":        locale,
+		"Title":       r.Title,
+		"Period":      formatDate(r.Period.Start) + " – " + formatDate(end),
+		"Generated":   formatDate(now) + " " + now.Format("15:04"),
+		"Headings":    headings,
+		"Groups":      groups,
+		"Completions": completions,
+		"PerDay":      r.PerDay,
+		"PerTag":      r.PerTag,
+		"Stats": [][]any{
+			{tr("Open"), r.Open},
+			{tr("Overdue"), r.Overdue},
+			{tr("Completed"), r.Completed},
+			{tr("Created"), r.Created},
+		},
+		"Labels": map[string]string{
+			"Generated":   tr("Generated"),
+			"Open":        tr("Open tasks"),
+			"NoOpen":      tr("No open tasks"),
+			"Completed":   tr("Completed tasks"),
+			"NoCompleted": tr("No tasks were completed in this period"),
+			"PerDay":      perDay,
+			"PerTag":      tr("Completions per tag"),
+			"Date":        tr("Completed"),
+			"Desc":        tr("Description"),
+			"Tags":        tr("Tags"),
+		},
+	}
+	return htmlReportTemplate.Execute(w, data)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · {{.Period}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; }
+h1 { margin-bottom: 0; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: .3rem; margin-top: 2rem; }
+h3 { margin-bottom: .3rem; }
+.meta { color: #666; margin-top: .3rem; }
+.stats { display: flex; gap: 1rem; flex-wrap: wrap; }
+.stat { border: 1px solid #ddd; border-radius: 6px; padding: .6rem 1rem; min-width: 7rem; }
+.stat .value { font-size: 1.8rem; font-weight: bold; }
+.stat .label { color: #666; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3rem .5rem; border-bottom: 1px solid #eee; vertical-align: top; }
+th { background: #f6f6f6; }
+td.id { color: #666; }
+td.overdue { color: #c0392b; font-weight: bold; }
+.chart td { border: none; padding: .15rem .5rem; }
+.chart td.label { white-space: nowrap; width: 8rem; }
+.chart td.count { width: 3rem; text-align: right; }
+.bar { background: #3b82f6; height: 1rem; border-radius: 2px; }
+.empty { color: #666; font-style: italic; }
+@media print {
+	body { margin: 0; max-width: none; }
+	h2 { break-after: avoid; }
+	tr, .stat { break-inside: avoid; }
+	.bar { -webkit-print-color-adjust: exact; print-color-adjust: exact; }
+}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{.Period}} · {{.Labels.Generated}} {{.Generated}}</p>
+
+<div class="stats">
+{{- range .Stats}}
+<div class="stat"><div class="value">{{index . 1}}</div><div class="label">{{index . 0}}</div></div>
+{{- end}}
+</div>
+
+<h2>{{.Labels.Open}}</h2>
+{{- range .Groups}}
+{{- if .Name}}
+<h3>{{.Name}} ({{len .Rows}})</h3>
+{{- end}}
+<table>
+<tr>{{range $.Headings}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr>{{range .}}<td class="{{.Class}}">{{.Value}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- else}}
+<p class="empty">{{.Labels.NoOpen}}</p>
+{{- end}}
+
+<h2>{{.Labels.Completed}}</h2>
+{{- if .Completions}}
+<table>
+<tr><th>{{.Labels.Date}}</th><th>{{.Labels.Desc}}</th><th>{{.Labels.Tags}}</th></tr>
+{{- range .Completions}}
+<tr><td>{{.Date}}</td><td>{{.Desc}}</td><td>{{.Tags}}</td></tr>
+{{- end}}
+</table>
+
+<h2>{{.Labels.PerDay}}</h2>
+<table class="chart">
+{{- range .PerDay}}
+<tr><td class="label">{{.Label}}</td><td class="count">{{.Count}}</td><td><div class="bar" style="width: {{.Percent}}%"></div></td></tr>
+{{- end}}
+</table>
+{{- if .PerTag}}
+
+<h2>{{.Labels.PerTag}}</h2>
+<table class="chart">
+{{- range .PerTag}}
+<tr><td class="label">{{.Label}}</td><td class="count">{{.Count}}</td><td><div class="bar" style="width: {{.Percent}}%"></div></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- else}}
+<p class="empty">{{.Labels.NoCompleted}}</p>
+{{- end}}
+</body>
+</html>
+`))
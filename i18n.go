@@ -0,0 +1,827 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Language of user facing messages, detected from the environment
+var locale = detectLocale()
+
+// Detect the user's language from the standard locale variables, in order of precedence.
+// Returns a language code such as "es", defaulting to "en"
+func detectLocale() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		if v == "C" || v == "POSIX" {
+			return "en"
+		}
+		// "es_MX.UTF-8" -> "es"
+		lang, _, _ := strings.Cut(v, ".")
+		lang, _, _ = strings.Cut(lang, "_")
+		return strings.ToLower(lang)
+	}
+	return "en"
+}
+
+// Translate `msg` into the user's language. Messages are keyed by their English text,
+// so a message without a translation is shown in English
+func tr(msg string) string {
+	if t, ok := translations[locale][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Translations of user facing messages, keyed by language and then by the English text
+var translations = map[string]map[string]string{
+	"es": {
+		"%s can't be used with --read-only":                                                  "%s no se puede usar con --read-only",
+		"The database at %s is locked by another process, likely %s. Close it and try again": "La base de datos en %s está bloqueada por otro proceso, probablemente %s. Ciérralo e inténtalo de nuevo",
+		"The database at %s is locked by another process. Close it and try again":            "La base de datos en %s está bloqueada por otro proceso. Ciérralo e inténtalo de nuevo",
+		"Keep the database open and run commands sent by the CLI":                            "Mantener la base de datos abierta y ejecutar los comandos enviados por la CLI",
+		"By hour of day": "Por hora del día",
+		"By weekday":     "Por día de la semana",
+		"\"%s\" is not a mm/dd/yyyy-mm/dd/yyyy range": "\"%s\" no es un rango mm/dd/yyyy-mm/dd/yyyy",
+		"Change":      "Cambio",
+		"Completions": "Completadas",
+		"Average/day": "Promedio/día",
+		"Print a digest of the tasks created and completed in a month": "Mostrar un resumen de las tareas creadas y completadas en un mes",
+		"Invalid month \"%s\", must be in the format yyyy-mm":          "Mes no válido \"%s\", debe tener el formato yyyy-mm",
+		"\"%s\" (%d days)":   "\"%s\" (%d días)",
+		"Report for %s":      "Informe de %s",
+		"Completed:":         "Completadas:",
+		"Created:":           "Creadas:",
+		"Backlog change:":    "Cambio del pendiente:",
+		"Top tags:":          "Etiquetas principales:",
+		"Longest open:":      "Abierta más tiempo:",
+		"No completed tasks": "No hay tareas completadas",
+		"All":                "Todas",
+		"Tasks":              "Tareas",
+		"Average":            "Promedio",
+		"Median":             "Mediana",
+		"Estimate when your open tasks will be done at your recent pace": "Estimar cuándo terminarás tus tareas abiertas a tu ritmo reciente",
+		"The window must be at least 1 day":                              "La ventana debe ser de al menos 1 día",
+		"Velocity: %.2f tasks/day over the last %d days\n":               "Velocidad: %.2f tareas/día en los últimos %d días\n",
+		"Backlog:  %d open tasks\n":                                      "Pendiente: %d tareas abiertas\n",
+		"Nothing left to do":                                             "No queda nada por hacer",
+		"The end date is before the start date":                          "La fecha de fin es anterior a la fecha de inicio",
+		"Summarize every task in the archive":                            "Resumir todas las tareas del archivo",
+		"%s (%d tasks)":                                                  "%s (%d tareas)",
+		"Completions:":                                                   "Completadas:",
+		"First completion:":                                              "Primera completada:",
+		"Last completion:":                                               "Última completada:",
+		"Busiest day:":                                                   "Día con más tareas:",
+		"Per tag:":                                                       "Por etiqueta:",
+		"Unknown date":                                                   "Fecha desconocida",
+		"Move tasks finished in a period back to your TODO list":         "Devolver a tu lista de TODO las tareas terminadas en un periodo",
+		"Must specify a start date with --since":                         "Debes indicar una fecha de inicio con --since",
+		"No archived tasks were finished in that period":                 "No se terminó ninguna tarea archivada en ese periodo",
+		"Restored %d tasks\n":                                            "Se restauraron %d tareas\n",
+		"Can't use task IDs in combination with the tag flag":            "No se pueden usar IDs de tareas junto con la opción de etiqueta",
+		"Delete %d tasks tagged +%s?":                                    "¿Eliminar %d tareas con la etiqueta +%s?",
+		"Aborted, nothing was deleted":                                   "Cancelado, no se eliminó nada",
+		"yes":                                                            "sí",
+		"Can't understand the date \"%s\"":                               "No se entiende la fecha \"%s\"",
+		"Can't use the due flag in combination with the no-due flag":     "No se puede usar la opción due junto con la opción no-due",
+		"Priority": "Prioridad",
+		"Invalid priority \"%s\", must be high, med, low or none": "Prioridad no válida \"%s\", debe ser high, med, low o none",
+		"Add text to the end of a task's description":             "Añadir texto al final de la descripción de una tarea",
+		"Add text to the start of a task's description":           "Añadir texto al principio de la descripción de una tarea",
+		"Must specify a task and the text to add":                 "Debes indicar una tarea y el texto que añadir",
+		"Renumber tasks 1..N in the order they are listed":        "Renumerar las tareas 1..N en el orden en que se listan",
+		"Task IDs are already in order":                           "Los IDs de las tareas ya están en orden",
+		"\"%s\" matches %d tasks, use a longer prefix":            "\"%s\" coincide con %d tareas, usa un prefijo más largo",
+		"UUID": "UUID",
+		"Hash": "Hash",
+		"Invalid grouping \"%s\", must be one of %s": "Agrupación \"%s\" no válida, debe ser una de %s",
+		"Untagged":    "Sin etiqueta",
+		"High":        "Alta",
+		"Medium":      "Media",
+		"Low":         "Baja",
+		"No priority": "Sin prioridad",
+		"Overdue":     "Vencidas",
+		"Today":       "Hoy",
+		"This week":   "Esta semana",
+		"Later":       "Más adelante",
+		"No due date": "Sin fecha de vencimiento",
+		"Can't use the group flag in combination with the tree flag": "No se puede usar la opción group junto con la opción tree",
+		"A task can't be a subtask of itself or of its subtasks":     "Una tarea no puede ser subtarea de sí misma ni de sus subtareas",
+		"Move tasks to another status, or list the statuses":         "Mover tareas a otro estado, o listar los estados",
+		"Invalid config file %s: the \"%s\" status is missing":       "Archivo de configuración %s no válido: falta el estado \"%s\"",
+		"%d tasks were %s\n":                                             "%d tareas quedaron en %s\n",
+		"%d tasks":                                                       "%d tareas",
+		"Invalid config file %s: %v":                                     "Archivo de configuración %s no válido: %v",
+		"Invalid status \"%s\", must be one of %s":                       "Estado \"%s\" no válido, debe ser uno de %s",
+		"Invalid config file %s: unknown status \"%s\"":                  "Archivo de configuración %s no válido: estado desconocido \"%s\"",
+		"Can't move a task from %s to %s":                                "No se puede mover una tarea de %s a %s",
+		"Must specify the tasks to move and the status to move them to":  "Debes indicar las tareas a mover y el estado al que moverlas",
+		"Moved task %d to %s\n":                                          "Tarea %d movida a %s\n",
+		"Task %d isn't in progress":                                      "La tarea %d no está en curso",
+		"Started task %d\n":                                              "Tarea %d iniciada\n",
+		"Stopped task %d, %s spent on it so far\n":                       "Tarea %d detenida, %s dedicados hasta ahora\n",
+		"Stopped task %d\n":                                              "Tarea %d detenida\n",
+		"Time spent":                                                     "Tiempo dedicado",
+		"Move tasks in progress back to incomplete":                      "Devolver tareas en curso a incompletas",
+		"Mark tasks as in progress":                                      "Marcar tareas como en curso",
+		"The \"%s\" status is missing from the config":                   "Falta el estado \"%s\" en la configuración",
+		"Can't use the blocked flag in combination with the status flag": "No se puede usar la opción blocked junto con la opción status",
+		"Mark tasks as blocked, optionally with the reason why":          "Marcar tareas como bloqueadas, opcionalmente con el motivo",
+		"Blocked task %d\n":                                              "Tarea %d bloqueada\n",
+		"Blocked":                                                        "Bloqueada",
+		"Close tasks as cancelled rather than completed":                 "Cerrar tareas como canceladas en lugar de completadas",
+		"Cancelled task %d\n":                                            "Tarea %d cancelada\n",
+		"Add a timestamped comment to a task":                            "Añadir un comentario con fecha y hora a una tarea",
+		"Must specify a task and the comment to add":                     "Debes indicar una tarea y el comentario a añadir",
+		"Commented on task %d\n":                                         "Comentario añadido a la tarea %d\n",
+		"Comments":                                                       "Comentarios",
+		"Can't use task IDs or the all or tag flags in combination with a filter": "No se pueden usar IDs de tareas junto con las opciones all o tag y un filtro",
+		"%s can't be used with a filter":                                          "%s no se puede usar con un filtro",
+		"No tasks match the filter":                                               "Ninguna tarea coincide con el filtro",
+		"Can't use task IDs in combination with a filter":                         "No se pueden usar IDs de tareas junto con un filtro",
+		"Can't use task IDs or the tag flag in combination with a filter":         "No se pueden usar IDs de tareas ni la opción tag junto con un filtro",
+		"Delete %d tasks matching the filter?":                                    "¿Eliminar %d tareas que coinciden con el filtro?",
+		"Invalid view name \"%s\"":                                                "Nombre de vista no válido \"%s\"",
+		"No saved views, save one with \"task view save\"":                        "No hay vistas guardadas, guarda una con \"task view save\"",
+		"Saved view %s, run it with \"task view %s\"\n":                           "Vista %s guardada, ejecútala con \"task view %s\"\n",
+		"Delete a saved view":                                                     "Eliminar una vista guardada",
+		"Must provide a name and a filter":                                        "Debes indicar un nombre y un filtro",
+		"No view named \"%s\"":                                                    "No hay ninguna vista llamada \"%s\"",
+		"Save a filter as a view":                                                 "Guardar un filtro como vista",
+		"Run a saved filter, or list the saved filters":                           "Ejecutar un filtro guardado, o listar los filtros guardados",
+		"\"%s\" isn't a filter term":                                              "\"%s\" no es un término de filtro",
+		"Must provide the name of a view":                                         "Debes indicar el nombre de una vista",
+		"Deleted view %s\n":                                                       "Vista %s eliminada\n",
+		"No view named \"%s\", save one with \"task view save\"":                  "No hay ninguna vista llamada \"%s\", guarda una con \"task view save\"",
+		"Invalid config file %s: \"%s\" isn't a filter term":                      "Archivo de configuración %s no válido: \"%s\" no es un término de filtro",
+		"ID":  "ID",
+		"Age": "Antigüedad",
+		"report \"%s\": \"%s\" isn't a filter term":                                          "informe \"%s\": \"%s\" no es un término de filtro",
+		"Print a digest of your tasks over a period, or a report defined in the config file": "Mostrar un resumen de tus tareas en un periodo, o un informe definido en el archivo de configuración",
+		"report \"%s\": can't sort by \"%s\", must be one of %s":                             "informe \"%s\": no se puede ordenar por \"%s\", debe ser uno de %s",
+		"No report named \"%s\"":                                                             "No hay ningún informe llamado \"%s\"",
+		"the \"%s\" report is built in":                                                      "el informe \"%s\" ya existe de serie",
+		"report \"%s\": invalid grouping \"%s\", must be one of %s":                          "informe \"%s\": agrupación \"%s\" no válida, debe ser una de %s",
+		"report \"%s\": unknown column \"%s\", must be one of %s":                            "informe \"%s\": columna desconocida \"%s\", debe ser una de %s",
+		"Streak:":              "Racha:",
+		"Due today:":           "Vencen hoy:",
+		"Completed this week:": "Completadas esta semana:",
+		"%d days":              "%d días",
+		"Overdue:":             "Vencidas:",
+		"Print an overview of your tasks: open, due, completed and your streak": "Mostrar un resumen de tus tareas: abiertas, por vencer, completadas y tu racha",
+		"Open:":                       "Abiertas:",
+		"Completed today:":            "Completadas hoy:",
+		"Burnup: completed █, open ░": "Burnup: completadas █, abiertas ░",
+		"Arrange your open tasks in an urgent/important grid": "Ordenar tus tareas abiertas en una cuadrícula urgente/importante",
+		"The number of days must be at least 0":               "El número de días debe ser al menos 0",
+		"Do first: urgent and important":                      "Hacer ya: urgente e importante",
+		"Schedule: important":                                 "Planificar: importante",
+		"Delegate: urgent":                                    "Delegar: urgente",
+		"Eliminate: neither":                                  "Eliminar: ninguna",
+		"Points can't be negative":                            "Los puntos no pueden ser negativos",
+		"That's %d points\n":                                  "Eso son %d puntos\n",
+		"Points":                                              "Puntos",
+		"Points:   %.2f points/day, %d open points\n":         "Puntos: %.2f puntos/día, %d puntos abiertos\n",
+		"Export the time tracked with start and stop in Timewarrior's format":      "Exportar el tiempo registrado con start y stop en el formato de Timewarrior",
+		"Timewarrior's data directory %s doesn't exist, is Timewarrior installed?": "El directorio de datos de Timewarrior %s no existe, ¿está instalado Timewarrior?",
+		"Added %d intervals to %s\n": "Se añadieron %d intervalos a %s\n",
+		"No description column, use --map to choose the columns, e.g. --map desc=1":                                                        "No hay columna de descripción, usa --map para elegir las columnas, p. ej. --map desc=1",
+		"Invalid format \"%s\", must be one of %s":                                                                                         "Formato no válido \"%s\", debe ser uno de %s",
+		"Add tasks from a file exported by another tracker":                                                                                "Añadir tareas desde un archivo exportado por otro gestor",
+		"Invalid mapping \"%s\", must be field=column with a field such as desc, tag, status, priority, points, due, created or completed": "Asignación no válida \"%s\", debe ser campo=columna con un campo como desc, tag, status, priority, points, due, created o completed",
+		"Nothing to import": "Nada que importar",
+		"Invalid column \"%s\", columns are counted from 1": "Columna no válida \"%s\", las columnas se cuentan desde 1",
+		"Row %d: %v":                                                        "Fila %d: %v",
+		"Invalid points \"%s\"":                                             "Puntos no válidos \"%s\"",
+		"Archived %d completed tasks\n":                                     "Se archivaron %d tareas completadas\n",
+		"To the archive:":                                                   "Al archivo:",
+		"Could not read the Reminders list: %v":                             "No se pudo leer la lista de Recordatorios: %v",
+		"Mirror your tasks to another app":                                  "Refleja tus tareas en otra aplicación",
+		"Added %d tasks and completed %d from Reminders\n":                  "Se agregaron %d tareas y se completaron %d desde Recordatorios\n",
+		"Could not update the Reminders list: %v":                           "No se pudo actualizar la lista de Recordatorios: %v",
+		"Added %d reminders, updated %d and deleted %d in %s\n":             "Se agregaron %d recordatorios, se actualizaron %d y se eliminaron %d en %s\n",
+		"Syncing with Reminders needs macOS":                                "Sincronizar con Recordatorios requiere macOS",
+		"Mirror your tasks to a list in the macOS Reminders app, both ways": "Sincroniza en ambos sentidos tus tareas con una lista de la aplicación Recordatorios de macOS",
+		"Overdue task":                                                      "Tarea vencida",
+		"Invalid interval %s, must be positive":                             "Intervalo %s no válido, debe ser positivo",
+		"Show a desktop notification when a task is due, until interrupted": "Muestra una notificación de escritorio cuando vence una tarea, hasta que se interrumpa",
+		"Task due today":                                                    "Tarea para hoy",
+		"Task due %s":                                                       "Tarea para el %s",
+		"Invalid %s: %v":                                                    "%s no válido: %v",
+		"Stop and remove the background jobs added by install":              "Detiene y elimina las tareas en segundo plano agregadas por install",
+		"Installing background jobs needs systemd or launchd, which %s doesn't have": "Instalar tareas en segundo plano requiere systemd o launchd, que %s no tiene",
+		"Invalid number of backups %d, must be 0 or more":                            "Número de copias de seguridad %d no válido, debe ser 0 o más",
+		"loaded":                 "cargado",
+		"Saved a backup to %s\n": "Se guardó una copia de seguridad en %s\n",
+		"Print whether the background jobs added by install are running": "Muestra si las tareas en segundo plano agregadas por install se están ejecutando",
+		"not installed":  "no instalado",
+		"Installed %s\n": "Se instaló %s\n",
+		"not loaded":     "no cargado",
+		"Removed %s\n":   "Se eliminó %s\n",
+		"Run remind in the background and back up the database daily, with systemd or launchd": "Ejecuta remind en segundo plano y hace una copia de seguridad diaria de la base de datos, con systemd o launchd",
+		"Save a copy of the database in the backups directory":                                 "Guarda una copia de la base de datos en el directorio de copias de seguridad",
+		"Nothing is installed":                                    "No hay nada instalado",
+		"Deleted %d old backups\n":                                "Se eliminaron %d copias de seguridad antiguas\n",
+		"Print a one line summary for status bars such as tmux's": "Muestra un resumen de una línea para barras de estado como la de tmux",
+		"Unknown placeholder \"%s\", must be one of %s":           "Marcador \"%s\" desconocido, debe ser uno de %s",
+		"Print the number of open and overdue tasks and the task in progress, for shell prompts": "Muestra el número de tareas abiertas y vencidas y la tarea en curso, para prompts de shell",
+		"Invalid request, must have \"jsonrpc\": \"2.0\" and a method":                           "Solicitud no válida, debe tener \"jsonrpc\": \"2.0\" y un método",
+		"Serve a JSON-RPC API for editor plugins":                                                "Sirve una API JSON-RPC para complementos de editores",
+		"Must use --stdio, the API is only served over stdin and stdout":                         "Debe usar --stdio, la API solo se sirve por stdin y stdout",
+		"Could not find the added task":                                                          "No se encontró la tarea agregada",
+		"Unknown method \"%s\", must be one of %s":                                               "Método \"%s\" desconocido, debe ser uno de %s",
+		"Assignee":                               "Asignada a",
+		"Generated":                              "Generado",
+		"Saved the report to %s\n":               "Informe guardado en %s\n",
+		"Completed tasks":                        "Tareas completadas",
+		"Open tasks":                             "Tareas abiertas",
+		"Open":                                   "Abiertas",
+		"No tasks were completed in this period": "No se completó ninguna tarea en este periodo",
+		"Task report":                            "Informe de tareas",
+		"Completions per tag":                    "Completadas por etiqueta",
+		"No open tasks":                          "No hay tareas abiertas",
+		"Completions per week":                   "Completadas por semana",
+		"Completions per day":                    "Completadas por día",
+		"--out can only be used with --format html":                    "--out solo se puede usar con --format html",
+		"Print the task list as JSON or CSV, or laid out for printing": "Mostrar la lista de tareas como JSON o CSV, o maquetada para imprimir",
+		"due %s":       "vence %s",
+		"Tasks for %s": "Tareas del %s",
+		"Invalid page length %d, must be at least %d lines": "Longitud de página %d no válida, debe tener al menos %d líneas",
+		"Page %d of %d":             "Página %d de %d",
+		"(overdue)":                 "(vencida)",
+		"Weekly report for %s - %s": "Informe semanal del %s al %s",
+		"email: invalid server \"%s\", must be host:port":                                "email: servidor \"%s\" no válido, debe ser host:puerto",
+		"Completed last week":                                                            "Completadas la semana pasada",
+		"email: from and to must be set":                                                 "email: from y to son obligatorios",
+		"Set up the email section of config.json to email reports":                       "Configura la sección email de config.json para enviar informes por correo",
+		"Due this week":                                                                  "Vencen esta semana",
+		"Print the tasks completed last week and the tasks due in the coming week":       "Mostrar las tareas completadas la semana pasada y las que vencen la próxima semana",
+		"Invalid schedule \"%s\", must be a weekday and a time such as \"monday 08:00\"": "Horario \"%s\" no válido, debe ser un día de la semana y una hora como \"monday 08:00\"",
+		"Sent the weekly report to %s\n":                                                 "Informe semanal enviado a %s\n",
+		"Per day: %s (most %d)\n":                                                        "Por día: %s (máximo %d)\n",
+		"Invalid config file %s: unknown color state \"%s\", must be one of %s":          "Archivo de configuración %s no válido: estado de color \"%s\" desconocido, debe ser uno de %s",
+		"Unknown color \"%s\"":                                                           "Color \"%s\" desconocido",
+		"invalid date_format \"%s\", must be \"locale\" or a layout such as \"2006-01-02\" or \"Jan 2\"": "date_format \"%s\" no válido, debe ser \"locale\" o un formato como \"2006-01-02\" o \"Jan 2\"",
+		"just now":                "ahora mismo",
+		"tomorrow":                "mañana",
+		"yesterday":               "ayer",
+		"today":                   "hoy",
+		"%s ago":                  "hace %s",
+		"in %s":                   "en %s",
+		"Unknown timezone \"%s\"": "Zona horaria \"%s\" desconocida",
+		"Invalid config file %s: unknown timezone \"%s\"":                    "Archivo de configuración %s no válido: zona horaria \"%s\" desconocida",
+		"No changes recorded":                                                "No hay cambios registrados",
+		"History (%d changes)":                                               "Historial (%d cambios)",
+		"Manage the database file":                                           "Gestiona el archivo de la base de datos",
+		"Merge the tasks and archive of another database file into this one": "Combina las tareas y el archivo de otra base de datos con esta",
+		"Can't merge the database into itself":                               "No se puede combinar la base de datos consigo misma",
+		"Could not open %s: %v":                                              "No se pudo abrir %s: %v",
+		"Would create %d tasks, update %d and skip %d, run again without --dry-run to import them": "Se crearían %d tareas, se actualizarían %d y se omitirían %d, vuelve a ejecutarlo sin --dry-run para importarlas",
+		"Created %d tasks, updated %d and skipped %d":                                              "Se crearon %d tareas, se actualizaron %d y se omitieron %d",
+		"Deleted %d old tasks from the archive\n":                                                  "Se eliminaron %d tareas antiguas del archivo\n",
+		"archive: max_entries must be 0 or more":                                                   "archive: max_entries debe ser 0 o más",
+		"Invalid period \"%s\", must be a number of days, weeks or months such as 30d, 2w or 6m":   "Período \"%s\" no válido, debe ser un número de días, semanas o meses como 30d, 2w o 6m",
+		"Read and change the settings of config.json":                                              "Lee y cambia los ajustes de config.json",
+		"Print a setting, such as do.auto_finish. Prints nothing for settings that aren't set":     "Muestra un ajuste, como do.auto_finish. No muestra nada para los ajustes sin valor",
+		"Change a setting, such as do.auto_finish, checking the key and the value":                 "Cambia un ajuste, como do.auto_finish, comprobando la clave y el valor",
+		"Set %s to %s\n": "%s cambiado a %s\n",
+		"Print every setting in use with its value": "Muestra todos los ajustes en uso con su valor",
+		"Unknown setting \"%s\"":                    "Ajuste \"%s\" desconocido",
+		"Invalid value \"%s\" for %s, must be JSON such as true, 10 or [\"a\", \"b\"]":                        "Valor \"%s\" no válido para %s, debe ser JSON como true, 10 o [\"a\", \"b\"]",
+		"Invalid config file %s: the defaults of \"%s\" must start with a flag, not \"%s\"":                   "Archivo de configuración %s no válido: los valores por defecto de \"%s\" deben empezar por una opción, no por \"%s\"",
+		"%s with the TASK_* environment variables":                                                            "%s con las variables de entorno TASK_*",
+		"Interactive mode can't be used while the daemon is running":                                          "El modo interactivo no se puede usar mientras el daemon está en marcha",
+		"↑/↓ to move, space to select, enter to confirm, q to quit":                                           "↑/↓ para moverte, espacio para seleccionar, intro para confirmar, q para salir",
+		"Can't use task IDs in combination with -i":                                                           "No se pueden usar IDs de tareas junto con -i",
+		"Due date, empty for none: ":                                                                          "Fecha de vencimiento, vacía para ninguna: ",
+		"Go through the open tasks one at a time, setting their priority, due date and tags with single keys": "Repasa las tareas abiertas una a una, asignando su prioridad, fecha de vencimiento y etiquetas con una sola tecla",
+		"tags: %s  due: %s  priority: %s":                                                                     "etiquetas: %s  vence: %s  prioridad: %s",
+		"h/m/l priority, 0 no priority, d due date, t tags, s snooze a week, enter next, ← back, q quit":      "h/m/l prioridad, 0 sin prioridad, d vencimiento, t etiquetas, s posponer una semana, intro siguiente, ← atrás, q salir",
+		"Tags, +tag to add and -tag to remove: ":                                                              "Etiquetas, +etiqueta para añadir y -etiqueta para quitar: ",
+		"Task %d of %d":                                                                                       "Tarea %d de %d",
+		"sort only runs interactively, use sort -i":                                                           "sort solo funciona de forma interactiva, usa sort -i",
+		"Changed %d of %d tasks\n":                                                                            "Se cambiaron %d de %d tareas\n",
+		"# Edit the descriptions, +tags and due:yyyy-mm-dd dates of the tasks below, then save and":           "# Edita las descripciones, +etiquetas y fechas due:aaaa-mm-dd de las tareas de abajo, luego guarda y",
+		"# close the editor. Lines starting with # are ignored, removing a line leaves its task as it is":     "# cierra el editor. Las líneas que empiezan por # se ignoran, quitar una línea deja su tarea como está",
+		"Can't use task IDs or a filter in combination with --all":                                            "No se pueden usar IDs de tareas ni un filtro junto con --all",
+		"Line %d: \"%s\" isn't the ID of a task being edited":                                                 "Línea %d: \"%s\" no es el ID de una tarea en edición",
+		"Line %d: %v":                      "Línea %d: %v",
+		"Line %d: task %d is listed twice": "Línea %d: la tarea %d aparece dos veces",
+		"No changes":                       "Sin cambios",
+		"Edit the descriptions, tags and due dates of tasks as text in your $EDITOR": "Edita las descripciones, etiquetas y fechas de vencimiento de las tareas como texto en tu $EDITOR",
+		"Aborted, nothing was changed": "Cancelado, no se cambió nada",
+		"format must be \"%s\"":        "el formato debe ser \"%s\"",
+		"Replace the %d tasks and %d archived tasks of the database with the %d tasks and %d archived tasks of %s?": "¿Reemplazar las %d tareas y %d tareas archivadas de la base de datos por las %d tareas y %d tareas archivadas de %s?",
+		"ID %d is used twice": "el ID %d se usa dos veces",
+		"Replace the whole database with a file written by db dump, - for stdin": "Reemplaza toda la base de datos por un archivo escrito por db dump, - para la entrada estándar",
+		"Invalid dump %s: %v": "Volcado no válido %s: %v",
+		"Print the whole database as text that db load reads back":                                 "Imprime toda la base de datos como texto que db load puede volver a leer",
+		"invalid ID %d of \"%s\", must be 1 or more":                                               "ID %d no válido de \"%s\", debe ser 1 o más",
+		"Loaded %d tasks and %d archived tasks\n":                                                  "Se cargaron %d tareas y %d tareas archivadas\n",
+		"unsupported version %d, this version of task reads version %d":                            "versión %d no admitida, esta versión de task lee la versión %d",
+		"Apply the changes in a file or stdin, one JSON object per line, all at once":              "Aplica los cambios de un archivo o de la entrada estándar, un objeto JSON por línea, todos a la vez",
+		"%d of %d operations failed, nothing was changed":                                          "Fallaron %d de %d operaciones, no se cambió nada",
+		"Task %d is deleted twice":                                                                 "La tarea %d se elimina dos veces",
+		"Unknown op \"%s\", must be one of %s":                                                     "Operación \"%s\" desconocida, debe ser una de %s",
+		"The daemon couldn't check for due tasks":                                                  "El daemon no pudo comprobar las tareas pendientes",
+		"Can't read the clipboard: %v":                                                             "No se puede leer el portapapeles: %v",
+		"Lost the connection to the daemon, the command may have run: %v":                          "Se perdió la conexión con el daemon, puede que el comando se haya ejecutado: %v",
+		"Use -y to load a dump from stdin":                                                         "Usa -y para cargar un volcado desde stdin",
+		"Print the archive as JSON or CSV":                                                         "Mostrar el archivo como JSON o CSV",
+		"Invalid format \"%s\", must be \"json\" or \"csv\"":                                       "Formato no válido \"%s\", debe ser \"json\" o \"csv\"",
+		"Invalid date \"%s\", must be in the format mm/dd/yyyy":                                    "Fecha no válida \"%s\", debe tener el formato mm/dd/yyyy",
+		"No tasks were completed in the last %d days, the backlog won't be cleared at this pace\n": "No se completaron tareas en los últimos %d días, a este ritmo el pendiente no se terminará\n",
+		"At this pace the backlog is cleared in %d days, around %s\n":                              "A este ritmo el pendiente se termina en %d días, alrededor del %s\n",
+		"Mon":               "lun",
+		"Tue":               "mar",
+		"Wed":               "mié",
+		"Thu":               "jue",
+		"Fri":               "vie",
+		"Sat":               "sáb",
+		"Sun":               "dom",
+		"Listening on %s\n": "Escuchando en %s\n",
+		"The editor can't be opened while the daemon is running, pass the task as an argument instead": "No se puede abrir el editor mientras el daemon está en ejecución, pasa la tarea como argumento",
+		"%d is out of range, only %d tasks exist\n":                                                    "%d está fuera de rango, solo existen %d tareas\n",
+		"%d tasks\n": "%d tareas\n",
+		"%s  open: %d  archived: %d  last used: %s":                   "%s  abiertas: %d  archivadas: %d  último uso: %s",
+		"%s is not a file":                                            "%s no es un archivo",
+		"A CLI for managing your TODOs":                               "Una CLI para gestionar tus pendientes",
+		"Add a new task to your TODO list":                            "Añade una nueva tarea a tu lista de pendientes",
+		"Added task: '%s'\n":                                          "Tarea añadida: '%s'\n",
+		"Archive is empty, finish a task to add it to the archive":    "El archivo está vacío, finaliza una tarea para añadirla al archivo",
+		"Attached %s to task %d\n":                                    "%s adjuntado a la tarea %d\n",
+		"Attachments":                                                 "Adjuntos",
+		"Average: %.1f/day\n":                                         "Promedio: %.1f/día\n",
+		"Can't use tag filtering in combination with exclude flag":    "No se puede filtrar por etiquetas junto con la opción de exclusión",
+		"Can't use task IDs in combination with the all or tag flags": "No se pueden usar IDs de tareas junto con las opciones all o tag",
+		"Can't use the all flag in combination with the tag flag":     "No se puede usar la opción all junto con la opción tag",
+		"Cleared the archive":                                         "Archivo vaciado",
+		"Completed %d tasks\n":                                        "%d tareas completadas\n",
+		"Completed task %d\n":                                         "Tarea %d completada\n",
+		"Completed":                                                   "Completada",
+		"Count must be at least 1":                                    "La cantidad debe ser al menos 1",
+		"Created":                                                     "Creada",
+		"Delete a task":                                               "Elimina una tarea",
+		"Delete all completed tasks":                                  "Elimina todas las tareas completadas",
+		"Delete all tasks":                                            "Elimina todas las tareas",
+		"Deleted %d tasks\n":                                          "%d tareas eliminadas\n",
+		"Deleted Task ":                                               "Tarea eliminada ",
+		"Deleted all completed tasks tagged %s\n":                     "Se eliminaron todas las tareas completadas con la etiqueta %s\n",
+		"Deleted all completed tasks\n":                               "Se eliminaron todas las tareas completadas\n",
+		"Deleted all tasks":                                           "Se eliminaron todas las tareas",
+		"Deleted task %d\n":                                           "Tarea %d eliminada\n",
+		"Description":                                                 "Descripción",
+		"Did not make any updates, try using a flag":                  "No se hizo ningún cambio, intenta usar una opción",
+		"Due": "Vence",
+		"Duplicate a task as new incomplete tasks":                   "Duplica una tarea como nuevas tareas incompletas",
+		"Duplicated task %d %d times\n":                              "Tarea %d duplicada %d veces\n",
+		"Error parsing completed date:":                              "Error al interpretar la fecha de finalización:",
+		"Error parsing date:":                                        "Error al interpretar la fecha:",
+		"Error: End date occured prior to the Start date":            "Error: La fecha de fin es anterior a la fecha de inicio",
+		"Failed to copy %s: %v":                                      "No se pudo copiar %s: %v",
+		"Failed to open %s: %v":                                      "No se pudo abrir %s: %v",
+		"Invalid attachment %d, task %d has %d attachments":          "Adjunto %d no válido, la tarea %d tiene %d adjuntos",
+		"Invalid task ID, %d tasks exist":                            "ID de tarea no válido, existen %d tareas",
+		"Link a file to a task":                                      "Vincula un archivo a una tarea",
+		"List all of your incomplete tasks":                          "Lista todas tus tareas incompletas",
+		"Mark a task on your TODO list as complete":                  "Marca una tarea de tu lista como completada",
+		"Move a task down one position in your TODO list":            "Baja una tarea una posición en tu lista",
+		"Move a task up one position in your TODO list":              "Sube una tarea una posición en tu lista",
+		"Moved task %d down\n":                                       "Tarea %d movida hacia abajo\n",
+		"Moved task %d up\n":                                         "Tarea %d movida hacia arriba\n",
+		"Must provide a task ID":                                     "Debes indicar un ID de tarea",
+		"Must provide a task description":                            "Debes indicar una descripción de la tarea",
+		"Must specify a single task to duplicate":                    "Debes indicar una sola tarea para duplicar",
+		"Must specify a single task to move":                         "Debes indicar una sola tarea para mover",
+		"Must specify a single task to open":                         "Debes indicar una sola tarea para abrir",
+		"Must specify a single task to show":                         "Debes indicar una sola tarea para mostrar",
+		"Must specify a task to update":                              "Debes indicar una tarea para actualizar",
+		"Must specify a start date":                                  "Debes indicar una fecha de inicio",
+		"Must specify a task and a file to attach":                   "Debes indicar una tarea y un archivo para adjuntar",
+		"No completed tasks to finish":                               "No hay tareas completadas para finalizar",
+		"No completed tasks to purge":                                "No hay tareas completadas para purgar",
+		"No matching tasks to complete":                              "No hay tareas que coincidan para completar",
+		"No matching tasks to delete":                                "No hay tareas que coincidan para eliminar",
+		"No tags":                                                    "No hay etiquetas",
+		"No tasks":                                                   "No hay tareas",
+		"Open the first URL in a task's description in your browser": "Abre en tu navegador la primera URL de la descripción de una tarea",
+		"Opened %s\n":                                                "%s abierto\n",
+		"Permanently delete all completed tasks without adding them to the archive": "Elimina permanentemente las tareas completadas sin añadirlas al archivo",
+		"Print existing tags":                       "Muestra las etiquetas existentes",
+		"Print the number of existing tasks":        "Muestra el número de tareas existentes",
+		"Purged %d completed tasks\n":               "%d tareas completadas purgadas\n",
+		"See statistics on your task completion":    "Consulta estadísticas de las tareas completadas",
+		"Show every detail of a task":               "Muestra todos los detalles de una tarea",
+		"Status":                                    "Estado",
+		"Tags must be in the form +tag, got \"%s\"": "Las etiquetas deben tener la forma +etiqueta, se recibió \"%s\"",
+		"Tags":                                     "Etiquetas",
+		"Task %d can't be moved any further":       "La tarea %d no se puede mover más",
+		"Task %d does not contain a URL":           "La tarea %d no contiene una URL",
+		"Task %d does not exist":                   "La tarea %d no existe",
+		"Task %d does not exist\n":                 "La tarea %d no existe\n",
+		"Task %d":                                  "Tarea %d",
+		"Update a task":                            "Actualiza una tarea",
+		"Updated task %d\n":                        "Tarea %d actualizada\n",
+		"View all previously completed tasks":      "Consulta todas las tareas completadas anteriormente",
+		"You already finished task %d\n":           "Ya terminaste la tarea %d\n",
+		"\nYou completed %d tasks from %s to %s\n": "\nCompletaste %d tareas del %s al %s\n",
+		"never":                "nunca",
+		`Invalid task ID "%s"`: `ID de tarea "%s" no válido`,
+	},
+	"ja": {
+		"%s can't be used with --read-only":                                                  "%s は --read-only と同時に使用できません",
+		"The database at %s is locked by another process, likely %s. Close it and try again": "%s のデータベースは別のプロセス (おそらく %s) にロックされています。そのプロセスを終了してから再試行してください",
+		"The database at %s is locked by another process. Close it and try again":            "%s のデータベースは別のプロセスにロックされています。そのプロセスを終了してから再試行してください",
+		"Keep the database open and run commands sent by the CLI":                            "データベースを開いたままにし、CLI から送られたコマンドを実行する",
+		"By hour of day": "時間帯別",
+		"By weekday":     "曜日別",
+		"\"%s\" is not a mm/dd/yyyy-mm/dd/yyyy range": "\"%s\" は mm/dd/yyyy-mm/dd/yyyy 形式の範囲ではありません",
+		"Change":      "増減",
+		"Completions": "完了数",
+		"Average/day": "1日平均",
+		"Print a digest of the tasks created and completed in a month": "1か月間に作成・完了したタスクの概要を表示する",
+		"Invalid month \"%s\", must be in the format yyyy-mm":          "無効な月 \"%s\" です。yyyy-mm 形式で指定してください",
+		"\"%s\" (%d days)":   "\"%s\" (%d 日)",
+		"Report for %s":      "%s のレポート",
+		"Completed:":         "完了:",
+		"Created:":           "作成:",
+		"Backlog change:":    "未完了の増減:",
+		"Top tags:":          "上位タグ:",
+		"Longest open:":      "最長未完了:",
+		"No completed tasks": "完了したタスクはありません",
+		"All":                "すべて",
+		"Tasks":              "タスク数",
+		"Average":            "平均",
+		"Median":             "中央値",
+		"Estimate when your open tasks will be done at your recent pace": "最近のペースで未完了のタスクがいつ終わるかを予測する",
+		"The window must be at least 1 day":                              "期間は 1 日以上にしてください",
+		"Velocity: %.2f tasks/day over the last %d days\n":               "ペース: 1 日あたり %.2f 件 (過去 %d 日間)\n",
+		"Backlog:  %d open tasks\n":                                      "未完了: %d 件\n",
+		"Nothing left to do":                                             "残っているタスクはありません",
+		"The end date is before the start date":                          "終了日が開始日より前です",
+		"Summarize every task in the archive":                            "アーカイブ内のすべてのタスクを要約する",
+		"%s (%d tasks)":                                                  "%s (%d 件)",
+		"Completions:":                                                   "完了数:",
+		"First completion:":                                              "最初の完了:",
+		"Last completion:":                                               "最後の完了:",
+		"Busiest day:":                                                   "最も多い日:",
+		"Per tag:":                                                       "タグ別:",
+		"Unknown date":                                                   "日付不明",
+		"Move tasks finished in a period back to your TODO list":         "期間内に終了したタスクを TODO リストに戻す",
+		"Must specify a start date with --since":                         "--since で開始日を指定してください",
+		"No archived tasks were finished in that period":                 "その期間に終了したアーカイブ済みのタスクはありません",
+		"Restored %d tasks\n":                                            "%d 件のタスクを復元しました\n",
+		"Can't use task IDs in combination with the tag flag":            "タスク ID とタグオプションは同時に使用できません",
+		"Delete %d tasks tagged +%s?":                                    "%d 件の +%s タグ付きタスクを削除しますか?",
+		"Aborted, nothing was deleted":                                   "中止しました。何も削除されていません",
+		"yes":                                                            "はい",
+		"Can't understand the date \"%s\"":                               "日付 \"%s\" を解釈できません",
+		"Can't use the due flag in combination with the no-due flag":     "due オプションと no-due オプションは同時に使用できません",
+		"Priority": "優先度",
+		"Invalid priority \"%s\", must be high, med, low or none": "無効な優先度 \"%s\" です。high、med、low、none のいずれかを指定してください",
+		"Add text to the end of a task's description":             "タスクの説明の末尾にテキストを追加する",
+		"Add text to the start of a task's description":           "タスクの説明の先頭にテキストを追加する",
+		"Must specify a task and the text to add":                 "タスクと追加するテキストを指定してください",
+		"Renumber tasks 1..N in the order they are listed":        "タスクを表示順に 1..N で振り直す",
+		"Task IDs are already in order":                           "タスク ID はすでに順番どおりです",
+		"\"%s\" matches %d tasks, use a longer prefix":            "\"%s\" は %d 件のタスクに一致します。もっと長いプレフィックスを指定してください",
+		"UUID": "UUID",
+		"Hash": "ハッシュ",
+		"Invalid grouping \"%s\", must be one of %s": "無効なグループ分け \"%s\" です。次のいずれかを指定してください: %s",
+		"Untagged":    "タグなし",
+		"High":        "高",
+		"Medium":      "中",
+		"Low":         "低",
+		"No priority": "優先度なし",
+		"Overdue":     "期限切れ",
+		"Today":       "今日",
+		"This week":   "今週",
+		"Later":       "それ以降",
+		"No due date": "期限なし",
+		"Can't use the group flag in combination with the tree flag": "group フラグと tree フラグは同時に使用できません",
+		"A task can't be a subtask of itself or of its subtasks":     "タスクを自身やそのサブタスクのサブタスクにすることはできません",
+		"Move tasks to another status, or list the statuses":         "タスクを別のステータスに移動する、またはステータスを一覧表示する",
+		"Invalid config file %s: the \"%s\" status is missing":       "設定ファイル %s が無効です: \"%s\" ステータスがありません",
+		"%d tasks were %s\n":                                             "%d 件のタスクが %s になりました\n",
+		"%d tasks":                                                       "%d 件のタスク",
+		"Invalid config file %s: %v":                                     "設定ファイル %s が無効です: %v",
+		"Invalid status \"%s\", must be one of %s":                       "無効なステータス \"%s\" です。次のいずれかを指定してください: %s",
+		"Invalid config file %s: unknown status \"%s\"":                  "設定ファイル %s が無効です: 不明なステータス \"%s\"",
+		"Can't move a task from %s to %s":                                "タスクを %s から %s に移動できません",
+		"Must specify the tasks to move and the status to move them to":  "移動するタスクと移動先のステータスを指定してください",
+		"Moved task %d to %s\n":                                          "タスク %d を %s に移動しました\n",
+		"Task %d isn't in progress":                                      "タスク %d は進行中ではありません",
+		"Started task %d\n":                                              "タスク %d を開始しました\n",
+		"Stopped task %d, %s spent on it so far\n":                       "タスク %d を停止しました。これまでの作業時間は %s です\n",
+		"Stopped task %d\n":                                              "タスク %d を停止しました\n",
+		"Time spent":                                                     "作業時間",
+		"Move tasks in progress back to incomplete":                      "進行中のタスクを未完了に戻す",
+		"Mark tasks as in progress":                                      "タスクを進行中にする",
+		"The \"%s\" status is missing from the config":                   "設定に \"%s\" ステータスがありません",
+		"Can't use the blocked flag in combination with the status flag": "blocked フラグと status フラグは同時に使用できません",
+		"Mark tasks as blocked, optionally with the reason why":          "タスクをブロック中にする。理由も記録できます",
+		"Blocked task %d\n":                                              "タスク %d をブロック中にしました\n",
+		"Blocked":                                                        "ブロック中",
+		"Close tasks as cancelled rather than completed":                 "タスクを完了ではなくキャンセルとして閉じる",
+		"Cancelled task %d\n":                                            "タスク %d をキャンセルしました\n",
+		"Add a timestamped comment to a task":                            "タスクに日時付きのコメントを追加する",
+		"Must specify a task and the comment to add":                     "タスクと追加するコメントを指定してください",
+		"Commented on task %d\n":                                         "タスク %d にコメントしました\n",
+		"Comments":                                                       "コメント",
+		"Can't use task IDs or the all or tag flags in combination with a filter": "フィルターとタスクIDやallまたはtagフラグは同時に使えません",
+		"%s can't be used with a filter":                                          "%sはフィルターと一緒に使えません",
+		"No tasks match the filter":                                               "フィルターに一致するタスクはありません",
+		"Can't use task IDs in combination with a filter":                         "フィルターとタスクIDは同時に使えません",
+		"Can't use task IDs or the tag flag in combination with a filter":         "フィルターとタスクIDやtagフラグは同時に使えません",
+		"Delete %d tasks matching the filter?":                                    "フィルターに一致する%d件のタスクを削除しますか?",
+		"Invalid view name \"%s\"":                                                "無効なビュー名「%s」",
+		"No saved views, save one with \"task view save\"":                        "保存されたビューはありません。\"task view save\"で保存してください",
+		"Saved view %s, run it with \"task view %s\"\n":                           "ビュー%sを保存しました。\"task view %s\"で実行できます\n",
+		"Delete a saved view":                                                     "保存されたビューを削除する",
+		"Must provide a name and a filter":                                        "名前とフィルターを指定してください",
+		"No view named \"%s\"":                                                    "「%s」という名前のビューはありません",
+		"Save a filter as a view":                                                 "フィルターをビューとして保存する",
+		"Run a saved filter, or list the saved filters":                           "保存されたフィルターを実行する、または一覧表示する",
+		"\"%s\" isn't a filter term":                                              "「%s」はフィルターの条件ではありません",
+		"Must provide the name of a view":                                         "ビューの名前を指定してください",
+		"Deleted view %s\n":                                                       "ビュー%sを削除しました\n",
+		"No view named \"%s\", save one with \"task view save\"":                  "「%s」という名前のビューはありません。\"task view save\"で保存してください",
+		"Invalid config file %s: \"%s\" isn't a filter term":                      "無効な設定ファイル%s: 「%s」はフィルターの条件ではありません",
+		"ID":  "ID",
+		"Age": "経過",
+		"report \"%s\": \"%s\" isn't a filter term":                                          "レポート「%s」: 「%s」はフィルターの条件ではありません",
+		"Print a digest of your tasks over a period, or a report defined in the config file": "期間内のタスクの概要、または設定ファイルで定義したレポートを表示する",
+		"report \"%s\": can't sort by \"%s\", must be one of %s":                             "レポート「%s」: 「%s」では並べ替えできません。%sのいずれかを指定してください",
+		"No report named \"%s\"":                                                             "「%s」という名前のレポートはありません",
+		"the \"%s\" report is built in":                                                      "「%s」レポートは組み込みです",
+		"report \"%s\": invalid grouping \"%s\", must be one of %s":                          "レポート「%s」: 無効なグループ化「%s」です。%sのいずれかを指定してください",
+		"report \"%s\": unknown column \"%s\", must be one of %s":                            "レポート「%s」: 不明な列「%s」です。%sのいずれかを指定してください",
+		"Streak:":              "連続記録:",
+		"Due today:":           "今日が期限:",
+		"Completed this week:": "今週の完了:",
+		"%d days":              "%d日",
+		"Overdue:":             "期限切れ:",
+		"Print an overview of your tasks: open, due, completed and your streak": "タスクの概要を表示する: 未完了、期限、完了、連続記録",
+		"Open:":                       "未完了:",
+		"Completed today:":            "今日の完了:",
+		"Burnup: completed █, open ░": "バーンアップ: 完了 █、未完了 ░",
+		"Arrange your open tasks in an urgent/important grid": "未完了のタスクを緊急度と重要度のグリッドに並べる",
+		"The number of days must be at least 0":               "日数は0以上にしてください",
+		"Do first: urgent and important":                      "すぐやる: 緊急かつ重要",
+		"Schedule: important":                                 "計画する: 重要",
+		"Delegate: urgent":                                    "任せる: 緊急",
+		"Eliminate: neither":                                  "やめる: どちらでもない",
+		"Points can't be negative":                            "ポイントは負の値にできません",
+		"That's %d points\n":                                  "合計 %d ポイントです\n",
+		"Points":                                              "ポイント",
+		"Points:   %.2f points/day, %d open points\n":         "ポイント: 1 日あたり %.2f ポイント、未完了 %d ポイント\n",
+		"Export the time tracked with start and stop in Timewarrior's format":      "startとstopで記録した時間をTimewarrior形式で出力する",
+		"Timewarrior's data directory %s doesn't exist, is Timewarrior installed?": "Timewarriorのデータディレクトリ%sがありません。Timewarriorはインストールされていますか?",
+		"Added %d intervals to %s\n": "%d件の区間を%sに追加しました\n",
+		"No description column, use --map to choose the columns, e.g. --map desc=1":                                                        "説明の列がありません。--mapで列を指定してください (例: --map desc=1)",
+		"Invalid format \"%s\", must be one of %s":                                                                                         "無効な形式「%s」です。%sのいずれかを指定してください",
+		"Add tasks from a file exported by another tracker":                                                                                "他のツールから書き出したファイルのタスクを追加する",
+		"Invalid mapping \"%s\", must be field=column with a field such as desc, tag, status, priority, points, due, created or completed": "無効な対応付け「%s」です。フィールド=列の形式で、desc、tag、status、priority、points、due、created、completedなどのフィールドを指定してください",
+		"Nothing to import": "インポートするものはありません",
+		"Invalid column \"%s\", columns are counted from 1": "無効な列「%s」です。列は1から数えます",
+		"Row %d: %v":                                                        "%d行目: %v",
+		"Invalid points \"%s\"":                                             "無効なポイント「%s」",
+		"Archived %d completed tasks\n":                                     "完了したタスク %d 件をアーカイブしました\n",
+		"To the archive:":                                                   "アーカイブへ:",
+		"Could not read the Reminders list: %v":                             "リマインダーのリストを読み込めませんでした: %v",
+		"Mirror your tasks to another app":                                  "タスクを別のアプリと同期します",
+		"Added %d tasks and completed %d from Reminders\n":                  "リマインダーから %d 件のタスクを追加し、%d 件を完了しました\n",
+		"Could not update the Reminders list: %v":                           "リマインダーのリストを更新できませんでした: %v",
+		"Added %d reminders, updated %d and deleted %d in %s\n":             "%d 件のリマインダーを追加、%d 件を更新、%d 件を削除しました (%s)\n",
+		"Syncing with Reminders needs macOS":                                "リマインダーとの同期には macOS が必要です",
+		"Mirror your tasks to a list in the macOS Reminders app, both ways": "タスクを macOS のリマインダーアプリのリストと双方向に同期します",
+		"Overdue task":                                                      "期限切れのタスク",
+		"Invalid interval %s, must be positive":                             "無効な間隔 %s です。正の値にしてください",
+		"Show a desktop notification when a task is due, until interrupted": "中断されるまで、タスクの期限が来たらデスクトップ通知を表示します",
+		"Task due today":                                                    "今日が期限のタスク",
+		"Task due %s":                                                       "%s が期限のタスク",
+		"Invalid %s: %v":                                                    "無効な %s: %v",
+		"Stop and remove the background jobs added by install":              "install で追加したバックグラウンドジョブを停止して削除します",
+		"Installing background jobs needs systemd or launchd, which %s doesn't have": "バックグラウンドジョブのインストールには systemd か launchd が必要ですが、%s にはありません",
+		"Invalid number of backups %d, must be 0 or more":                            "無効なバックアップ数 %d です。0 以上にしてください",
+		"loaded":                 "読み込み済み",
+		"Saved a backup to %s\n": "バックアップを %s に保存しました\n",
+		"Print whether the background jobs added by install are running": "install で追加したバックグラウンドジョブが実行中かどうかを表示します",
+		"not installed":  "未インストール",
+		"Installed %s\n": "%s をインストールしました\n",
+		"not loaded":     "未読み込み",
+		"Removed %s\n":   "%s を削除しました\n",
+		"Run remind in the background and back up the database daily, with systemd or launchd": "systemd か launchd で remind をバックグラウンドで実行し、データベースを毎日バックアップします",
+		"Save a copy of the database in the backups directory":                                 "データベースのコピーをバックアップディレクトリに保存します",
+		"Nothing is installed":                                    "何もインストールされていません",
+		"Deleted %d old backups\n":                                "古いバックアップを %d 件削除しました\n",
+		"Print a one line summary for status bars such as tmux's": "tmux などのステータスバー向けに 1 行の概要を表示します",
+		"Unknown placeholder \"%s\", must be one of %s":           "不明なプレースホルダー \"%s\" です。%s のいずれかにしてください",
+		"Print the number of open and overdue tasks and the task in progress, for shell prompts": "シェルのプロンプト向けに、未完了と期限切れのタスク数、進行中のタスクを表示します",
+		"Invalid request, must have \"jsonrpc\": \"2.0\" and a method":                           "無効なリクエストです。\"jsonrpc\": \"2.0\" とメソッドが必要です",
+		"Serve a JSON-RPC API for editor plugins":                                                "エディタのプラグイン向けに JSON-RPC API を提供します",
+		"Must use --stdio, the API is only served over stdin and stdout":                         "--stdio を指定してください。API は標準入出力でのみ提供されます",
+		"Could not find the added task":                                                          "追加したタスクが見つかりませんでした",
+		"Unknown method \"%s\", must be one of %s":                                               "不明なメソッド \"%s\" です。%s のいずれかにしてください",
+		"Assignee":                               "担当者",
+		"Generated":                              "作成日時",
+		"Saved the report to %s\n":               "レポートを %s に保存しました\n",
+		"Completed tasks":                        "完了したタスク",
+		"Open tasks":                             "未完了のタスク",
+		"Open":                                   "未完了",
+		"No tasks were completed in this period": "この期間に完了したタスクはありません",
+		"Task report":                            "タスクレポート",
+		"Completions per tag":                    "タグ別の完了数",
+		"No open tasks":                          "未完了のタスクはありません",
+		"Completions per week":                   "週ごとの完了数",
+		"Completions per day":                    "日ごとの完了数",
+		"--out can only be used with --format html":                    "--out は --format html と一緒にしか使えません",
+		"Print the task list as JSON or CSV, or laid out for printing": "タスク一覧を JSON か CSV、または印刷用のレイアウトで表示する",
+		"due %s":       "期限 %s",
+		"Tasks for %s": "%s のタスク",
+		"Invalid page length %d, must be at least %d lines": "ページの行数 %d は無効です。%d 行以上にしてください",
+		"Page %d of %d":             "%d / %d ページ",
+		"(overdue)":                 "(期限切れ)",
+		"Weekly report for %s - %s": "週次レポート %s - %s",
+		"email: invalid server \"%s\", must be host:port":                                "email: サーバー \"%s\" は無効です。host:port の形式にしてください",
+		"Completed last week":                                                            "先週完了したタスク",
+		"email: from and to must be set":                                                 "email: from と to を設定してください",
+		"Set up the email section of config.json to email reports":                       "レポートをメールで送るには config.json の email セクションを設定してください",
+		"Due this week":                                                                  "今週期限のタスク",
+		"Print the tasks completed last week and the tasks due in the coming week":       "先週完了したタスクと今後1週間に期限を迎えるタスクを表示する",
+		"Invalid schedule \"%s\", must be a weekday and a time such as \"monday 08:00\"": "スケジュール \"%s\" は無効です。\"monday 08:00\" のように曜日と時刻を指定してください",
+		"Sent the weekly report to %s\n":                                                 "週次レポートを %s に送信しました\n",
+		"Per day: %s (most %d)\n":                                                        "日ごと: %s (最大 %d)\n",
+		"Invalid config file %s: unknown color state \"%s\", must be one of %s":          "設定ファイル %s が無効です: 色の状態 \"%s\" は不明です。%s のいずれかにしてください",
+		"Unknown color \"%s\"":                                                           "色 \"%s\" は不明です",
+		"invalid date_format \"%s\", must be \"locale\" or a layout such as \"2006-01-02\" or \"Jan 2\"": "date_format \"%s\" は無効です。\"locale\" か \"2006-01-02\" や \"Jan 2\" のような形式にしてください",
+		"just now":                "たった今",
+		"tomorrow":                "明日",
+		"yesterday":               "昨日",
+		"today":                   "今日",
+		"%s ago":                  "%s前",
+		"in %s":                   "%s後",
+		"Unknown timezone \"%s\"": "不明なタイムゾーン \"%s\"",
+		"Invalid config file %s: unknown timezone \"%s\"":                    "設定ファイル %s が無効です: タイムゾーン \"%s\" は不明です",
+		"No changes recorded":                                                "変更の記録はありません",
+		"History (%d changes)":                                               "履歴 (%d 件の変更)",
+		"Manage the database file":                                           "データベースファイルを管理します",
+		"Merge the tasks and archive of another database file into this one": "別のデータベースファイルのタスクとアーカイブをこのデータベースに統合します",
+		"Can't merge the database into itself":                               "データベースをそれ自身に統合することはできません",
+		"Could not open %s: %v":                                              "%s を開けませんでした: %v",
+		"Would create %d tasks, update %d and skip %d, run again without --dry-run to import them": "%d 件のタスクを作成、%d 件を更新、%d 件をスキップします。インポートするには --dry-run なしで再実行してください",
+		"Created %d tasks, updated %d and skipped %d":                                              "%d 件のタスクを作成、%d 件を更新、%d 件をスキップしました",
+		"Deleted %d old tasks from the archive\n":                                                  "アーカイブから古いタスクを %d 件削除しました\n",
+		"archive: max_entries must be 0 or more":                                                   "archive: max_entries は 0 以上にしてください",
+		"Invalid period \"%s\", must be a number of days, weeks or months such as 30d, 2w or 6m":   "無効な期間 \"%s\" です。30d、2w、6m のような日数、週数、月数にしてください",
+		"Read and change the settings of config.json":                                              "config.json の設定を読み書きします",
+		"Print a setting, such as do.auto_finish. Prints nothing for settings that aren't set":     "do.auto_finish などの設定を表示します。設定されていない場合は何も表示しません",
+		"Change a setting, such as do.auto_finish, checking the key and the value":                 "キーと値を確認して、do.auto_finish などの設定を変更します",
+		"Set %s to %s\n": "%s を %s に設定しました\n",
+		"Print every setting in use with its value": "使用中のすべての設定と値を表示します",
+		"Unknown setting \"%s\"":                    "不明な設定 \"%s\"",
+		"Invalid value \"%s\" for %s, must be JSON such as true, 10 or [\"a\", \"b\"]":                        "値 \"%s\" は %s には無効です。true、10、[\"a\", \"b\"] のような JSON にしてください",
+		"Invalid config file %s: the defaults of \"%s\" must start with a flag, not \"%s\"":                   "設定ファイル %s が無効です: \"%s\" のデフォルトは \"%s\" ではなくフラグで始めてください",
+		"%s with the TASK_* environment variables":                                                            "%s と TASK_* 環境変数",
+		"Interactive mode can't be used while the daemon is running":                                          "デーモンの実行中はインタラクティブモードを使えません",
+		"↑/↓ to move, space to select, enter to confirm, q to quit":                                           "↑/↓ で移動、スペースで選択、Enter で確定、q で終了",
+		"Can't use task IDs in combination with -i":                                                           "タスク ID と -i は同時に使えません",
+		"Due date, empty for none: ":                                                                          "期限（空欄でなし）: ",
+		"Go through the open tasks one at a time, setting their priority, due date and tags with single keys": "未完了のタスクを1つずつ確認し、1キーで優先度・期限・タグを設定する",
+		"tags: %s  due: %s  priority: %s":                                                                     "タグ: %s  期限: %s  優先度: %s",
+		"h/m/l priority, 0 no priority, d due date, t tags, s snooze a week, enter next, ← back, q quit":      "h/m/l 優先度、0 優先度なし、d 期限、t タグ、s 1週間延期、Enter 次へ、← 戻る、q 終了",
+		"Tags, +tag to add and -tag to remove: ":                                                              "タグ（+タグで追加、-タグで削除）: ",
+		"Task %d of %d":                                                                                       "タスク %d / %d",
+		"sort only runs interactively, use sort -i":                                                           "sort は対話モードでのみ動作します。sort -i を使ってください",
+		"Changed %d of %d tasks\n":                                                                            "%d / %d 件のタスクを変更しました\n",
+		"# Edit the descriptions, +tags and due:yyyy-mm-dd dates of the tasks below, then save and":           "# 下のタスクの説明、+タグ、due:yyyy-mm-dd の期限を編集し、保存して",
+		"# close the editor. Lines starting with # are ignored, removing a line leaves its task as it is":     "# エディタを閉じてください。# で始まる行は無視され、行を消してもそのタスクは変わりません",
+		"Can't use task IDs or a filter in combination with --all":                                            "タスク ID やフィルタと --all は同時に使えません",
+		"Line %d: \"%s\" isn't the ID of a task being edited":                                                 "%d 行目: \"%s\" は編集中のタスクの ID ではありません",
+		"Line %d: %v":                      "%d 行目: %v",
+		"Line %d: task %d is listed twice": "%d 行目: タスク %d が2回あります",
+		"No changes":                       "変更はありません",
+		"Edit the descriptions, tags and due dates of tasks as text in your $EDITOR": "タスクの説明・タグ・期限を $EDITOR でテキストとして編集する",
+		"Aborted, nothing was changed": "中止しました。何も変更されていません",
+		"format must be \"%s\"":        "format は \"%s\" でなければなりません",
+		"Replace the %d tasks and %d archived tasks of the database with the %d tasks and %d archived tasks of %s?": "データベースの %d 件のタスクと %d 件のアーカイブ済みタスクを、%d 件のタスクと %d 件のアーカイブ済みタスク（%s）で置き換えますか？",
+		"ID %d is used twice": "ID %d が2回使われています",
+		"Replace the whole database with a file written by db dump, - for stdin": "db dump で書き出したファイルでデータベース全体を置き換える（- で標準入力）",
+		"Invalid dump %s: %v": "無効なダンプ %s: %v",
+		"Print the whole database as text that db load reads back":                                 "db load で読み戻せるテキストとしてデータベース全体を出力する",
+		"invalid ID %d of \"%s\", must be 1 or more":                                               "ID %d（\"%s\"）が無効です。1 以上でなければなりません",
+		"Loaded %d tasks and %d archived tasks\n":                                                  "%d 件のタスクと %d 件のアーカイブ済みタスクを読み込みました\n",
+		"unsupported version %d, this version of task reads version %d":                            "バージョン %d には対応していません。この task はバージョン %d を読み込みます",
+		"Apply the changes in a file or stdin, one JSON object per line, all at once":              "ファイルまたは標準入力の変更（1行に1つの JSON オブジェクト）をまとめて適用する",
+		"%d of %d operations failed, nothing was changed":                                          "%d / %d 件の操作が失敗したため、何も変更されていません",
+		"Task %d is deleted twice":                                                                 "タスク %d が2回削除されています",
+		"Unknown op \"%s\", must be one of %s":                                                     "不明な op \"%s\" です。%s のいずれかでなければなりません",
+		"The daemon couldn't check for due tasks":                                                  "デーモンが期限のタスクを確認できませんでした",
+		"Can't read the clipboard: %v":                                                             "クリップボードを読み取れません: %v",
+		"Lost the connection to the daemon, the command may have run: %v":                          "デーモンとの接続が切れました。コマンドは実行済みの可能性があります: %v",
+		"Use -y to load a dump from stdin":                                                         "stdin からダンプを読み込むには -y を指定してください",
+		"Print the archive as JSON or CSV":                                                         "アーカイブを JSON または CSV で出力する",
+		"Invalid format \"%s\", must be \"json\" or \"csv\"":                                       "無効な形式 \"%s\" です。\"json\" または \"csv\" を指定してください",
+		"Invalid date \"%s\", must be in the format mm/dd/yyyy":                                    "無効な日付 \"%s\" です。mm/dd/yyyy 形式で指定してください",
+		"No tasks were completed in the last %d days, the backlog won't be cleared at this pace\n": "過去 %d 日間に完了したタスクがないため、このペースでは未完了のタスクは終わりません\n",
+		"At this pace the backlog is cleared in %d days, around %s\n":                              "このペースだと未完了のタスクは %d 日後、%s ごろに終わります\n",
+		"Mon":               "月",
+		"Tue":               "火",
+		"Wed":               "水",
+		"Thu":               "木",
+		"Fri":               "金",
+		"Sat":               "土",
+		"Sun":               "日",
+		"Listening on %s\n": "%s で待機しています\n",
+		"The editor can't be opened while the daemon is running, pass the task as an argument instead": "デーモンの実行中はエディタを開けません。タスクを引数として渡してください",
+		"%d is out of range, only %d tasks exist\n":                                                    "%d は範囲外です。タスクは %d 件しかありません\n",
+		"%d tasks\n": "%d 件のタスク\n",
+		"%s  open: %d  archived: %d  last used: %s":                   "%s  未完了: %d  アーカイブ: %d  最終使用: %s",
+		"%s is not a file":                                            "%s はファイルではありません",
+		"A CLI for managing your TODOs":                               "TODO を管理する CLI",
+		"Add a new task to your TODO list":                            "TODO リストに新しいタスクを追加します",
+		"Added task: '%s'\n":                                          "タスクを追加しました: '%s'\n",
+		"Archive is empty, finish a task to add it to the archive":    "アーカイブは空です。タスクを finish するとアーカイブに追加されます",
+		"Attached %s to task %d\n":                                    "%s をタスク %d に添付しました\n",
+		"Attachments":                                                 "添付ファイル",
+		"Average: %.1f/day\n":                                         "平均: %.1f 件/日\n",
+		"Can't use tag filtering in combination with exclude flag":    "タグによる絞り込みと除外フラグは同時に使用できません",
+		"Can't use task IDs in combination with the all or tag flags": "タスク ID と all または tag フラグは同時に使用できません",
+		"Can't use the all flag in combination with the tag flag":     "all フラグと tag フラグは同時に使用できません",
+		"Cleared the archive":                                         "アーカイブを消去しました",
+		"Completed %d tasks\n":                                        "%d 件のタスクを完了しました\n",
+		"Completed task %d\n":                                         "タスク %d を完了しました\n",
+		"Completed":                                                   "完了日時",
+		"Count must be at least 1":                                    "件数は 1 以上である必要があります",
+		"Created":                                                     "作成日時",
+		"Delete a task":                                               "タスクを削除します",
+		"Delete all completed tasks":                                  "完了したタスクをすべて削除します",
+		"Delete all tasks":                                            "すべてのタスクを削除します",
+		"Deleted %d tasks\n":                                          "%d 件のタスクを削除しました\n",
+		"Deleted Task ":                                               "削除したタスク ",
+		"Deleted all completed tasks tagged %s\n":                     "タグ %s の完了したタスクをすべて削除しました\n",
+		"Deleted all completed tasks\n":                               "完了したタスクをすべて削除しました\n",
+		"Deleted all tasks":                                           "すべてのタスクを削除しました",
+		"Deleted task %d\n":                                           "タスク %d を削除しました\n",
+		"Description":                                                 "説明",
+		"Did not make any updates, try using a flag":                  "何も更新されませんでした。フラグを指定してください",
+		"Due": "期限",
+		"Duplicate a task as new incomplete tasks":                   "タスクを未完了の新しいタスクとして複製します",
+		"Duplicated task %d %d times\n":                              "タスク %d を %d 回複製しました\n",
+		"Error parsing completed date:":                              "完了日の解析エラー:",
+		"Error parsing date:":                                        "日付の解析エラー:",
+		"Error: End date occured prior to the Start date":            "エラー: 終了日が開始日より前です",
+		"Failed to copy %s: %v":                                      "%s をコピーできませんでした: %v",
+		"Failed to open %s: %v":                                      "%s を開けませんでした: %v",
+		"Invalid attachment %d, task %d has %d attachments":          "添付ファイル %d は無効です。タスク %d の添付ファイルは %d 件です",
+		"Invalid task ID, %d tasks exist":                            "無効なタスク ID です。タスクは %d 件あります",
+		"Link a file to a task":                                      "タスクにファイルを関連付けます",
+		"List all of your incomplete tasks":                          "未完了のタスクをすべて表示します",
+		"Mark a task on your TODO list as complete":                  "TODO リストのタスクを完了にします",
+		"Move a task down one position in your TODO list":            "タスクを TODO リストで 1 つ下に移動します",
+		"Move a task up one position in your TODO list":              "タスクを TODO リストで 1 つ上に移動します",
+		"Moved task %d down\n":                                       "タスク %d を下に移動しました\n",
+		"Moved task %d up\n":                                         "タスク %d を上に移動しました\n",
+		"Must provide a task ID":                                     "タスク ID を指定してください",
+		"Must provide a task description":                            "タスクの説明を指定してください",
+		"Must specify a single task to duplicate":                    "複製するタスクを 1 つ指定してください",
+		"Must specify a single task to move":                         "移動するタスクを 1 つ指定してください",
+		"Must specify a single task to open":                         "開くタスクを 1 つ指定してください",
+		"Must specify a single task to show":                         "表示するタスクを 1 つ指定してください",
+		"Must specify a task to update":                              "更新するタスクを指定してください",
+		"Must specify a start date":                                  "開始日を指定してください",
+		"Must specify a task and a file to attach":                   "タスクと添付するファイルを指定してください",
+		"No completed tasks to finish":                               "finish する完了済みタスクはありません",
+		"No completed tasks to purge":                                "purge する完了済みタスクはありません",
+		"No matching tasks to complete":                              "完了にする該当タスクはありません",
+		"No matching tasks to delete":                                "削除する該当タスクはありません",
+		"No tags":                                                    "タグはありません",
+		"No tasks":                                                   "タスクはありません",
+		"Open the first URL in a task's description in your browser": "タスクの説明にある最初の URL をブラウザで開きます",
+		"Opened %s\n":                                                "%s を開きました\n",
+		"Permanently delete all completed tasks without adding them to the archive": "完了したタスクをアーカイブに追加せずに完全に削除します",
+		"Print existing tags":                       "既存のタグを表示します",
+		"Print the number of existing tasks":        "既存のタスク数を表示します",
+		"Purged %d completed tasks\n":               "%d 件の完了したタスクを完全に削除しました\n",
+		"See statistics on your task completion":    "タスクの完了状況の統計を表示します",
+		"Show every detail of a task":               "タスクの詳細をすべて表示します",
+		"Status":                                    "状態",
+		"Tags must be in the form +tag, got \"%s\"": "タグは +tag の形式で指定してください。指定された値: \"%s\"",
+		"Tags":                                     "タグ",
+		"Task %d can't be moved any further":       "タスク %d はこれ以上移動できません",
+		"Task %d does not contain a URL":           "タスク %d には URL が含まれていません",
+		"Task %d does not exist":                   "タスク %d は存在しません",
+		"Task %d does not exist\n":                 "タスク %d は存在しません\n",
+		"Task %d":                                  "タスク %d",
+		"Update a task":                            "タスクを更新します",
+		"Updated task %d\n":                        "タスク %d を更新しました\n",
+		"View all previously completed tasks":      "これまでに完了したタスクをすべて表示します",
+		"You already finished task %d\n":           "タスク %d はすでに完了しています\n",
+		"\nYou completed %d tasks from %s to %s\n": "\n%d 件のタスクを完了しました (%s から %s)\n",
+		"never":                "なし",
+		`Invalid task ID "%s"`: `無効なタスク ID "%s"`,
+	},
+}
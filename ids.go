@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/boltdb/bolt"
+)
+
+// Shortest UUID or hash prefix accepted in place of a task ID
+const minUUIDPrefix = 4
+
+// Number of characters of a task's hash that are displayed, like a git abbreviation
+const shortHashLength = 7
+
+// Returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Returns the short hash identifying `t`. It's derived from the UUID, so it survives the
+// task being renumbered or edited. Empty if the task has no UUID
+func shortHash(t Task) string {
+	if t.UUID == "" {
+		return ""
+	}
+	sum := sha1.Sum([]byte(t.UUID))
+	return hex.EncodeToString(sum[:])[:shortHashLength]
+}
+
+// Give a UUID to every task in `buckets` created before tasks had one
+func assignUUIDs(tx *bolt.Tx, buckets ...[]byte) error {
+	for _, bucket := range buckets {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			continue
+		}
+		updates := map[int]Task{}
+		b.ForEach(func(k, v []byte) error {
+			if t := bToTask(v); t.UUID == "" {
+				t.UUID = newUUID()
+				updates[btoi(k)] = t
+			}
+			return nil
+		})
+		for k, t := range updates {
+			buf, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(k), buf); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve `s`, either a task ID or the prefix of a task's UUID or hash, to the key of a task
+// in the tasks bucket. A number is a task ID if such a task exists. Hashes can be all digits,
+// but a number is only taken for one when it's as long as a displayed hash, so a mistyped ID
+// such as 1000 never picks an unrelated task. Numbers matching no task are returned as is,
+// for the caller to report
+func parseTaskID(db *bolt.DB, s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	isNumber := err == nil
+	if isNumber {
+		if _, err := getTask(db, id); err == nil || len(s) < shortHashLength {
+			return id, nil
+		}
+	}
+	prefix := strings.ToLower(s)
+	if len(prefix) < minUUIDPrefix {
+		return 0, fmt.Errorf(tr(`Invalid task ID "%s"`), s)
+	}
+
+	var matches []int
+	for _, tp := range getTasks(db, TASKS_BUCKET) {
+		if strings.HasPrefix(tp.task.UUID, prefix) || strings.HasPrefix(shortHash(tp.task), prefix) {
+			matches = append(matches, tp.dbKey)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		if isNumber {
+			return id, nil
+		}
+		return 0, fmt.Errorf(tr(`Invalid task ID "%s"`), s)
+	case 1:
+		return matches[0], nil
+	default:
+		return 0, fmt.Errorf(tr(`"%s" matches %d tasks, use a longer prefix`), s, len(matches))
+	}
+}
+
+// Resolve `s` like parseTaskID to a task that exists, returning its UUID
+func findParent(db *bolt.DB, s string) (string, error) {
+	id, err := parseTaskID(db, s)
+	if err != nil {
+		return "", err
+	}
+	t, err := getTask(db, id)
+	if err != nil {
+		return "", fmt.Errorf(tr("Task %d does not exist"), id)
+	}
+	return t.UUID, nil
+}
+
+// Reports whether the task with UUID `ancestor` is a parent, grandparent and so on of the task
+// with UUID `uuid`, among the tasks in `tp`
+func isAncestor(tp []TaskPosition, ancestor, uuid string) bool {
+	parents := map[string]string{}
+	for _, t := range tp {
+		parents[t.task.UUID] = t.task.Parent
+	}
+	// Stops after as many steps as there are tasks in case the parents form a cycle
+	for range tp {
+		uuid = parents[uuid]
+		if uuid == "" {
+			return false
+		}
+		if uuid == ancestor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+// Formats `import` reads
+var IMPORT_FORMATS = []string{"csv", "md"}
+
+// What an import does with a task the database already has
+type DuplicatePolicy int
+
+const (
+	// Add it again
+	DuplicatesAdd DuplicatePolicy = iota
+	// Leave the task the database has as it is
+	DuplicatesSkip
+	// Replace the task the database has with the imported one
+	DuplicatesOverwrite
+	// Merge both with mergeTasks
+	DuplicatesMerge
+)
+
+// What an import did, or would do with --dry-run
+type ImportResult struct {
+	// Tasks added to the database
+	Created int
+	// Tasks the database already had, changed by the import
+	Updated int
+	// Tasks the database already had, left as they were
+	Skipped int
+}
+
+// Returned from a transaction to roll back the changes of --dry-run
+var errDryRun = errors.New("dry run")
+
+// Task fields a CSV column can be mapped to, by the names accepted in `import --map`
+var importFields = map[string]string{
+	"desc":        "desc",
+	"description": "desc",
+	"tag":         "tags",
+	"tags":        "tags",
+	"status":      "status",
+	"priority":    "priority",
+	"points":      "points",
+	"due":         "due",
+	"created":     "created",
+	"completed":   "completed",
+}
+
+func newImportCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	iCmd := &cobra.Command{
+		Use:          "import [file] -[fm] [--dry-run]",
+		Short:        tr("Add tasks from a file exported by another tracker"),
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Read stdin without a file, e.g. `task archive export -f csv | task import`
+			in := cmd.InOrStdin()
+			format := ImportFormat
+			if len(args) > 0 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+				if format == "" {
+					format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+				}
+			}
+			if format == "" {
+				format = "csv"
+			}
+
+			var tasks []Task
+			var err error
+			switch format {
+			case "csv":
+				tasks, err = importCSV(in, ImportMap, ImportHeader, time.Now())
+			case "md", "markdown":
+				tasks, err = importMarkdown(in, time.Now())
+			default:
+				return fmt.Errorf(tr(`Invalid format "%s", must be one of %s`), format, strings.Join(IMPORT_FORMATS, ", "))
+			}
+			if err != nil {
+				return err
+			}
+			if len(tasks) == 0 {
+				fmt.Fprintln(out, tr("Nothing to import"))
+				return nil
+			}
+
+			var archived []Task
+			if ImportArchiveDone {
+				tasks, archived = splitDone(tasks)
+			}
+			if ImportDryRun {
+				if len(tasks) > 0 {
+					fmt.Fprintln(out, formatImportPreview(tasks))
+				}
+				if len(archived) > 0 {
+					fmt.Fprintln(out, tr("To the archive:"))
+					fmt.Fprintln(out, formatImportPreview(archived))
+				}
+			}
+			policy := duplicatePolicy(DuplicatesAdd, ImportSkipDuplicates, ImportOverwrite)
+			// A file has no UUIDs, a task with the same description is already there
+			same := func(existing, t Task) bool {
+				return strings.TrimSpace(existing.Desc) == strings.TrimSpace(t.Desc)
+			}
+			overwrite := []string{"description", "status", "due", "tags", "priority", "points"}
+			var r ImportResult
+			err = mgr.db.Update(func(tx *bolt.Tx) error {
+				if r, err = addImported(tx, tasks, archived, policy, same, overwrite); err != nil {
+					return err
+				}
+				if ImportDryRun {
+					return errDryRun
+				}
+				return nil
+			})
+			if err != nil && err != errDryRun {
+				return err
+			}
+			fmt.Fprintln(out, formatImportResult(r, ImportDryRun))
+			if len(archived) > 0 && !ImportDryRun {
+				fmt.Fprintf(out, tr("Archived %d completed tasks\n"), len(archived))
+			}
+			return nil
+		},
+	}
+	iCmd.Flags().StringVarP(&ImportFormat, "format", "f", "", "Format of the file: csv or md. Defaults to the file extension, or csv")
+	iCmd.Flags().StringVarP(&ImportMap, "map", "m", "", "Columns holding each field, counting from 1, e.g. desc=2,tag=4,created=5. Defaults to the columns named after the fields in the header")
+	iCmd.Flags().BoolVar(&ImportHeader, "header", true, "The first row is a header rather than a task")
+	iCmd.Flags().BoolVar(&ImportArchiveDone, "archive-done", false, "Add completed tasks straight to the archive")
+	iCmd.Flags().BoolVar(&ImportDryRun, "dry-run", false, "Print the tasks that would be imported and what would be done with them, without importing them")
+	iCmd.Flags().BoolVar(&ImportSkipDuplicates, "skip-duplicates", false, "Leave out tasks with the same description as a task you already have")
+	iCmd.Flags().BoolVar(&ImportOverwrite, "overwrite", false, "Replace the description, status, due date, tags, priority and points of tasks with the same description as a task you already have")
+	iCmd.MarkFlagsMutuallyExclusive("skip-duplicates", "overwrite")
+	return iCmd
+}
+
+// Parse a mapping such as "desc=2,tag=4,created=5" to the index of the column of each field
+func parseColumnMap(s string) (map[string]int, error) {
+	columns := map[string]int{}
+	for _, pair := range strings.Split(s, ",") {
+		name, col, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		field, known := importFields[strings.ToLower(name)]
+		if !ok || !known {
+			return nil, fmt.Errorf(tr(`Invalid mapping "%s", must be field=column with a field such as desc, tag, status, priority, points, due, created or completed`), pair)
+		}
+		n, err := strconv.Atoi(col)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf(tr(`Invalid column "%s", columns are counted from 1`), col)
+		}
+		columns[field] = n - 1
+	}
+	return columns, nil
+}
+
+// Read tasks from CSV `r`, taking each field from the column `mapping` assigns it. Without a
+// mapping, columns are matched to fields by the names in the header. Relative dates are
+// relative to `now`. Returns an error naming the row of the first invalid value
+func importCSV(r io.Reader, mapping string, header bool, now time.Time) ([]Task, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns map[string]int
+	if mapping != "" {
+		if columns, err = parseColumnMap(mapping); err != nil {
+			return nil, err
+		}
+	} else if header && len(rows) > 0 {
+		columns = map[string]int{}
+		for i, name := range rows[0] {
+			if field, ok := importFields[strings.ToLower(strings.TrimSpace(name))]; ok {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["desc"]; !ok {
+		return nil, errors.New(tr("No description column, use --map to choose the columns, e.g. --map desc=1"))
+	}
+
+	first := 0
+	if header {
+		first = 1
+	}
+	var tasks []Task
+	for i := first; i < len(rows); i++ {
+		t, err := taskFromRow(rows[i], columns, now)
+		if err != nil {
+			return nil, fmt.Errorf(tr("Row %d: %v"), i+1, err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// Build a task from the CSV `row`, see importCSV
+func taskFromRow(row []string, columns map[string]int, now time.Time) (Task, error) {
+	value := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	t := newTask(value("desc"), nil)
+	t.Created = timestamp(now)
+	if t.Desc == "" {
+		return t, errors.New(tr("Must provide a task description"))
+	}
+	// Tags may be separated by commas or spaces, with or without a leading +
+	for _, tag := range strings.FieldsFunc(value("tags"), func(r rune) bool { return r == ',' || r == ' ' }) {
+		if tag = strings.TrimPrefix(tag, "+"); tag != "" && !slices.Contains(t.Tags, tag) {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+
+	var err error
+	if t.Priority, err = parsePriority(value("priority")); err != nil {
+		return t, err
+	}
+	if s := value("points"); s != "" {
+		if t.Points, err = strconv.Atoi(s); err != nil || t.Points < 0 {
+			return t, fmt.Errorf(tr(`Invalid points "%s"`), s)
+		}
+	}
+	dates := []struct {
+		field string
+		dst   *string
+	}{{"due", &t.Due}, {"created", &t.Created}, {"completed", &t.Completed}}
+	for _, d := range dates {
+		s := value(d.field)
+		if s == "" {
+			continue
+		}
+		parsed, err := parseImportedDate(s, now)
+		if err != nil {
+			return t, err
+		}
+		// Due dates are days, kept at midnight where they were set
+		if d.field == "due" {
+			*d.dst = parsed.Format(RFC3339)
+		} else {
+			*d.dst = timestamp(parsed)
+		}
+	}
+
+	if s := value("status"); s != "" {
+		if t.Status, err = parseStatus(s); err != nil {
+			return t, err
+		}
+	}
+	// A task done without a completion date was done when it was imported
+	if isDone(t) && t.Completed == "" {
+		t.Completed = timestamp(now)
+	}
+	if !isDone(t) {
+		t.Completed = ""
+	}
+	return t, nil
+}
+
+// Parse a date written by another tracker: an RFC3339 timestamp or any date parseDate accepts
+func parseImportedDate(s string, now time.Time) (time.Time, error) {
+	if t, err := parseTimestamp(s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, now.Location()); err == nil {
+		return t, nil
+	}
+	return parseDate(s, now)
+}
+
+// Matches a Markdown checklist item such as "- [x] text", capturing the check mark and the text
+var checklistItem = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+
+// Matches a Markdown heading such as "## text", capturing its level and its text
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// Read tasks from the checklist items in Markdown `r`, e.g. `- [ ] todo` and `- [x] done`.
+// Each task is tagged with the heading it's under, so items under "## Next Week" get the
+// tag next-week. +tags in an item are kept as tags. Other lines are ignored
+func importMarkdown(r io.Reader, now time.Time) ([]Task, error) {
+	var tasks []Task
+	heading := ""
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			heading = headingTag(m[2])
+			continue
+		}
+		m := checklistItem.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		tags, desc := parseTags(m[2])
+		if desc == "" {
+			continue
+		}
+		if heading != "" && !slices.Contains(tags, heading) {
+			tags = append([]string{heading}, tags...)
+		}
+		t := newTask(desc, tags)
+		t.Created = timestamp(now)
+		if m[1] != " " {
+			t.Status = STATUS.COMPLETE
+			t.Completed = t.Created
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, sc.Err()
+}
+
+// Returns the tag for the Markdown heading `text`: lower case, with words joined by dashes
+func headingTag(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// Split `tasks` into the open tasks and the tasks in a done status
+func splitDone(tasks []Task) (open, done []Task) {
+	for _, t := range tasks {
+		if isDone(t) {
+			done = append(done, t)
+		} else {
+			open = append(open, t)
+		}
+	}
+	return open, done
+}
+
+// Returns the policy of the --skip-duplicates and --overwrite flags, or `def` without them
+func duplicatePolicy(def DuplicatePolicy, skip, overwrite bool) DuplicatePolicy {
+	switch {
+	case skip:
+		return DuplicatesSkip
+	case overwrite:
+		return DuplicatesOverwrite
+	}
+	return def
+}
+
+// Add the imported `tasks` and `archived` tasks to the database of `tx`. New tasks are added
+// to the tasks or the archive as they were given. A task the database already has, as told by
+// `same`, is treated as `policy` says: overwriting replaces the `overwrite` fields of
+// historyFields, recording the changes in the task's history. Tasks the database already
+// has stay in their bucket
+func addImported(tx *bolt.Tx, tasks, archived []Task, policy DuplicatePolicy, same func(existing, t Task) bool, overwrite []string) (ImportResult, error) {
+	type existing struct {
+		bucket []byte
+		key    int
+		task   Task
+	}
+	var all []existing
+	for _, bucket := range [][]byte{TASKS_BUCKET, ARCHIVE_BUCKET} {
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return ImportResult{}, err
+		}
+		b.ForEach(func(k, v []byte) error {
+			all = append(all, existing{bucket, btoi(k), bToTask(v)})
+			return nil
+		})
+	}
+
+	var r ImportResult
+	now := time.Now()
+	added := map[string][]Task{}
+	for _, in := range []struct {
+		bucket []byte
+		tasks  []Task
+	}{{TASKS_BUCKET, tasks}, {ARCHIVE_BUCKET, archived}} {
+		for _, t := range in.tasks {
+			i := slices.IndexFunc(all, func(e existing) bool { return same(e.task, t) })
+			if i < 0 || policy == DuplicatesAdd {
+				added[string(in.bucket)] = append(added[string(in.bucket)], t)
+				r.Created++
+				continue
+			}
+			if policy == DuplicatesSkip {
+				r.Skipped++
+				continue
+			}
+
+			e := &all[i]
+			updated := snapshot(e.task)
+			if policy == DuplicatesMerge {
+				updated = mergeTasks(e.task, t)
+			} else {
+				for _, f := range historyFields {
+					if slices.Contains(overwrite, f.name) {
+						f.copy(&updated, t)
+					}
+				}
+				recordChanges(e.task, &updated, now)
+			}
+			if !importChanged(e.task, updated) {
+				r.Skipped++
+				continue
+			}
+			buf, err := json.Marshal(updated)
+			if err != nil {
+				return r, err
+			}
+			if err := tx.Bucket(e.bucket).Put(itob(e.key), buf); err != nil {
+				return r, err
+			}
+			e.task = updated
+			r.Updated++
+		}
+	}
+	for _, bucket := range [][]byte{TASKS_BUCKET, ARCHIVE_BUCKET} {
+		if err := insertTasksTx(tx, bucket, added[string(bucket)]); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// Reports whether an import changed `t` into `updated`
+func importChanged(t, updated Task) bool {
+	for _, f := range historyFields {
+		if f.value(t) != f.value(updated) {
+			return true
+		}
+	}
+	return t.Created != updated.Created || t.Completed != updated.Completed ||
+		!slices.Equal(t.Comments, updated.Comments) || !slices.Equal(t.Intervals, updated.Intervals) ||
+		!slices.Equal(t.History, updated.History)
+}
+
+// Describe `r`, what an import did or, with `dryRun`, would do
+func formatImportResult(r ImportResult, dryRun bool) string {
+	if dryRun {
+		return fmt.Sprintf(tr("Would create %d tasks, update %d and skip %d, run again without --dry-run to import them"), r.Created, r.Updated, r.Skipped)
+	}
+	return fmt.Sprintf(tr("Created %d tasks, updated %d and skipped %d"), r.Created, r.Updated, r.Skipped)
+}
+
+// Returns the tasks `import --dry-run` would create, as a table
+func formatImportPreview(tasks []Task) string {
+	var tp []TaskPosition
+	for i, t := range tasks {
+		tp = append(tp, TaskPosition{t, i + 1})
+	}
+	columns := []string{"status", "description", "tags", "priority", "due", "created"}
+	return formatReportTable([]TaskGroup{{Tasks: tp}}, columns, time.Now())
+}
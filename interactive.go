@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Keys returned by readKey besides printable characters
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyEnter     = "enter"
+	keySpace     = "space"
+	keyEscape    = "esc"
+	keyInterrupt = "ctrl-c"
+	keyBackspace = "backspace"
+)
+
+// Most tasks shown at once by selectTasks. The list scrolls to keep the cursor in view
+const pickerRows = 15
+
+// Start reading single keys from the input of `cmd`, returning it along with the writer to
+// draw on in place of `out`. A terminal is switched to raw mode until the returned function is
+// called, other input such as a pipe is read as it is. A variable so the daemon, which has no
+// terminal to read from, can refuse interactive commands
+var startInteractive = func(cmd *cobra.Command, out io.Writer) (*bufio.Reader, io.Writer, func(), error) {
+	in := cmd.InOrStdin()
+	restore := func() {}
+	if f, ok := in.(*os.File); ok && isTerminal(f) {
+		var err error
+		if restore, err = rawMode(f); err != nil {
+			return nil, nil, nil, err
+		}
+		out = rawWriter{out}
+	}
+	return bufio.NewReader(in), out, restore, nil
+}
+
+// Writes to a terminal in raw mode, where "\n" moves down a line without going back to its
+// start, as "\r\n"
+type rawWriter struct {
+	w io.Writer
+}
+
+func (r rawWriter) Write(p []byte) (int, error) {
+	if _, err := r.w.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read a key press: a printable character as it is or one of the key constants. Returns an
+// empty key for escape sequences it doesn't know
+func readKey(in *bufio.Reader) (string, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch r {
+	case '\r', '\n':
+		return keyEnter, nil
+	case ' ':
+		return keySpace, nil
+	case 3:
+		return keyInterrupt, nil
+	case 8, 127:
+		return keyBackspace, nil
+	case 27:
+		// Arrow keys arrive at once as ESC [ A, a lone ESC is the escape key
+		if in.Buffered() == 0 {
+			return keyEscape, nil
+		}
+		if b, _ := in.ReadByte(); b != '[' && b != 'O' {
+			return keyEscape, nil
+		}
+		// Parameters run until the final byte of the sequence
+		for {
+			b, err := in.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			if b >= 0x40 && b <= 0x7e {
+				switch b {
+				case 'A':
+					return keyUp, nil
+				case 'B':
+					return keyDown, nil
+				case 'C':
+					return keyRight, nil
+				case 'D':
+					return keyLeft, nil
+				}
+				return "", nil
+			}
+		}
+	}
+	return string(r), nil
+}
+
+// Let the user pick tasks among `tp` in a checkbox list drawn on `out`, reading keys from
+// `in`: up and down, or k and j, move, space toggles a task and enter confirms. Returns the
+// keys of the picked tasks in list order, and false if the user quit with q, escape or Ctrl-C
+// or the input ended
+func selectTasks(in *bufio.Reader, out io.Writer, tp []TaskPosition) ([]int, bool, error) {
+	picked := make([]bool, len(tp))
+	cursor, top, drawn := 0, 0, 0
+	draw := func() {
+		if drawn > 0 {
+			fmt.Fprintf(out, "\x1b[%dA\x1b[J", drawn)
+		}
+		// Scroll so the cursor stays in the window
+		top = min(max(top, cursor-pickerRows+1), cursor)
+		lines := []string{tr("↑/↓ to move, space to select, enter to confirm, q to quit")}
+		for i := top; i < min(top+pickerRows, len(tp)); i++ {
+			pointer, box := " ", " "
+			if i == cursor {
+				pointer = ">"
+			}
+			if picked[i] {
+				box = "x"
+			}
+			line := fmt.Sprintf("%s [%s] %d: %s", pointer, box, tp[i].dbKey, tp[i].task.Desc)
+			// A wrapped line would throw off the redraw
+			if width := terminalWidth(); width > 0 {
+				line = truncateText(line, width-1)
+			}
+			lines = append(lines, line)
+		}
+		fmt.Fprintln(out, strings.Join(lines, "\n"))
+		drawn = len(lines)
+	}
+	erase := func() {
+		fmt.Fprintf(out, "\x1b[%dA\x1b[J", drawn)
+	}
+
+	for {
+		draw()
+		key, err := readKey(in)
+		if err == io.EOF {
+			erase()
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		switch key {
+		case keyUp, "k":
+			cursor = max(cursor-1, 0)
+		case keyDown, "j":
+			cursor = min(cursor+1, len(tp)-1)
+		case keySpace:
+			picked[cursor] = !picked[cursor]
+		case keyEnter:
+			erase()
+			var keys []int
+			for i, t := range tp {
+				if picked[i] {
+					keys = append(keys, t.dbKey)
+				}
+			}
+			return keys, true, nil
+		case "q", keyEscape, keyInterrupt:
+			erase()
+			return nil, false, nil
+		}
+	}
+}
+
+// Read a line of text after `prompt` on `out`, echoing the keys typed since a terminal in raw
+// mode doesn't. Backspace deletes the last character. Returns false if the user gave up with
+// escape or Ctrl-C or the input ended
+func readLine(in *bufio.Reader, out io.Writer, prompt string) (string, bool, error) {
+	fmt.Fprint(out, prompt)
+	var line []rune
+	for {
+		key, err := readKey(in)
+		if err == io.EOF {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		switch key {
+		case keyEnter:
+			return string(line), true, nil
+		case keyEscape, keyInterrupt:
+			return "", false, nil
+		case keyBackspace:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				fmt.Fprint(out, "\b \b")
+			}
+		case keySpace:
+			line = append(line, ' ')
+			fmt.Fprint(out, " ")
+		default:
+			// Other special keys are ignored
+			if r := []rune(key); len(r) == 1 {
+				line = append(line, r[0])
+				fmt.Fprint(out, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Returns the other processes that have the file at `path` open, formatted as "1234 (name)".
+// This is best effort: /proc is searched on Linux and lsof is used on other Unix systems.
+// Returns nil if the processes can't be determined, e.g. on Windows
+func lockHolders(path string) []string {
+	var pids []int
+	switch runtime.GOOS {
+	case "linux":
+		pids = procHolders(path)
+	case "windows":
+		return nil
+	default:
+		pids = lsofHolders(path)
+	}
+
+	var holders []string
+	for _, pid := range pids {
+		if pid == os.Getpid() {
+			continue
+		}
+		holders = append(holders, fmt.Sprintf("%d (%s)", pid, processName(pid)))
+	}
+	return holders
+}
+
+// Search the open file descriptors of every process in /proc for `path`
+func procHolders(path string) []int {
+	var pids []int
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		target, err := os.Readlink(fd)
+		if err != nil || target != path {
+			continue
+		}
+		// "/proc/1234/fd/5" -> 1234
+		pid, err := strconv.Atoi(strings.Split(fd, "/")[2])
+		if err == nil && (len(pids) == 0 || pids[len(pids)-1] != pid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// Ask lsof for the processes that have `path` open
+func lsofHolders(path string) []int {
+	var pids []int
+	buf, _ := exec.Command("lsof", "-t", "--", path).Output()
+	for _, field := range strings.Fields(string(buf)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// Returns the command name of the process with ID `pid`, or "unknown"
+func processName(pid int) string {
+	if buf, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+		return strings.TrimSpace(string(buf))
+	}
+	if buf, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "comm=").Output(); err == nil && len(buf) > 0 {
+		return filepath.Base(strings.TrimSpace(string(buf)))
+	}
+	return "unknown"
+}
@@ -1,48 +1,108 @@
 package main
 
 import (
+	"io"
 	"os"
 
-	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
 )
 
 func main() {
+	// hand the command over to a running daemon, which already has the db open
+	if code, ok := delegate(os.Args[1:], os.Stdin, os.Stdout); ok {
+		os.Exit(code)
+	}
+
 	// Create a new connection manager to manage the db instance
-	mgr, err := newBoltManager()
-	check(err)
+	mgr := newBoltManager()
 	defer mgr.Close()
 
-	// initialize buckets
-	mgr.Database().Update(func(tx *bolt.Tx) error {
-		tx.CreateBucketIfNotExists(TASKS_BUCKET)
-		tx.CreateBucketIfNotExists(ARCHIVE_BUCKET)
-		return nil
-	})
-
-	// create sub commands
-	osOut := os.Stdout
-	addCmd := newAddCmd(mgr, osOut)
-	doCmd := newDoCmd(mgr, osOut)
-	updateCmd := newUpdateCmd(mgr, osOut)
-	listCmd := newListCmd(mgr, osOut)
-	finishCmd := newFinishCmd(mgr, osOut)
-	clearCmd := newClearCmd(mgr, osOut)
-	archiveCmd := newArchiveCmd(mgr, osOut)
-	deleteCmd := newDeleteCmd(mgr, osOut)
-	statsCmd := newStatsCmd(mgr, osOut)
-	countCmd := newCountCmd(mgr, osOut)
-	tagsCmd := newTagsCmd(mgr, osOut)
+	// connect to the db and initialize buckets before running any command
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return openDatabase(mgr, cmd, args)
+	}
 
 	// add sub commands
-	rootCmd.AddCommand(
+	rootCmd.AddCommand(newSubcommands(mgr, os.Stdout)...)
+	rootCmd.AddCommand(newDaemonCmd(mgr))
+
+	// initialize cobra
+	Execute()
+}
+
+// Create the sub commands, writing their output to `out`
+func newSubcommands(mgr *connectionManager, out io.Writer) []*cobra.Command {
+	addCmd := newAddCmd(mgr, out)
+	doCmd := newDoCmd(mgr, out)
+	updateCmd := newUpdateCmd(mgr, out)
+	statusCmd := newStatusCmd(mgr, out)
+	startCmd := newStartCmd(mgr, out)
+	stopCmd := newStopCmd(mgr, out)
+	blockCmd := newBlockCmd(mgr, out)
+	cancelCmd := newCancelCmd(mgr, out)
+	appendCmd := newAppendCmd(mgr, out)
+	prependCmd := newPrependCmd(mgr, out)
+	listCmd := newListCmd(mgr, out)
+	finishCmd := newFinishCmd(mgr, out)
+	clearCmd := newClearCmd(mgr, out)
+	purgeCmd := newPurgeCmd(mgr, out)
+	moveUpCmd := newMoveUpCmd(mgr, out)
+	moveDownCmd := newMoveDownCmd(mgr, out)
+	renumberCmd := newRenumberCmd(mgr, out)
+	dupCmd := newDupCmd(mgr, out)
+	showCmd := newShowCmd(mgr, out)
+	openCmd := newOpenCmd(mgr, out)
+	attachCmd := newAttachCmd(mgr, out)
+	commentCmd := newCommentCmd(mgr, out)
+	archiveCmd := newArchiveCmd(mgr, out)
+	deleteCmd := newDeleteCmd(mgr, out)
+	statsCmd := newStatsCmd(mgr, out)
+	reportCmd := newReportCmd(mgr, out)
+	forecastCmd := newForecastCmd(mgr, out)
+	countCmd := newCountCmd(mgr, out)
+	tagsCmd := newTagsCmd(mgr, out)
+	viewCmd := newViewCmd(mgr, out)
+	summaryCmd := newSummaryCmd(mgr, out)
+	matrixCmd := newMatrixCmd(mgr, out)
+	timewCmd := newTimewCmd(mgr, out)
+	importCmd := newImportCmd(mgr, out)
+	syncCmd := newSyncCmd(mgr, out)
+	remindCmd := newRemindCmd(mgr, out)
+	backupCmd := newBackupCmd(mgr, out)
+	statuslineCmd := newStatuslineCmd(mgr, out)
+	promptCmd := newPromptCmd(mgr, out)
+	apiCmd := newAPICmd(mgr, out)
+	exportCmd := newExportCmd(mgr, out)
+	dbCmd := newDBCmd(mgr, out)
+	configCmd := newConfigCmd(mgr, out)
+	sortCmd := newSortCmd(mgr, out)
+	editCmd := newEditCmd(mgr, out)
+	batchCmd := newBatchCmd(mgr, out)
+
+	return []*cobra.Command{
 		addCmd, doCmd,
-		updateCmd, listCmd,
+		updateCmd, statusCmd,
+		startCmd, stopCmd,
+		blockCmd, cancelCmd,
+		listCmd,
+		appendCmd, prependCmd,
 		finishCmd, clearCmd,
+		purgeCmd, moveUpCmd,
+		moveDownCmd, renumberCmd,
+		dupCmd,
+		showCmd, openCmd,
+		attachCmd, commentCmd,
 		archiveCmd, deleteCmd,
 		countCmd, tagsCmd,
-		statsCmd,
-	)
-
-	// initialize cobra
-	Execute()
+		statsCmd, reportCmd,
+		forecastCmd, viewCmd,
+		summaryCmd, matrixCmd,
+		timewCmd, importCmd,
+		syncCmd, remindCmd,
+		backupCmd, statuslineCmd,
+		promptCmd, apiCmd,
+		exportCmd, dbCmd,
+		configCmd, sortCmd,
+		editCmd, batchCmd,
+	}
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// The four quadrants of the Eisenhower matrix, in the order they are printed: top left, top
+// right, bottom left and bottom right
+const (
+	DO_FIRST = iota
+	SCHEDULE
+	DELEGATE
+	ELIMINATE
+)
+
+func newMatrixCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	mCmd := &cobra.Command{
+		Use:          "matrix -[d]",
+		Short:        tr("Arrange your open tasks in an urgent/important grid"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if MatrixDays < 0 {
+				return errors.New(tr("The number of days must be at least 0"))
+			}
+			quadrants := eisenhower(getTasks(mgr.db, TASKS_BUCKET), MatrixDays, time.Now())
+			fmt.Fprintln(out, formatMatrix(quadrants, terminalWidth()))
+			return nil
+		},
+	}
+	mCmd.Flags().IntVarP(&MatrixDays, "days", "d", 2, "Tasks due within this many days, or overdue, are urgent")
+	return mCmd
+}
+
+// Sort the open tasks in `tp` into the quadrants of the Eisenhower matrix, indexed by DO_FIRST,
+// SCHEDULE, DELEGATE and ELIMINATE. Tasks with a high or medium priority are important, tasks
+// that are overdue or due within `days` days of `now` are urgent
+func eisenhower(tp []TaskPosition, days int, now time.Time) [4][]TaskPosition {
+	var quadrants [4][]TaskPosition
+	y, m, d := now.Date()
+	cutoff := time.Date(y, m, d+days+1, 0, 0, 0, 0, now.Location())
+	for _, t := range tp {
+		if isDone(t.task) {
+			continue
+		}
+		important := t.task.Priority == "high" || t.task.Priority == "med"
+		urgent := false
+		if due, err := parseTimestamp(t.task.Due); err == nil {
+			y, m, d := due.Date()
+			urgent = time.Date(y, m, d, 0, 0, 0, 0, now.Location()).Before(cutoff)
+		}
+		switch {
+		case urgent && important:
+			quadrants[DO_FIRST] = append(quadrants[DO_FIRST], t)
+		case important:
+			quadrants[SCHEDULE] = append(quadrants[SCHEDULE], t)
+		case urgent:
+			quadrants[DELEGATE] = append(quadrants[DELEGATE], t)
+		default:
+			quadrants[ELIMINATE] = append(quadrants[ELIMINATE], t)
+		}
+	}
+	return quadrants
+}
+
+// Draw the `quadrants` as a 2x2 grid fitting `width` columns, or 80 columns if `width` is 0.
+// Descriptions too long for their cell are cut short
+func formatMatrix(quadrants [4][]TaskPosition, width int) string {
+	if width <= 0 {
+		width = 80
+	}
+	// Two cells, each with a space on either side, between three borders
+	cell := max((width-7)/2, 20)
+	titles := [4]string{
+		tr("Do first: urgent and important"),
+		tr("Schedule: important"),
+		tr("Delegate: urgent"),
+		tr("Eliminate: neither"),
+	}
+
+	// A border with the titles of the quadrants below it
+	border := func(left, middle, right string, top, bottom int) string {
+		title := func(q int) string {
+			if q < 0 {
+				return strings.Repeat("─", cell+2)
+			}
+			s := truncateText(fmt.Sprintf(" %s (%d) ", titles[q], len(quadrants[q])), cell+1)
+			return "─" + s + strings.Repeat("─", cell+1-textWidth(s))
+		}
+		return left + title(top) + middle + title(bottom) + right
+	}
+	line := func(t []TaskPosition, i int) string {
+		if i >= len(t) {
+			return strings.Repeat(" ", cell)
+		}
+		desc, _, _ := strings.Cut(t[i].task.Desc, "\n")
+		return padRight(truncateText(fmt.Sprintf("%d: %s", t[i].dbKey, desc), cell), cell)
+	}
+	rows := func(left, right int) []string {
+		var lines []string
+		for i := 0; i < max(len(quadrants[left]), len(quadrants[right]), 1); i++ {
+			lines = append(lines, "│ "+line(quadrants[left], i)+" │ "+line(quadrants[right], i)+" │")
+		}
+		return lines
+	}
+
+	lines := []string{border("┌", "┬", "┐", DO_FIRST, SCHEDULE)}
+	lines = append(lines, rows(DO_FIRST, SCHEDULE)...)
+	lines = append(lines, border("├", "┼", "┤", DELEGATE, ELIMINATE))
+	lines = append(lines, rows(DELEGATE, ELIMINATE)...)
+	lines = append(lines, border("└", "┴", "┘", -1, -1))
+	return strings.Join(lines, "\n")
+}
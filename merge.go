@@ -0,0 +1,98 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// Merge two versions of the same task, edited apart on different machines, so no edit is lost.
+// Each field of historyFields takes the value of the version that changed it last according to
+// the task histories, so a description changed on one machine and a completion on the other
+// both survive. Comments, time intervals and history entries are the union of both versions.
+// The merge is deterministic: both machines end up with the same task whichever merges first.
+// Only the display order, which belongs to each database, is kept from `a`
+func mergeTasks(a, b Task) Task {
+	merged := snapshot(a)
+	for _, f := range historyFields {
+		ta, tb := lastChanged(a, f.name), lastChanged(b, f.name)
+		c := compareTimestamps(ta, tb)
+		// Versions changed at the same time, or never changed, settle on the greater value
+		if c == 0 {
+			c = strings.Compare(f.value(a), f.value(b))
+		}
+		if c < 0 {
+			f.copy(&merged, b)
+		}
+	}
+	if compareTimestamps(b.Created, a.Created) < 0 {
+		merged.Created = b.Created
+	}
+	merged.Comments = mergeComments(a.Comments, b.Comments)
+	merged.Intervals = mergeIntervals(a.Intervals, b.Intervals)
+	merged.History = mergeHistories(a.History, b.History)
+	return merged
+}
+
+// Returns when `field` of `t` last changed: the time of its latest change in the history, or
+// the creation time of the task if it never changed
+func lastChanged(t Task, field string) string {
+	last := t.Created
+	for _, c := range t.History {
+		if c.Field == field && compareTimestamps(c.Time, last) > 0 {
+			last = c.Time
+		}
+	}
+	return last
+}
+
+// Returns the comments of both `a` and `b` once each, oldest first
+func mergeComments(a, b []Comment) []Comment {
+	var merged []Comment
+	for _, c := range append(slices.Clone(a), b...) {
+		if !slices.Contains(merged, c) {
+			merged = append(merged, c)
+		}
+	}
+	slices.SortStableFunc(merged, func(x, y Comment) int {
+		if c := compareTimestamps(x.Time, y.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Text, y.Text)
+	})
+	return merged
+}
+
+// Returns the intervals of both `a` and `b` once each by start, oldest first. An interval
+// still open in one version and closed in the other is closed
+func mergeIntervals(a, b []Interval) []Interval {
+	var merged []Interval
+	for _, in := range append(slices.Clone(a), b...) {
+		i := slices.IndexFunc(merged, func(m Interval) bool { return m.Start == in.Start })
+		if i < 0 {
+			merged = append(merged, in)
+		} else if merged[i].End == "" || (in.End != "" && compareTimestamps(in.End, merged[i].End) < 0) {
+			merged[i].End = in.End
+		}
+	}
+	slices.SortStableFunc(merged, func(x, y Interval) int {
+		return compareTimestamps(x.Start, y.Start)
+	})
+	return merged
+}
+
+// Returns the changes of both `a` and `b` once each, oldest first
+func mergeHistories(a, b []Change) []Change {
+	var merged []Change
+	for _, c := range append(slices.Clone(a), b...) {
+		if !slices.Contains(merged, c) {
+			merged = append(merged, c)
+		}
+	}
+	slices.SortStableFunc(merged, func(x, y Change) int {
+		if c := compareTimestamps(x.Time, y.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Field+"\x00"+x.Old+"\x00"+x.New, y.Field+"\x00"+y.Old+"\x00"+y.New)
+	})
+	return merged
+}
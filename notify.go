@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Show a desktop notification with `title` and `message` using the notification system of
+// the OS: Notification Center on macOS, toasts on Windows and notify-send elsewhere.
+// A variable so tests can avoid showing notifications
+var notify = func(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// Passed as arguments so the text needs no AppleScript escaping
+		cmd = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, message))
+	default:
+		cmd = exec.Command("notify-send", "--app-name", "task", title, message)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return errors.New(s)
+		}
+		return err
+	}
+	return nil
+}
+
+// ID PowerShell's own toasts are shown under, since toasts need an app registered with
+// Windows and task isn't installed as one
+const powershellAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// Returns a PowerShell script showing a toast notification with `title` and `message`
+func windowsToastScript(title, message string) string {
+	var text bytes.Buffer
+	for _, s := range []string{title, message} {
+		text.WriteString("<text>")
+		xml.EscapeText(&text, []byte(s))
+		text.WriteString("</text>")
+	}
+	toast := `<toast><visual><binding template="ToastGeneric">` + text.String() + `</binding></visual></toast>`
+	// Single quoted PowerShell strings only need their quotes doubled
+	quote := func(s string) string {
+		var b strings.Builder
+		b.WriteString("'")
+		for _, r := range s {
+			// PowerShell also ends strings with the typographic single quotes ‘ ’ ‚ ‛
+			if strings.ContainsRune("'\u2018\u2019\u201a\u201b", r) {
+				b.WriteRune(r)
+			}
+			b.WriteRune(r)
+		}
+		b.WriteString("'")
+		return b.String()
+	}
+	return strings.Join([]string{
+		"$ErrorActionPreference = 'Stop'",
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null",
+		"[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null",
+		"$xml = New-Object Windows.Data.Xml.Dom.XmlDocument",
+		"$xml.LoadXml(" + quote(toast) + ")",
+		"$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(" + quote(powershellAppID) + ").Show($toast)",
+	}, "\n")
+}
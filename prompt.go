@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// What `prompt` prints about the task list
+type PromptInfo struct {
+	Open    int `json:"open"`
+	Overdue int `json:"overdue"`
+	// Description of the task in progress, the first one if several are. Empty if none is
+	Active string `json:"active"`
+}
+
+// Widest description of the task in progress `prompt` prints, longer ones are truncated
+const promptActiveWidth = 20
+
+func newPromptCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	pCmd := &cobra.Command{
+		Use:          "prompt -[j]",
+		Short:        tr("Print the number of open and overdue tasks and the task in progress, for shell prompts"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := promptInfo(getTasks(mgr.db, TASKS_BUCKET), time.Now())
+			if PromptJSON {
+				buf, err := json.Marshal(p)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(out, string(buf))
+				return nil
+			}
+			if s := formatPrompt(p); s != "" {
+				fmt.Fprintln(out, s)
+			}
+			return nil
+		},
+	}
+	pCmd.Flags().BoolVarP(&PromptJSON, "json", "j", false, "Print the counts and the task in progress as JSON")
+	return pCmd
+}
+
+// Count the open and overdue tasks in `tp` as of `now`, and find the task in progress
+func promptInfo(tp []TaskPosition, now time.Time) PromptInfo {
+	var p PromptInfo
+	for _, t := range tp {
+		if isDone(t.task) {
+			continue
+		}
+		p.Open++
+		if isOverdue(t.task, now) {
+			p.Overdue++
+		}
+		if p.Active == "" && t.task.Status == STATUS.IN_PROGRESS {
+			p.Active, _, _ = strings.Cut(t.task.Desc, "\n")
+		}
+	}
+	return p
+}
+
+// Format `p` as a prompt segment such as `7 !1 ▶ write docs`: the open tasks, the overdue
+// tasks and the task in progress, each left out when there's none. Empty without open tasks,
+// so the segment disappears
+func formatPrompt(p PromptInfo) string {
+	if p.Open == 0 {
+		return ""
+	}
+	parts := []string{strconv.Itoa(p.Open)}
+	if p.Overdue > 0 {
+		parts = append(parts, "!"+strconv.Itoa(p.Overdue))
+	}
+	if p.Active != "" {
+		parts = append(parts, "▶ "+truncateText(p.Active, promptActiveWidth))
+	}
+	return strings.Join(parts, " ")
+}
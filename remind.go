@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+func newRemindCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	rCmd := &cobra.Command{
+		Use:          "remind -[lio]",
+		Short:        tr("Show a desktop notification when a task is due, until interrupted"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if RemindInterval <= 0 {
+				return fmt.Errorf(tr("Invalid interval %s, must be positive"), RemindInterval)
+			}
+			dir, err := taskDir()
+			if err != nil {
+				return err
+			}
+			// The daemon runs remind with its db open
+			if mgr.db != nil {
+				return remindDue(mgr.db, dir, time.Now(), out)
+			}
+			if RemindOnce {
+				return checkDue(mgr, dir, out)
+			}
+
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(sig)
+			ticker := time.NewTicker(RemindInterval)
+			defer ticker.Stop()
+			for {
+				if err := checkDue(mgr, dir, out); err != nil {
+					fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+				}
+
+				select {
+				case <-sig:
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	rCmd.Flags().DurationVarP(&RemindLead, "lead", "l", 0, "How long before the day a task is due to alert, e.g. 24h to alert the day before")
+	rCmd.Flags().DurationVarP(&RemindInterval, "interval", "i", time.Minute, "How often to check for due tasks")
+	rCmd.Flags().BoolVarP(&RemindOnce, "once", "o", false, "Check once and exit, for cron jobs and systemd timers")
+	return rCmd
+}
+
+// Check once for due tasks, see remindDue. A running daemon holds the db, so it checks in
+// place of this process. Otherwise the db is only opened while checking, so other commands
+// can run in between, and the weekly report is emailed on its schedule as the daemon would
+func checkDue(mgr *connectionManager, dir string, out io.Writer) error {
+	if code, ok := runOnDaemon([]string{"remind", "--once", "--lead", RemindLead.String()}, nil, out); ok {
+		if code != 0 {
+			return errors.New(tr("The daemon couldn't check for due tasks"))
+		}
+		return nil
+	}
+	// Nothing is due before the db is created
+	if _, err := os.Stat(dbPath(dir)); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := mgr.Open(true); err != nil {
+		return err
+	}
+	defer mgr.Close()
+	if err := remindDue(mgr.db, dir, time.Now(), out); err != nil {
+		return err
+	}
+	return sendScheduledReport(mgr.db, dir, time.Now(), out)
+}
+
+// Show a notification for each task in `db` due as of `now` that wasn't alerted yet, printing
+// it to `out`. The alerted tasks are recorded in `dir`, so each task is alerted once per due
+// date even across runs. Returns the last notification error, after trying every task
+func remindDue(db *bolt.DB, dir string, now time.Time, out io.Writer) error {
+	alerted, err := loadAlerted(dir)
+	if err != nil {
+		return err
+	}
+
+	// Only tasks still due are kept, so the record doesn't grow
+	keep := map[string]bool{}
+	var notifyErr error
+	for _, t := range dueAlerts(getTasks(db, TASKS_BUCKET), RemindLead, now) {
+		key := t.task.UUID + " " + t.task.Due
+		if alerted[key] {
+			keep[key] = true
+			continue
+		}
+		title, message := alertText(t.task, now)
+		if err := notify(title, message); err != nil {
+			notifyErr = err
+			continue
+		}
+		keep[key] = true
+		fmt.Fprintf(out, "%s: %s\n", title, message)
+	}
+
+	if !maps.Equal(alerted, keep) {
+		if err := saveAlerted(dir, keep); err != nil {
+			return err
+		}
+	}
+	return notifyErr
+}
+
+// Returns the path of the file recording the tasks `remind` alerted
+func alertedPath(dir string) string {
+	return filepath.Join(dir, "reminded.json")
+}
+
+// Read the tasks `remind` alerted from `dir`, keyed by UUID and due date. Empty if nothing was
+// alerted yet
+func loadAlerted(dir string) (map[string]bool, error) {
+	alerted := map[string]bool{}
+	buf, err := os.ReadFile(alertedPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return alerted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := json.Unmarshal(buf, &keys); err != nil {
+		return nil, fmt.Errorf(tr("Invalid %s: %v"), alertedPath(dir), err)
+	}
+	for _, k := range keys {
+		alerted[k] = true
+	}
+	return alerted, nil
+}
+
+// Record the `alerted` tasks in `dir`, see loadAlerted
+func saveAlerted(dir string, alerted map[string]bool) error {
+	keys := []string{}
+	for k := range alerted {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	buf, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(alertedPath(dir), append(buf, '\n'), 0600)
+}
+
+// Returns the open tasks in `tp` that are due, or will be within `lead` of `now`. Tasks are
+// due from the start of their due day, overdue tasks included
+func dueAlerts(tp []TaskPosition, lead time.Duration, now time.Time) []TaskPosition {
+	var due []TaskPosition
+	for _, t := range tp {
+		if t.task.Due == "" || isDone(t.task) {
+			continue
+		}
+		d, err := parseTimestamp(t.task.Due)
+		if err != nil {
+			continue
+		}
+		y, m, dd := d.Date()
+		start := time.Date(y, m, dd, 0, 0, 0, 0, now.Location())
+		if !now.Add(lead).Before(start) {
+			due = append(due, t)
+		}
+	}
+	return due
+}
+
+// Returns the title and message of the notification for `t`, due as of `now`
+func alertText(t Task, now time.Time) (string, string) {
+	desc, _, _ := strings.Cut(t.Desc, "\n")
+	switch dueSection(t, now) {
+	case 0:
+		return tr("Overdue task"), desc
+	case 1:
+		return tr("Task due today"), desc
+	}
+	return fmt.Sprintf(tr("Task due %s"), formatDateStamp(t.Due, false)), desc
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// A reminder in the macOS Reminders app
+type Reminder struct {
+	ID   string
+	Name string
+	// Notes of the reminder. Reminders mirroring a task hold a `task:<uuid>` line
+	Body      string
+	Completed bool
+	// RFC3339, empty without a due date
+	Due string
+}
+
+// Changes `sync reminders` makes to the task list and to the Reminders list
+type ReminderSync struct {
+	// Tasks for reminders added in the Reminders app, e.g. with Siri
+	NewTasks []Task
+	// Keys of the tasks completed in the Reminders app
+	Complete []int
+	// Reminders for new tasks
+	Create []Reminder
+	// Reminders whose task changed, including reminders linked to a new task
+	Update []Reminder
+	// IDs of the reminders whose task was deleted
+	Delete []string
+}
+
+// Matches the line linking a reminder to the task it mirrors, capturing the task's UUID
+var reminderTaskLine = regexp.MustCompile(`(?m)^task:([0-9a-f-]+)$`)
+
+// Run a JavaScript for Automation script with osascript, returning its output.
+// A variable so tests can avoid depending on macOS
+var runJXA = func(script string, args ...string) ([]byte, error) {
+	cmd := exec.Command("osascript", append([]string{"-l", "JavaScript", "-e", script}, args...)...)
+	buf, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return buf, err
+}
+
+// Prints the reminders in the list named by the first argument as JSON, creating the list
+// if it doesn't exist
+const readRemindersScript = `function run(argv) {
+	const app = Application("Reminders");
+	let list = app.lists.whose({name: argv[0]})[0];
+	if (!list.exists()) {
+		list = app.List({name: argv[0]});
+		app.lists.push(list);
+	}
+	const r = list.reminders;
+	const ids = r.id(), names = r.name(), bodies = r.body(), completed = r.completed(), due = r.dueDate();
+	return JSON.stringify(ids.map((id, i) => ({
+		ID: id, Name: names[i], Body: bodies[i] || "", Completed: completed[i],
+		Due: due[i] ? due[i].toISOString() : "",
+	})));
+}`
+
+// Applies the ReminderSync given as JSON in the second argument to the list named by the first
+const writeRemindersScript = `function run(argv) {
+	const app = Application("Reminders");
+	const list = app.lists.whose({name: argv[0]})[0];
+	const sync = JSON.parse(argv[1]);
+	for (const r of sync.Create || []) {
+		const reminder = app.Reminder({name: r.Name, body: r.Body});
+		list.reminders.push(reminder);
+		if (r.Due) reminder.dueDate = new Date(r.Due);
+		if (r.Completed) reminder.completed = true;
+	}
+	for (const r of sync.Update || []) {
+		const reminder = list.reminders.byId(r.ID);
+		reminder.name = r.Name;
+		reminder.body = r.Body;
+		reminder.completed = r.Completed;
+		reminder.dueDate = r.Due ? new Date(r.Due) : null;
+	}
+	for (const id of sync.Delete || []) {
+		app.delete(list.reminders.byId(id));
+	}
+	return "";
+}`
+
+func newSyncCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	sCmd := &cobra.Command{
+		Use:   "sync",
+		Short: tr("Mirror your tasks to another app"),
+		Args:  cobra.NoArgs,
+	}
+	sCmd.AddCommand(newSyncRemindersCmd(mgr, out))
+	return sCmd
+}
+
+func newSyncRemindersCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	rCmd := &cobra.Command{
+		Use:          "reminders -[l]",
+		Short:        tr("Mirror your tasks to a list in the macOS Reminders app, both ways"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if runtime.GOOS != "darwin" {
+				return errors.New(tr("Syncing with Reminders needs macOS"))
+			}
+			buf, err := runJXA(readRemindersScript, SyncList)
+			if err != nil {
+				return fmt.Errorf(tr("Could not read the Reminders list: %v"), err)
+			}
+			var reminders []Reminder
+			if err := json.Unmarshal(buf, &reminders); err != nil {
+				return err
+			}
+
+			s := planReminderSync(getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET), reminders, time.Now())
+			// Change the task list first: if Reminders can't be updated, the next sync picks up
+			// where this one left off, while reminders linked to tasks that were never added
+			// would be deleted
+			if err := insertTasks(mgr.db, TASKS_BUCKET, s.NewTasks); err != nil {
+				return err
+			}
+			if len(s.Complete) > 0 {
+				if _, err := completeTasks(mgr.db, s.Complete, false); err != nil {
+					return err
+				}
+			}
+			changes, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			if _, err := runJXA(writeRemindersScript, SyncList, string(changes)); err != nil {
+				return fmt.Errorf(tr("Could not update the Reminders list: %v"), err)
+			}
+
+			fmt.Fprintf(out, tr("Added %d tasks and completed %d from Reminders\n"), len(s.NewTasks), len(s.Complete))
+			fmt.Fprintf(out, tr("Added %d reminders, updated %d and deleted %d in %s\n"), len(s.Create), len(s.Update), len(s.Delete), SyncList)
+			return nil
+		},
+	}
+	rCmd.Flags().StringVarP(&SyncList, "list", "l", "Tasks", "Name of the Reminders list, created if it doesn't exist")
+	return rCmd
+}
+
+// Work out how to bring the task list `tp`, its `archive` and the `reminders` in sync as of
+// `now`. Open tasks get a reminder and reminders added in the app become tasks. Reminders
+// completed in the app complete their task, otherwise the task's description, due date and
+// status are copied to its reminder. Reminders of deleted tasks are deleted
+func planReminderSync(tp, archive []TaskPosition, reminders []Reminder, now time.Time) ReminderSync {
+	var s ReminderSync
+	tasks := map[string]TaskPosition{}
+	for _, t := range tp {
+		tasks[t.task.UUID] = t
+	}
+	archived := map[string]bool{}
+	for _, t := range archive {
+		archived[t.task.UUID] = true
+	}
+
+	linked := map[string]bool{}
+	for _, r := range reminders {
+		m := reminderTaskLine.FindStringSubmatch(r.Body)
+		if m == nil {
+			// Added in the Reminders app, completed reminders are left alone
+			if r.Completed {
+				continue
+			}
+			t := newTask(r.Name, nil)
+			t.Created = timestamp(now)
+			t.UUID = newUUID()
+			if due, err := time.Parse(time.RFC3339, r.Due); err == nil {
+				t.Due = due.Format(RFC3339)
+			}
+			s.NewTasks = append(s.NewTasks, t)
+			r.Body = strings.TrimSpace(r.Body + "\ntask:" + t.UUID)
+			s.Update = append(s.Update, r)
+			continue
+		}
+
+		uuid := m[1]
+		linked[uuid] = true
+		t, ok := tasks[uuid]
+		switch {
+		case ok && r.Completed && !isDone(t.task):
+			s.Complete = append(s.Complete, t.dbKey)
+		case ok:
+			if want := taskReminder(t.task, r); want != r {
+				s.Update = append(s.Update, want)
+			}
+		case archived[uuid]:
+			// Archived tasks are done
+			if !r.Completed {
+				r.Completed = true
+				s.Update = append(s.Update, r)
+			}
+		default:
+			s.Delete = append(s.Delete, r.ID)
+		}
+	}
+
+	for _, t := range tp {
+		if !linked[t.task.UUID] && !isDone(t.task) && t.task.UUID != "" {
+			s.Create = append(s.Create, taskReminder(t.task, Reminder{}))
+		}
+	}
+	return s
+}
+
+// Returns reminder `r` updated to mirror `t`, clearing its due date if `t` has none
+func taskReminder(t Task, r Reminder) Reminder {
+	r.Name, _, _ = strings.Cut(t.Desc, "\n")
+	r.Completed = isDone(t)
+	if r.Body == "" {
+		r.Body = "task:" + t.UUID
+	}
+	if t.Due == "" {
+		r.Due = ""
+	} else if due, err := parseTimestamp(t.Due); err == nil {
+		if current, err := time.Parse(time.RFC3339, r.Due); err != nil || !current.Equal(due) {
+			r.Due = due.UTC().Format(time.RFC3339)
+		}
+	}
+	return r
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// A report defined in the reports section of the config file, printed by `task report <name>`
+type CustomReport struct {
+	// Printed when listing the reports
+	Description string `json:"description,omitempty"`
+	// Filter terms selecting the tasks in the report, such as "+work status:blocked". Every
+	// task is included if empty
+	Filter string `json:"filter,omitempty"`
+	// Columns to print, in order, from REPORT_COLUMNS. Defaults to id, status and description
+	Columns []string `json:"columns,omitempty"`
+	// Field to sort by from REPORT_SORTS, prefixed with "-" for descending order. Tasks are in
+	// ID order if empty
+	Sort string `json:"sort,omitempty"`
+	// Print tasks in sections, one of GROUPINGS
+	Group string `json:"group,omitempty"`
+}
+
+// Columns a report can have
+var REPORT_COLUMNS = []string{"id", "status", "description", "tags", "priority", "points", "assignee", "due", "created", "age", "hash"}
+
+// Fields a report can be sorted by
+var REPORT_SORTS = []string{"id", "status", "description", "priority", "points", "assignee", "due", "created"}
+
+// Names taken by the built in reports
+var builtinReports = []string{"monthly", "weekly"}
+
+// Returns an error if `r`, the report called `name`, refers to a column, sort order or grouping
+// that doesn't exist
+func (r CustomReport) validate(name string) error {
+	if slices.Contains(builtinReports, name) {
+		return fmt.Errorf(tr(`the "%s" report is built in`), name)
+	}
+	for _, term := range strings.Fields(r.Filter) {
+		if !isFilterTerm(term) {
+			return fmt.Errorf(tr(`report "%s": "%s" isn't a filter term`), name, term)
+		}
+	}
+	for _, c := range r.Columns {
+		if !slices.Contains(REPORT_COLUMNS, c) {
+			return fmt.Errorf(tr(`report "%s": unknown column "%s", must be one of %s`), name, c, strings.Join(REPORT_COLUMNS, ", "))
+		}
+	}
+	if s := strings.TrimPrefix(r.Sort, "-"); s != "" && !slices.Contains(REPORT_SORTS, s) {
+		return fmt.Errorf(tr(`report "%s": can't sort by "%s", must be one of %s`), name, s, strings.Join(REPORT_SORTS, ", "))
+	}
+	if r.Group != "" && !slices.Contains(GROUPINGS, r.Group) {
+		return fmt.Errorf(tr(`report "%s": invalid grouping "%s", must be one of %s`), name, r.Group, strings.Join(GROUPINGS, ", "))
+	}
+	return nil
+}
+
+// Select, sort and group the tasks in `tp` as `r` defines. Without a grouping a single group
+// with no name is returned. `now` is used for relative dates in the filter and due groups
+func runReport(r CustomReport, tp []TaskPosition, now time.Time) ([]TaskGroup, error) {
+	filter, err := parseFilter(strings.Fields(r.Filter), now)
+	if err != nil {
+		return nil, err
+	}
+	tasks := filter.Apply(tp)
+
+	by, descending := strings.CutPrefix(r.Sort, "-")
+	slices.SortStableFunc(tasks, func(a, b TaskPosition) int {
+		c := compareTasks(a, b, by)
+		if descending {
+			return -c
+		}
+		return c
+	})
+
+	if r.Group == "" {
+		return []TaskGroup{{Tasks: tasks}}, nil
+	}
+	return groupTasks(tasks, r.Group, now), nil
+}
+
+// Compare `a` and `b` by the field `by`, one of REPORT_SORTS. Tasks without a priority, assignee
+// or due date sort after the others
+func compareTasks(a, b TaskPosition, by string) int {
+	switch by {
+	case "status":
+		index := func(t Task) int {
+			return slices.IndexFunc(config.Statuses, func(s Status) bool { return s.Name == t.Status })
+		}
+		return cmp.Compare(index(a.task), index(b.task))
+	case "description":
+		return cmp.Compare(strings.ToLower(a.task.Desc), strings.ToLower(b.task.Desc))
+	case "priority":
+		rank := func(t Task) int {
+			if i := slices.Index(PRIORITIES, t.Priority); i >= 0 {
+				return i
+			}
+			return len(PRIORITIES)
+		}
+		return cmp.Compare(rank(a.task), rank(b.task))
+	case "points":
+		return cmp.Compare(a.task.Points, b.task.Points)
+	case "assignee":
+		switch {
+		case a.task.Assignee == b.task.Assignee:
+			return 0
+		case a.task.Assignee == "":
+			return 1
+		case b.task.Assignee == "":
+			return -1
+		}
+		return cmp.Compare(strings.ToLower(a.task.Assignee), strings.ToLower(b.task.Assignee))
+	case "due":
+		switch {
+		case a.task.Due == b.task.Due:
+			return 0
+		case a.task.Due == "":
+			return 1
+		case b.task.Due == "":
+			return -1
+		}
+		return compareTimestamps(a.task.Due, b.task.Due)
+	case "created":
+		return compareTimestamps(a.task.Created, b.task.Created)
+	}
+	return cmp.Compare(a.dbKey, b.dbKey)
+}
+
+// Compare two RFC3339 timestamps in time order
+func compareTimestamps(a, b string) int {
+	ta, _ := parseTimestamp(a)
+	tb, _ := parseTimestamp(b)
+	return ta.Compare(tb)
+}
+
+// Returns the heading of `column`, one of REPORT_COLUMNS
+func columnHeading(column string) string {
+	return map[string]string{
+		"id":          tr("ID"),
+		"status":      tr("Status"),
+		"description": tr("Description"),
+		"tags":        tr("Tags"),
+		"priority":    tr("Priority"),
+		"points":      tr("Points"),
+		"assignee":    tr("Assignee"),
+		"due":         tr("Due"),
+		"created":     tr("Created"),
+		"age":         tr("Age"),
+		"hash":        tr("Hash"),
+	}[column]
+}
+
+// Returns the value of `column` for `t`
+func columnValue(t TaskPosition, column string, now time.Time) string {
+	switch column {
+	case "id":
+		return strconv.Itoa(t.dbKey)
+	case "status":
+		return statusIcon(t.task) + " " + t.task.Status
+	case "description":
+		// Multi-line descriptions would break the table
+		desc, _, _ := strings.Cut(t.task.Desc, "\n")
+		return desc + blockedNote(t.task)
+	case "tags":
+		return strings.Join(t.task.Tags, ",")
+	case "priority":
+		return t.task.Priority
+	case "points":
+		if t.task.Points == 0 {
+			return ""
+		}
+		return strconv.Itoa(t.task.Points)
+	case "assignee":
+		return t.task.Assignee
+	case "due":
+		if t.task.Due == "" {
+			return ""
+		}
+		return formatWhen(t.task.Due, false)
+	case "created":
+		return formatWhen(t.task.Created, false)
+	case "age":
+		return strings.TrimSpace(formatAge(t.task, now))
+	case "hash":
+		return shortHash(t.task)
+	}
+	return ""
+}
+
+// Format `groups` as tables with the headings of `columns`. Groups with a name get a heading
+// with their number of tasks. Columns line up across groups
+func formatReportTable(groups []TaskGroup, columns []string, now time.Time) string {
+	if len(columns) == 0 {
+		columns = []string{"id", "status", "description"}
+	}
+	widths := make([]int, len(columns))
+	for i, c := range columns {
+		widths[i] = textWidth(columnHeading(c))
+	}
+	for _, g := range groups {
+		for _, t := range g.Tasks {
+			for i, c := range columns {
+				widths[i] = max(widths[i], textWidth(columnValue(t, c, now)))
+			}
+		}
+	}
+	row := func(values []string) string {
+		var cells []string
+		for i, v := range values {
+			if i < len(values)-1 {
+				v = padRight(v, widths[i])
+			}
+			cells = append(cells, v)
+		}
+		return strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+
+	var headings []string
+	for _, c := range columns {
+		headings = append(headings, columnHeading(c))
+	}
+	var sections []string
+	for _, g := range groups {
+		lines := []string{row(headings)}
+		if g.Name != "" {
+			lines = append([]string{fmt.Sprintf("%s (%d)", g.Name, len(g.Tasks))}, lines...)
+		}
+		for _, t := range g.Tasks {
+			var values []string
+			for _, c := range columns {
+				values = append(values, columnValue(t, c, now))
+			}
+			lines = append(lines, row(values))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// Returns the reports `cmd` can print, its built in subcommands first, one per line with
+// their description
+func formatReportList(cmd *cobra.Command, reports map[string]CustomReport) string {
+	var names, descriptions []string
+	for _, c := range cmd.Commands() {
+		if slices.Contains(builtinReports, c.Name()) {
+			names = append(names, c.Name())
+			descriptions = append(descriptions, c.Short)
+		}
+	}
+	var custom []string
+	for name := range reports {
+		custom = append(custom, name)
+	}
+	slices.Sort(custom)
+	for _, name := range custom {
+		names = append(names, name)
+		descriptions = append(descriptions, reports[name].Description)
+	}
+
+	width := 0
+	for _, name := range names {
+		width = max(width, textWidth(name))
+	}
+	var lines []string
+	for i, name := range names {
+		lines = append(lines, strings.TrimRight(padRight(name, width)+"  "+descriptions[i], " "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"slices"
 	"strconv"
 	"strings"
@@ -18,74 +25,166 @@ import (
 )
 
 // rootCmd represents the base command when called without any subcommands
-var rootCmd = &cobra.Command{
-	Use:   "task [command]",
-	Short: "A CLI for managing your TODOs",
-	// Long: ``
+var rootCmd = newRootCmd()
+
+// Create a base command with the flags shared by every subcommand. The daemon
+// builds a fresh one for each request it serves
+func newRootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task [command]",
+		Short: tr("A CLI for managing your TODOs"),
+		// Long: ``
+	}
+	cmd.PersistentFlags().BoolVar(&ReadOnly, "read-only", false, "Open the database read-only. Read-only commands can then run while other read-only commands are running")
+	cmd.PersistentFlags().BoolVar(&RelativeDates, "relative", false, "Show when tasks were created, completed or are due relative to now, such as 2d ago or in 3h")
+	cmd.PersistentFlags().BoolVar(&NoColor, "no-color", false, "Don't color the output. Output that isn't a terminal is never colored")
+	return cmd
 }
 
 // Subcommands
 func newAddCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
-	return &cobra.Command{
-		Use:   "add [task]",
-		Short: "Add a new task to your TODO list",
-		Run: func(cmd *cobra.Command, args []string) {
+	aCmd := &cobra.Command{
+		Use:          "add [task] -[dcep] [--parent taskID] [--points n] [--assign name]",
+		Short:        tr("Add a new task to your TODO list"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if AddFromClipboard {
+				clip, err := readClipboard()
+				if err != nil {
+					return fmt.Errorf(tr("Can't read the clipboard: %v"), err)
+				}
+				args = append(args, strings.TrimSpace(clip))
+			}
+			if AddWithEditor {
+				text, err := editText(strings.Join(args, " "))
+				if err != nil {
+					return err
+				}
+				args = []string{text}
+			}
 			tags, parsed := parseTags(strings.Join(args, " "))
 
 			if parsed == "" {
-				fmt.Fprintf(out, "Error: Empty task\n")
-				return
+				return errors.New(tr("Must provide a task description"))
 			}
 
-			var tag = ""
-			if len(tags) >= 1 {
-				// For now, only add the first tag to a task
-				tag = tags[0]
+			task := newTask(parsed, tags)
+			if DueDate != "" {
+				due, err := parseDueDate(DueDate)
+				if err != nil {
+					return err
+				}
+				task.Due = due.Format(RFC3339)
+			}
+			if AddPriority != "" {
+				priority, err := parsePriority(AddPriority)
+				if err != nil {
+					return err
+				}
+				task.Priority = priority
+			}
+			if AddPoints < 0 {
+				return errors.New(tr("Points can't be negative"))
+			}
+			task.Points = AddPoints
+			task.Assignee = parseAssignee(AddAssignee)
+			if AddParent != "" {
+				parent, err := findParent(mgr.db, AddParent)
+				if err != nil {
+					return err
+				}
+				task.Parent = parent
 			}
 
-			err := insert(mgr.db, TASKS_BUCKET, parsed, tag)
-			check(err)
-			fmt.Fprintf(out, "Added task: '%s'\n", parsed)
-
+			if err := insertTask(mgr.db, TASKS_BUCKET, task); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Added task: '%s'\n"), parsed)
+			return nil
 		},
 	}
+	aCmd.Flags().StringVarP(&DueDate, "due", "d", "", "Date the task is due on, e.g. 05/31/2025, tomorrow, friday or \"in 2 weeks\"")
+	aCmd.Flags().BoolVarP(&AddFromClipboard, "clipboard", "c", false, "Use the contents of the system clipboard as the task description. Any arguments, such as tags, are added before it")
+	aCmd.Flags().BoolVarP(&AddWithEditor, "edit", "e", false, "Write the task description in $EDITOR, allowing multiple lines")
+	aCmd.Flags().StringVarP(&AddPriority, "priority", "p", "", "Priority of the task: high, med or low")
+	aCmd.RegisterFlagCompletionFunc("priority", completePriorities)
+	aCmd.Flags().IntVar(&AddPoints, "points", 0, "Estimated effort of the task in points, e.g. 1, 2, 3, 5 or 8")
+	aCmd.Flags().StringVar(&AddParent, "parent", "", "ID of the task to add this task as a subtask of")
+	aCmd.Flags().StringVar(&AddAssignee, "assign", "", "Person the task is assigned to, e.g. alice")
+	return aCmd
 }
 
 func newDoCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 	doCmd := &cobra.Command{
-		Use:          "do [taskID]",
-		Short:        "Mark a task on your TODO list as complete",
-		SilenceUsage: true,
+		Use:               "do [taskID] -[fat]",
+		Short:             tr("Mark a task on your TODO list as complete"),
+		ValidArgsFunction: completeTaskIDs(mgr),
+		SilenceUsage:      true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			db := mgr.db
 			var keys []int
+			// do.auto_finish in the config file finishes tasks without -f, and -f=false doesn't
+			archive := DeleteOnDo
+			if !cmd.Flags().Changed("finish") {
+				archive = config.Do.AutoFinish
+			}
 
-			if len(args) == 0 {
-				return fmt.Errorf("Must provide a task ID")
+			if DoAll && DoTag != "" {
+				return errors.New(tr("Can't use the all flag in combination with the tag flag"))
+			}
+			if len(CommandFilter) > 0 {
+				if DoAll || DoTag != "" || len(args) > 0 {
+					return errors.New(tr("Can't use task IDs or the all or tag flags in combination with a filter"))
+				}
+				for _, t := range CommandFilter.Apply(getTasks(db, TASKS_BUCKET)) {
+					if !isDone(t.task) {
+						keys = append(keys, t.dbKey)
+					}
+				}
+				if len(keys) == 0 {
+					fmt.Fprintln(out, tr("No matching tasks to complete"))
+					return nil
+				}
+			} else if DoAll || DoTag != "" {
+				if len(args) > 0 {
+					return errors.New(tr("Can't use task IDs in combination with the all or tag flags"))
+				}
+				completed, err := completeMatching(db, strings.TrimPrefix(DoTag, "+"), archive)
+				if err != nil {
+					return err
+				}
+				if len(completed) == 0 {
+					fmt.Fprintln(out, tr("No matching tasks to complete"))
+					return nil
+				}
+				fmt.Fprintf(out, tr("Completed %d tasks\n"), len(completed))
+			} else if len(args) == 0 {
+				return errors.New(tr("Must provide a task ID"))
 			}
 			for _, v := range args {
-				id, err := strconv.Atoi(v)
+				id, err := parseTaskID(db, v)
 				if err != nil {
-					return fmt.Errorf(`Invalid task ID "%s"`, v)
+					return err
 				}
 				keys = append(keys, id)
-				er := completeTask(id, db)
-				if er != nil {
-					return er
-				}
-				fmt.Fprintf(out, "Completed task %d\n", id)
 			}
-			if DeleteOnDo {
-				// add the specified tasks to the archive ->
-				// remove _only_ the specified tasks from the
-				// tasks bucket
-				var tasks []Task
-				for _, k := range keys {
-					task, _ := getTask(db, k)
-					tasks = append(tasks, task)
+			if len(keys) > 0 {
+				// Complete, and with -f archive, every task in a single transaction so
+				// an invalid ID leaves all tasks untouched
+				completed, err := completeTasks(db, keys, archive)
+				if err != nil {
+					return err
+				}
+				for i, id := range keys {
+					if slices.Contains(keys[:i], id) {
+						continue
+					}
+					if slices.Contains(completed, id) {
+						fmt.Fprintf(out, tr("Completed task %d\n"), id)
+					} else {
+						fmt.Fprintf(out, tr("You already finished task %d\n"), id)
+					}
 				}
-				addToArchive(db, tasks)
-				deleteKeys(keys, db, TASKS_BUCKET)
 			}
 			fmt.Fprintln(out)
 			tp := getTasks(db, TASKS_BUCKET)
@@ -93,14 +192,17 @@ func newDoCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 			return nil
 		},
 	}
-	doCmd.Flags().BoolVarP(&DeleteOnDo, "finish", "f", false, "Complete and finish the specified tasks")
+	doCmd.Flags().BoolVarP(&DeleteOnDo, "finish", "f", false, "Complete and finish the specified tasks. Use -f=false to keep them in the list when do.auto_finish is set")
+	doCmd.Flags().BoolVarP(&DoAll, "all", "a", false, "Complete every task")
+	doCmd.Flags().StringVarP(&DoTag, "tag", "t", "", "Complete every task carrying the tag")
 	return doCmd
 }
 
 func newUpdateCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "update [taskID] [-ds]",
-		Short: "Update a task",
+		Use:               "update [taskID...] [-dstup] [--due date] [--no-due] [--parent taskID] [--points n] [--assign name]",
+		Short:             tr("Update a task"),
+		ValidArgsFunction: completeTaskIDs(mgr),
 		// SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Setting this to true at the start of the RunE instead of the cmd itself
@@ -109,61 +211,138 @@ func newUpdateCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 
 			db := mgr.db
 
-			// Make sure exactly 1 argument is passed in
-			if len(args) != 1 {
-				return errors.New("Must specify a single task to update")
-			}
-
-			// Make sure the argument is an int
-			id, err := strconv.Atoi(args[0])
-			if err != nil {
-				return fmt.Errorf("Argument should be an integer\n\"%s\" is not an integer", args[0])
+			// Update the tasks matching the filter, or else the tasks passed in
+			var ids []int
+			if len(CommandFilter) > 0 {
+				var err error
+				if ids, err = parseTaskIDs(db, args); err != nil {
+					return err
+				}
+			} else if len(args) == 0 {
+				return errors.New(tr("Must specify a task to update"))
 			}
 
-			// Make sure the input number is a valid taskID
+			// Make sure the arguments are valid taskIDs
 			taskCount := getCount(db, TASKS_BUCKET)
-			if id > taskCount || id == 0 {
-				return (fmt.Errorf("Invalid task ID, %d tasks exist", taskCount))
+			for _, arg := range args {
+				id, err := parseTaskID(db, arg)
+				if err != nil {
+					return err
+				}
+				if id > taskCount || id == 0 {
+					return (fmt.Errorf(tr("Invalid task ID, %d tasks exist"), taskCount))
+				}
+				// Updating a task twice would flip its status back
+				if !slices.Contains(ids, id) {
+					ids = append(ids, id)
+				}
 			}
 
 			// Return early if there's no update to make
-			if UpdatedDesc == "" && !UpdateStatus {
+			if UpdatedDesc == "" && !UpdateStatus && UpdateAddTags == "" && UpdateRemoveTags == "" && UpdateDue == "" && !UpdateNoDue && UpdatePriority == "" && UpdateParent == "" && !cmd.Flags().Changed("points") && !cmd.Flags().Changed("assign") {
 				cmd.SilenceUsage = false
-				return errors.New("Did not make any updates, try using a flag")
+				return errors.New(tr("Did not make any updates, try using a flag"))
 			}
 
-			t, _ := getTask(db, id)
-
-			// Flip the task status
-			if UpdateStatus {
-				if t.Status == STATUS.COMPLETE {
-					t.Status = STATUS.INCOMPLETE
-					t.Completed = ""
-				} else {
-					t.Status = STATUS.COMPLETE
-					t.Completed = time.Now().Format(RFC3339)
+			// Validate the new description and due date before touching any task
+			tags, desc := parseTags(UpdatedDesc)
+			if UpdatedDesc != "" && desc == "" {
+				return errors.New(tr("Must provide a task description"))
+			}
+			if UpdateDue != "" && UpdateNoDue {
+				return errors.New(tr("Can't use the due flag in combination with the no-due flag"))
+			}
+			var due string
+			if UpdateDue != "" {
+				d, err := parseDueDate(UpdateDue)
+				if err != nil {
+					return err
+				}
+				due = d.Format(RFC3339)
+			}
+			priority, err := parsePriority(UpdatePriority)
+			if err != nil {
+				return err
+			}
+			if UpdatePoints < 0 {
+				return errors.New(tr("Points can't be negative"))
+			}
+			var parent string
+			if UpdateParent != "" && strings.ToLower(UpdateParent) != "none" {
+				if parent, err = findParent(db, UpdateParent); err != nil {
+					return err
 				}
 			}
+			tasks := getTasks(db, TASKS_BUCKET)
+
+			update := func(t *Task) error {
+				// Flip the task status
+				if UpdateStatus {
+					to := STATUS.COMPLETE
+					if isDone(*t) {
+						to = STATUS.INCOMPLETE
+					}
+					status, _ := config.status(to)
+					setTaskStatus(t, status, time.Now())
+				}
+
+				// Update the task description
+				if UpdatedDesc != "" {
+					// Replace the tags if any tags are present in the input
+					if len(tags) >= 1 {
+						t.Tags = slices.Clone(tags)
+					}
+					t.Desc = desc
+				}
+
+				// Add and remove individual tags
+				for _, tag := range splitTags(UpdateAddTags) {
+					if !slices.Contains(t.Tags, tag) {
+						t.Tags = append(t.Tags, tag)
+					}
+				}
+				t.Tags = slices.DeleteFunc(t.Tags, func(tag string) bool {
+					return slices.Contains(splitTags(UpdateRemoveTags), tag)
+				})
+
+				// Set or clear the due date
+				if due != "" || UpdateNoDue {
+					t.Due = due
+				}
+
+				// Set or clear the priority
+				if UpdatePriority != "" {
+					t.Priority = priority
+				}
+
+				// Set the estimate, 0 clears it
+				if cmd.Flags().Changed("points") {
+					t.Points = UpdatePoints
+				}
 
-			// Update the task description
-			if UpdatedDesc != "" {
-				// Update the tag if a tag is present in the input
-				tags, s := parseTags(UpdatedDesc)
-				if s == "" {
-					return errors.New("Must provide a task description")
+				// Set or clear the assignee
+				if cmd.Flags().Changed("assign") {
+					t.Assignee = parseAssignee(UpdateAssignee)
 				}
-				if len(tags) >= 1 {
-					t.Tag = tags[0]
+
+				// Set or clear the parent, without making the task a subtask of itself
+				if UpdateParent != "" {
+					if parent != "" && (parent == t.UUID || isAncestor(tasks, t.UUID, parent)) {
+						return errors.New(tr("A task can't be a subtask of itself or of its subtasks"))
+					}
+					t.Parent = parent
 				}
-				t.Desc = s
+				return nil
 			}
 
-			// Finally, update the task in the db
-			if err := updateTask(db, id, t); err != nil {
+			// Finally, update the tasks in the db, all at once
+			if err := updateTasks(db, ids, update); err != nil {
 				return err
 			}
 
-			fmt.Fprintf(out, "Updated task %d\n", id)
+			for _, id := range ids {
+				fmt.Fprintf(out, tr("Updated task %d\n"), id)
+			}
 
 			// Print the updated tasks
 			tp := getTasks(db, TASKS_BUCKET)
@@ -171,16 +350,264 @@ func newUpdateCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 			return nil
 		},
 	}
-	cmd.Flags().StringVarP(&UpdatedDesc, "des", "d", "", "New task description. If a tag is present in the new description, the old tag will be replaced")
+	cmd.Flags().StringVarP(&UpdatedDesc, "des", "d", "", "New task description. If tags are present in the new description, the old tags will be replaced")
 	cmd.Flags().BoolVarP(&UpdateStatus, "status", "s", false, "Flip the completion status of the task")
+	cmd.Flags().StringVarP(&UpdateAddTags, "tag", "t", "", "Add tags to the task, keeping its other tags. The tags should be comma seperated. Example: -t=tag1,tag2")
+	cmd.Flags().StringVar(&UpdateDue, "due", "", "New due date, e.g. 05/31/2025, tomorrow, friday or \"in 2 weeks\"")
+	cmd.Flags().BoolVar(&UpdateNoDue, "no-due", false, "Remove the due date of the task")
+	cmd.Flags().StringVarP(&UpdatePriority, "priority", "p", "", "New priority: high, med, low or none to remove it")
+	cmd.RegisterFlagCompletionFunc("priority", completePriorities)
+	cmd.Flags().IntVar(&UpdatePoints, "points", 0, "New estimate in points, or 0 to remove it")
+	cmd.Flags().StringVar(&UpdateAssignee, "assign", "", "Person to assign the task to, or none to unassign it")
+	cmd.Flags().StringVar(&UpdateParent, "parent", "", "ID of the task to make this task a subtask of, or none to make it a top level task")
+	cmd.Flags().StringVarP(&UpdateRemoveTags, "untag", "u", "", "Remove tags from the task. The tags should be comma seperated. Example: -u=tag1,tag2")
 	return cmd
 }
 
+func newStatusCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "status [taskID...] [status]",
+		Short:        tr("Move tasks to another status, or list the statuses"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			if len(args) == 0 {
+				fmt.Fprintln(out, formatStatuses(CommandFilter.Apply(getTasks(db, TASKS_BUCKET))))
+				return nil
+			}
+			if len(args) == 1 && len(CommandFilter) == 0 {
+				return errors.New(tr("Must specify the tasks to move and the status to move them to"))
+			}
+
+			name, err := parseStatus(args[len(args)-1])
+			if err != nil {
+				return err
+			}
+			to, _ := config.status(name)
+			ids, err := parseTaskIDs(db, args[:len(args)-1])
+			if err != nil {
+				return err
+			}
+			if err := moveToStatus(db, ids, to); err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				fmt.Fprintf(out, tr("Moved task %d to %s\n"), id, to.Name)
+			}
+			fmt.Fprintln(out, formatTasks(getTasks(db, TASKS_BUCKET)))
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeStatuses(cmd, args, toComplete)
+		},
+	}
+}
+
+func newStartCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:               "start [taskID...]",
+		Short:             tr("Mark tasks as in progress"),
+		ValidArgsFunction: completeTaskIDs(mgr),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			ids, err := parseTaskIDs(db, args)
+			if err != nil {
+				return err
+			}
+			to, ok := config.status(STATUS.IN_PROGRESS)
+			if !ok {
+				return fmt.Errorf(tr(`The "%s" status is missing from the config`), STATUS.IN_PROGRESS)
+			}
+			if err := moveToStatus(db, ids, to); err != nil {
+				return err
+			}
+			for _, id := range ids {
+				fmt.Fprintf(out, tr("Started task %d\n"), id)
+			}
+			fmt.Fprintln(out, formatTasks(getTasks(db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+}
+
+func newStopCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:               "stop [taskID...]",
+		Short:             tr("Move tasks in progress back to incomplete"),
+		ValidArgsFunction: completeTaskIDs(mgr),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			ids, err := parseTaskIDs(db, args)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if t, err := getTask(db, id); err == nil && t.Status != STATUS.IN_PROGRESS {
+					return fmt.Errorf(tr("Task %d isn't in progress"), id)
+				}
+			}
+			to, _ := config.status(STATUS.INCOMPLETE)
+			if err := moveToStatus(db, ids, to); err != nil {
+				return err
+			}
+			now := time.Now()
+			for _, id := range ids {
+				t, _ := getTask(db, id)
+				if len(t.Intervals) > 0 {
+					fmt.Fprintf(out, tr("Stopped task %d, %s spent on it so far\n"), id, formatDuration(timeSpent(t, now)))
+				} else {
+					fmt.Fprintf(out, tr("Stopped task %d\n"), id)
+				}
+			}
+			fmt.Fprintln(out, formatTasks(getTasks(db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+}
+
+func newBlockCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	bCmd := &cobra.Command{
+		Use:               "block [taskID...] -[r]",
+		Short:             tr("Mark tasks as blocked, optionally with the reason why"),
+		ValidArgsFunction: completeTaskIDs(mgr),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			ids, err := parseTaskIDs(db, args)
+			if err != nil {
+				return err
+			}
+			to, ok := config.status(STATUS.BLOCKED)
+			if !ok {
+				return fmt.Errorf(tr(`The "%s" status is missing from the config`), STATUS.BLOCKED)
+			}
+			if err := moveToStatus(db, ids, to); err != nil {
+				return err
+			}
+			// Blocking a blocked task again only updates the reason
+			if err := updateTasks(db, ids, func(t *Task) error {
+				t.BlockedReason = strings.TrimSpace(BlockReason)
+				return nil
+			}); err != nil {
+				return err
+			}
+			for _, id := range ids {
+				fmt.Fprintf(out, tr("Blocked task %d\n"), id)
+			}
+			fmt.Fprintln(out, formatTasks(getTasks(db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+	bCmd.Flags().StringVarP(&BlockReason, "reason", "r", "", "Why the tasks are blocked, e.g. \"waiting on Bob\"")
+	return bCmd
+}
+
+func newCancelCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	cCmd := &cobra.Command{
+		Use:               "cancel [taskID...] -[f]",
+		Short:             tr("Close tasks as cancelled rather than completed"),
+		ValidArgsFunction: completeTaskIDs(mgr),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			ids, err := parseTaskIDs(db, args)
+			if err != nil {
+				return err
+			}
+			to, ok := config.status(STATUS.CANCELLED)
+			if !ok {
+				return fmt.Errorf(tr(`The "%s" status is missing from the config`), STATUS.CANCELLED)
+			}
+			if err := cancelTasks(db, ids, to, FinishOnCancel); err != nil {
+				return err
+			}
+			for _, id := range ids {
+				fmt.Fprintf(out, tr("Cancelled task %d\n"), id)
+			}
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, formatTasks(getTasks(db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+	cCmd.Flags().BoolVarP(&FinishOnCancel, "finish", "f", false, "Cancel and finish the specified tasks, moving them to the archive")
+	return cCmd
+}
+
+// Returns the reason `t` is blocked, formated to follow its description. Empty if there's no reason
+func blockedNote(t Task) string {
+	if t.Status != STATUS.BLOCKED || t.BlockedReason == "" {
+		return ""
+	}
+	return " (" + t.BlockedReason + ")"
+}
+
+// Resolve every argument to a task key, see parseTaskID. Keys given twice are only returned once.
+// With a filter on the command line, returns the keys of the matching tasks instead
+func parseTaskIDs(db *bolt.DB, args []string) ([]int, error) {
+	if len(CommandFilter) > 0 {
+		if len(args) > 0 {
+			return nil, errors.New(tr("Can't use task IDs in combination with a filter"))
+		}
+		return filteredKeys(db)
+	}
+	if len(args) == 0 {
+		return nil, errors.New(tr("Must provide a task ID"))
+	}
+	var ids []int
+	for _, arg := range args {
+		id, err := parseTaskID(db, arg)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Move the tasks with `keys` to the status `to` in a single transaction, so a move the config
+// doesn't allow leaves all tasks untouched
+func moveToStatus(db *bolt.DB, keys []int, to Status) error {
+	now := time.Now()
+	return updateTasks(db, keys, func(t *Task) error {
+		if err := canMove(*t, to); err != nil {
+			return err
+		}
+		setTaskStatus(t, to, now)
+		return nil
+	})
+}
+
+// Format the configured statuses, one per line with their icon, aliases and number of tasks
+func formatStatuses(tp []TaskPosition) string {
+	counts := map[string]int{}
+	for _, t := range tp {
+		counts[t.task.Status]++
+	}
+	var rows [][]string
+	for _, s := range config.Statuses {
+		aliases := ""
+		if len(s.Aliases) > 0 {
+			aliases = "(" + strings.Join(s.Aliases, ", ") + ")"
+		}
+		rows = append(rows, []string{s.Icon, s.Name, aliases, fmt.Sprintf(tr("%d tasks"), counts[s.Name])})
+	}
+	return formatTable(rows)
+}
+
 func newListCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 	lCmd := &cobra.Command{
-		Use:   "list -[te]",
-		Short: "List all of your incomplete tasks",
-		Run: func(cmd *cobra.Command, args []string) {
+		Use:          "list [+tag...] -[tegs0q]",
+		Short:        tr("List all of your incomplete tasks"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var exclude []string
 			var include []string
 
@@ -190,89 +617,657 @@ func newListCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 				exclude = []string{}
 			}
 
+			// Every argument must be a tag in the form "+tag"
 			input := strings.Join(args, " ")
-			if len(input) >= 1 {
-				include, _ = parseTags(input)
+			include, rest := parseTags(input)
+			if rest != "" {
+				return fmt.Errorf(tr("Tags must be in the form +tag, got \"%s\""), rest)
 			}
 
 			if len(include) > 0 && len(exclude) > 0 {
-				fmt.Fprintln(out, "Can't use tag filtering in combination with exclude flag")
-				return
+				return errors.New(tr("Can't use tag filtering in combination with exclude flag"))
+			}
+
+			if ListGroup != "" && ListTree {
+				return errors.New(tr("Can't use the group flag in combination with the tree flag"))
+			}
+
+			if ListGroup != "" && !slices.Contains(GROUPINGS, ListGroup) {
+				return fmt.Errorf(tr("Invalid grouping \"%s\", must be one of %s"), ListGroup, strings.Join(GROUPINGS, ", "))
+			}
+
+			if ListBlocked && ListStatus != "" {
+				return errors.New(tr("Can't use the blocked flag in combination with the status flag"))
+			}
+			status, err := parseStatus(ListStatus)
+			if ListBlocked {
+				status = STATUS.BLOCKED
+			}
+			if err != nil {
+				return err
 			}
 
 			tasks := getTasks(mgr.db, TASKS_BUCKET)
-			tasks = filterTasks(tasks, include, exclude)
+			tasks = filterTasks(tasks, include, exclude, MatchAllTags)
+			tasks = CommandFilter.Apply(tasks)
+			// The default filter only applies when no other way of selecting tasks is used
+			if config.DefaultFilter != "" && !NoDefaultFilter && len(CommandFilter) == 0 && len(include) == 0 && status == "" && ListAssignee == "" {
+				filter, err := parseFilter(strings.Fields(config.DefaultFilter), time.Now())
+				if err != nil {
+					return err
+				}
+				tasks = filter.Apply(tasks)
+			}
+			if status != "" {
+				tasks = slices.DeleteFunc(tasks, func(t TaskPosition) bool {
+					return t.task.Status != status
+				})
+			}
+			if ListAssignee != "" {
+				assignee := parseAssignee(ListAssignee)
+				tasks = slices.DeleteFunc(tasks, func(t TaskPosition) bool {
+					return !strings.EqualFold(t.task.Assignee, assignee)
+				})
+			}
 			if len(tasks) == 0 {
-				fmt.Fprintln(out, "No tasks")
-				return
+				// Output read by other commands gets no message
+				if !ListIDs && !ListPrint0 && !ListQuiet {
+					fmt.Fprintln(out, tr("No tasks"))
+				}
+				return noMatch(cmd)
+			}
+			if ListQuiet {
+				return nil
+			}
+			// IDs alone are for other commands to read
+			if ListIDs {
+				for _, t := range tasks {
+					fmt.Fprint(out, t.dbKey, listTerminator())
+				}
+				return nil
+			}
+			// Descriptions are written as they are, newlines included, since NUL ends each of them
+			if ListPrint0 {
+				for _, t := range tasks {
+					fmt.Fprint(out, t.task.Desc, listTerminator())
+				}
+				return nil
+			}
+			if ListGroup != "" {
+				fmt.Fprintln(out, formatGroups(groupTasks(tasks, ListGroup, time.Now())))
+				return nil
+			}
+			if ListTree {
+				fmt.Fprintln(out, formatTree(tasks))
+				return nil
 			}
 			fmt.Fprintln(out, formatTasks(tasks))
+			return nil
 		},
 	}
-	lCmd.Flags().BoolVarP(&ShowTags, "tag", "t", false, "Show tag associated with each task")
+	lCmd.Flags().BoolVarP(&ShowTags, "tag", "t", false, "Show tags associated with each task")
+	lCmd.Flags().BoolVar(&MatchAnyTag, "any", false, "List tasks carrying any of the listed tags (default)")
+	lCmd.Flags().BoolVar(&MatchAllTags, "all", false, "List tasks carrying all of the listed tags")
+	lCmd.MarkFlagsMutuallyExclusive("any", "all")
+	lCmd.Flags().BoolVar(&ShowAnnotations, "annotations", false, "Show the latest comment on each task below it")
+	lCmd.Flags().BoolVar(&ShowAge, "age", false, "Show how long each incomplete task has been open")
+	lCmd.Flags().IntVar(&AgeThreshold, "age-threshold", 7, "Number of days after which a task's age is flagged with a warning sign")
 	lCmd.Flags().StringVarP(&ExcludeTags, "exclude", "e", "", "Exclude tasks with listed tags. The tags should be comma seperated. Example: -e=tag1,tag2,tag3")
+	lCmd.Flags().StringVarP(&ListGroup, "group", "g", "", "Print tasks in sections, one of: tag, priority, due")
+	lCmd.RegisterFlagCompletionFunc("group", completeGroupings)
+	lCmd.Flags().StringVarP(&ListStatus, "status", "s", "", "Only list tasks with the status, such as in-progress or blocked")
+	lCmd.RegisterFlagCompletionFunc("status", completeStatuses)
+	lCmd.Flags().BoolVar(&ListBlocked, "blocked", false, "Only list blocked tasks, shorthand for --status=blocked")
+	lCmd.Flags().StringVar(&ListAssignee, "assignee", "", "Only list tasks assigned to the person, or none for unassigned tasks")
+	lCmd.Flags().BoolVar(&NoDefaultFilter, "no-default-filter", false, "List every task, ignoring the default_filter setting of the config file")
+	lCmd.Flags().BoolVar(&ListTree, "tree", false, "Print subtasks below their parent task, along with how much of each parent is complete")
+	lCmd.Flags().BoolVar(&ListIDs, "ids", false, "Print only the IDs of the tasks, one per line, e.g. to pass them to another command with xargs")
+	lCmd.MarkFlagsMutuallyExclusive("ids", "group")
+	lCmd.MarkFlagsMutuallyExclusive("ids", "tree")
+	lCmd.Flags().BoolVarP(&ListPrint0, "print0", "0", false, "End each task with a NUL character instead of a newline, for xargs -0. Prints the full descriptions, or the IDs with --ids")
+	lCmd.MarkFlagsMutuallyExclusive("print0", "group")
+	lCmd.MarkFlagsMutuallyExclusive("print0", "tree")
+	lCmd.Flags().BoolVarP(&ListQuiet, "quiet", "q", false, "Print nothing, only exit with 0 if any task matches and 1 if none does")
 	return lCmd
 }
 
+// What ends each task printed by `list --ids` or `list --print0`
+func listTerminator() string {
+	if ListPrint0 {
+		return "\x00"
+	}
+	return "\n"
+}
+
 func newFinishCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
-	return &cobra.Command{
-		Use:   "finish",
-		Short: "Delete all completed tasks",
-		Run: func(cmd *cobra.Command, args []string) {
+	fCmd := &cobra.Command{
+		Use:          "finish -[t]",
+		Short:        tr("Delete all completed tasks"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			db := mgr.db
-			deletedTasks, err := finish(db)
-			check(err)
+			tag := strings.TrimPrefix(FinishTag, "+")
+			deletedTasks, err := finish(db, tag)
+			if err != nil {
+				return err
+			}
 
 			if len(deletedTasks) == 0 {
-				fmt.Fprintln(out, "No completed tasks to finish")
-				return
+				fmt.Fprintln(out, tr("No completed tasks to finish"))
+			} else if tag != "" {
+				fmt.Fprintf(out, tr("Deleted all completed tasks tagged %s\n"), tag)
+			} else {
+				fmt.Fprint(out, tr("Deleted all completed tasks\n"))
 			}
 
-			fmt.Fprintf(out, "Deleted all completed tasks\n")
+			// Keep the archive within the limits of the config file
+			pruned, err := pruneArchive(db, config.Archive, time.Now())
+			if err != nil {
+				return err
+			}
+			if pruned > 0 {
+				fmt.Fprintf(out, tr("Deleted %d old tasks from the archive\n"), pruned)
+			}
+			if len(deletedTasks) == 0 {
+				return nil
+			}
 
 			// Print the updated task list
 			tp := getTasks(db, TASKS_BUCKET)
 			if len(tp) == 0 {
-				return
+				return nil
 			}
 			fmt.Fprintln(out, formatTasks(tp))
+			return nil
 		},
 	}
+	fCmd.Flags().StringVarP(&FinishTag, "tag", "t", "", "Only finish completed tasks carrying the tag")
+	return fCmd
 }
 
-func newClearCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+func newShowCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	sCmd := &cobra.Command{
+		Use:          "show [taskID] -[H]",
+		Short:        tr("Show every detail of a task"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New(tr("Must specify a single task to show"))
+			}
+			id, err := parseTaskID(mgr.db, args[0])
+			if err != nil {
+				return err
+			}
+			t, err := getTask(mgr.db, id)
+			if err != nil {
+				return fmt.Errorf(tr("Task %d does not exist"), id)
+			}
+			fmt.Fprintln(out, formatTaskCard(id, t))
+			if ShowHistory {
+				fmt.Fprintln(out, "\n"+formatHistory(t))
+			}
+			return nil
+		},
+	}
+	sCmd.Flags().BoolVarP(&ShowHistory, "history", "H", false, "Also print the changes made to the task, when and from what to what")
+	return sCmd
+}
+
+func newOpenCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	oCmd := &cobra.Command{
+		Use:          "open [taskID] -[a]",
+		Short:        tr("Open the first URL in a task's description in your browser"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New(tr("Must specify a single task to open"))
+			}
+			id, err := parseTaskID(mgr.db, args[0])
+			if err != nil {
+				return err
+			}
+			t, err := getTask(mgr.db, id)
+			if err != nil {
+				return fmt.Errorf(tr("Task %d does not exist"), id)
+			}
+
+			if OpenAttachment != 0 {
+				if OpenAttachment < 1 || OpenAttachment > len(t.Attachments) {
+					return fmt.Errorf(tr("Invalid attachment %d, task %d has %d attachments"), OpenAttachment, id, len(t.Attachments))
+				}
+				path := t.Attachments[OpenAttachment-1]
+				if err := openExternal(path); err != nil {
+					return fmt.Errorf(tr("Failed to open %s: %v"), path, err)
+				}
+				fmt.Fprintf(out, tr("Opened %s\n"), path)
+				return nil
+			}
+
+			url := findURL(t.Desc)
+			if url == "" {
+				return fmt.Errorf(tr("Task %d does not contain a URL"), id)
+			}
+			if err := openExternal(url); err != nil {
+				return fmt.Errorf(tr("Failed to open %s: %v"), url, err)
+			}
+			fmt.Fprintf(out, tr("Opened %s\n"), url)
+			return nil
+		},
+	}
+	oCmd.Flags().IntVarP(&OpenAttachment, "attachment", "a", 0, "Open the task's Nth attachment instead, as listed by show")
+	return oCmd
+}
+
+func newAttachCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	aCmd := &cobra.Command{
+		Use:          "attach [taskID] [path] -[c]",
+		Short:        tr("Link a file to a task"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			if len(args) != 2 {
+				return errors.New(tr("Must specify a task and a file to attach"))
+			}
+			id, err := parseTaskID(mgr.db, args[0])
+			if err != nil {
+				return err
+			}
+			t, err := getTask(db, id)
+			if err != nil {
+				return fmt.Errorf(tr("Task %d does not exist"), id)
+			}
+
+			path, err := filepath.Abs(args[1])
+			if err != nil {
+				return err
+			}
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				return fmt.Errorf(tr("%s is not a file"), args[1])
+			}
+
+			// Copy the file into the task directory so the attachment survives
+			// the original being moved or deleted
+			if CopyAttachment {
+				path, err = copyAttachment(path)
+				if err != nil {
+					return fmt.Errorf(tr("Failed to copy %s: %v"), args[1], err)
+				}
+			}
+
+			t.Attachments = append(t.Attachments, path)
+			if err := updateTask(db, id, t); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Attached %s to task %d\n"), path, id)
+			return nil
+		},
+	}
+	aCmd.Flags().BoolVarP(&CopyAttachment, "copy", "c", false, "Copy the file into the task directory instead of linking to its current path")
+	return aCmd
+}
+
+func newCommentCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 	return &cobra.Command{
-		Use:   "clear",
-		Short: "Delete all tasks",
-		Run: func(cmd *cobra.Command, args []string) {
-			mgr.db.Update(func(tx *bolt.Tx) error {
-				tx.DeleteBucket(TASKS_BUCKET)
+		Use:          "comment [taskID] [text]",
+		Short:        tr("Add a timestamped comment to a task"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New(tr("Must specify a task and the comment to add"))
+			}
+			id, err := parseTaskID(mgr.db, args[0])
+			if err != nil {
+				return err
+			}
+			text := strings.TrimSpace(strings.Join(args[1:], " "))
+			if text == "" {
+				return errors.New(tr("Must specify a task and the comment to add"))
+			}
+
+			comment := Comment{Time: timestamp(time.Now()), Text: text}
+			err = updateTasks(mgr.db, []int{id}, func(t *Task) error {
+				t.Comments = append(t.Comments, comment)
 				return nil
 			})
-			fmt.Fprintln(out, "Deleted all tasks")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Commented on task %d\n"), id)
+			return nil
 		},
 	}
 }
 
-func newDeleteCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+func newDupCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	dCmd := &cobra.Command{
+		Use:          "dup [taskID] -[c]",
+		Short:        tr("Duplicate a task as new incomplete tasks"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			if len(args) != 1 {
+				return errors.New(tr("Must specify a single task to duplicate"))
+			}
+			id, err := parseTaskID(mgr.db, args[0])
+			if err != nil {
+				return err
+			}
+			if DupCount < 1 {
+				return errors.New(tr("Count must be at least 1"))
+			}
+
+			t, err := getTask(db, id)
+			if err != nil {
+				return fmt.Errorf(tr("Task %d does not exist"), id)
+			}
+			copies := make([]Task, DupCount)
+			for i := range copies {
+				copies[i] = cloneTask(t)
+			}
+			if err := insertTasks(db, TASKS_BUCKET, copies); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, tr("Duplicated task %d %d times\n"), id, DupCount)
+			fmt.Fprintln(out, formatTasks(getTasks(db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+	dCmd.Flags().IntVarP(&DupCount, "count", "c", 1, "Number of copies to create")
+	return dCmd
+}
+
+func newMoveCmd(mgr *connectionManager, out io.Writer, use string, delta int) *cobra.Command {
+	short, moved := tr("Move a task up one position in your TODO list"), tr("Moved task %d up\n")
+	if delta > 0 {
+		short, moved = tr("Move a task down one position in your TODO list"), tr("Moved task %d down\n")
+	}
+	return &cobra.Command{
+		Use:          use + " [taskID]",
+		Short:        short,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			if len(args) != 1 {
+				return errors.New(tr("Must specify a single task to move"))
+			}
+			id, err := parseTaskID(mgr.db, args[0])
+			if err != nil {
+				return err
+			}
+			if err := moveTask(db, id, delta); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, moved, id)
+			fmt.Fprintln(out, formatTasks(getTasks(db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+}
+
+func newMoveUpCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return newMoveCmd(mgr, out, "move-up", -1)
+}
+
+func newMoveDownCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return newMoveCmd(mgr, out, "move-down", 1)
+}
+
+func newExtendCmd(mgr *connectionManager, out io.Writer, use string, prepend bool) *cobra.Command {
+	short := tr("Add text to the end of a task's description")
+	if prepend {
+		short = tr("Add text to the start of a task's description")
+	}
+	return &cobra.Command{
+		Use:          use + " [taskID] [text]",
+		Short:        short,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New(tr("Must specify a task and the text to add"))
+			}
+			id, err := parseTaskID(mgr.db, args[0])
+			if err != nil {
+				return err
+			}
+			// Tags in the text are added to the task
+			tags, text := parseTags(strings.Join(args[1:], " "))
+
+			err = updateTasks(mgr.db, []int{id}, func(t *Task) error {
+				switch {
+				case text == "":
+				case prepend:
+					t.Desc = text + " " + t.Desc
+				default:
+					t.Desc = t.Desc + " " + text
+				}
+				for _, tag := range tags {
+					if !slices.Contains(t.Tags, tag) {
+						t.Tags = append(t.Tags, tag)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Updated task %d\n"), id)
+			fmt.Fprintln(out, formatTasks(getTasks(mgr.db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+}
+
+func newAppendCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return newExtendCmd(mgr, out, "append", false)
+}
+
+func newPrependCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return newExtendCmd(mgr, out, "prepend", true)
+}
+
+func newRenumberCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "renumber",
+		Short:        tr("Renumber tasks 1..N in the order they are listed"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			changed, err := renumberTasks(mgr.db)
+			if err != nil {
+				return err
+			}
+			if len(changed) == 0 {
+				fmt.Fprintln(out, tr("Task IDs are already in order"))
+				return nil
+			}
+			for _, c := range changed {
+				fmt.Fprintf(out, "%d → %d\n", c.Old, c.New)
+			}
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, formatTasks(getTasks(mgr.db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+}
+
+func newPurgeCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 	return &cobra.Command{
-		Use:   "delete",
-		Short: "Delete a task",
+		Use:   "purge",
+		Short: tr("Permanently delete all completed tasks without adding them to the archive"),
+		Run: func(cmd *cobra.Command, args []string) {
+			db := mgr.db
+			purged := purge(db)
+			if purged == 0 {
+				fmt.Fprintln(out, tr("No completed tasks to purge"))
+				return
+			}
+			fmt.Fprintf(out, tr("Purged %d completed tasks\n"), purged)
+
+			// Print the remaining tasks
+			tp := getTasks(db, TASKS_BUCKET)
+			if len(tp) == 0 {
+				return
+			}
+			fmt.Fprintln(out, formatTasks(tp))
+		},
+	}
+}
+
+func newClearCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	cCmd := &cobra.Command{
+		Use:   "clear -[tc]",
+		Short: tr("Delete all tasks"),
 		Run: func(cmd *cobra.Command, args []string) {
 			db := mgr.db
+			if ClearTag == "" && !ClearCompleted {
+				db.Update(func(tx *bolt.Tx) error {
+					tx.DeleteBucket(TASKS_BUCKET)
+					return nil
+				})
+				fmt.Fprintln(out, tr("Deleted all tasks"))
+				return
+			}
+
+			tag := strings.TrimPrefix(ClearTag, "+")
+			var keys []int
+			for _, t := range getTasks(db, TASKS_BUCKET) {
+				if tag != "" && !slices.Contains(t.task.Tags, tag) {
+					continue
+				}
+				if ClearCompleted && !isDone(t.task) {
+					continue
+				}
+				keys = append(keys, t.dbKey)
+			}
+
+			if len(keys) == 0 {
+				fmt.Fprintln(out, tr("No matching tasks to delete"))
+				return
+			}
+			deleteKeys(keys, db, TASKS_BUCKET)
+			fmt.Fprintf(out, tr("Deleted %d tasks\n"), len(keys))
+
+			// Print the remaining tasks
+			tp := getTasks(db, TASKS_BUCKET)
+			if len(tp) == 0 {
+				return
+			}
+			fmt.Fprintln(out, formatTasks(tp))
+		},
+	}
+	cCmd.Flags().StringVarP(&ClearTag, "tag", "t", "", "Only delete tasks carrying the tag")
+	cCmd.Flags().BoolVarP(&ClearCompleted, "completed-only", "c", false, "Only delete completed tasks")
+	return cCmd
+}
+
+func newDeleteCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	dCmd := &cobra.Command{
+		Use:               "delete -[ty]",
+		Short:             tr("Delete a task"),
+		ValidArgsFunction: completeTaskIDs(mgr),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db := mgr.db
+			if DeleteInteractive {
+				if len(args) > 0 {
+					return errors.New(tr("Can't use task IDs in combination with -i"))
+				}
+				// A filter or a tag narrows the tasks to choose from
+				tp := getTasks(db, TASKS_BUCKET)
+				if len(CommandFilter) > 0 {
+					tp = CommandFilter.Apply(tp)
+				}
+				if DeleteTag != "" {
+					tp = filterTasks(tp, []string{strings.TrimPrefix(DeleteTag, "+")}, nil, false)
+				}
+				if len(tp) == 0 {
+					fmt.Fprintln(out, tr("No matching tasks to delete"))
+					return nil
+				}
+				in, screen, restore, err := startInteractive(cmd, out)
+				if err != nil {
+					return err
+				}
+				keys, ok, err := selectTasks(in, screen, tp)
+				restore()
+				if err != nil {
+					return err
+				}
+				if !ok || len(keys) == 0 {
+					fmt.Fprintln(out, tr("Aborted, nothing was deleted"))
+					return nil
+				}
+				if err := deleteKeys(keys, db, TASKS_BUCKET); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, tr("Deleted %d tasks\n"), len(keys))
+				if tp := getTasks(db, TASKS_BUCKET); len(tp) > 0 {
+					fmt.Fprintln(out, formatTasks(tp))
+				}
+				return nil
+			}
+			if len(CommandFilter) > 0 {
+				if DeleteTag != "" || len(args) > 0 {
+					return errors.New(tr("Can't use task IDs or the tag flag in combination with a filter"))
+				}
+				var keys []int
+				for _, t := range CommandFilter.Apply(getTasks(db, TASKS_BUCKET)) {
+					keys = append(keys, t.dbKey)
+				}
+				if len(keys) == 0 {
+					fmt.Fprintln(out, tr("No matching tasks to delete"))
+					return nil
+				}
+				if !DeleteYes && !confirm(cmd.InOrStdin(), out, fmt.Sprintf(tr("Delete %d tasks matching the filter?"), len(keys))) {
+					fmt.Fprintln(out, tr("Aborted, nothing was deleted"))
+					return nil
+				}
+				if err := deleteKeys(keys, db, TASKS_BUCKET); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, tr("Deleted %d tasks\n"), len(keys))
+				if tp := getTasks(db, TASKS_BUCKET); len(tp) > 0 {
+					fmt.Fprintln(out, formatTasks(tp))
+				}
+				return nil
+			}
+			if DeleteTag != "" {
+				if len(args) > 0 {
+					return errors.New(tr("Can't use task IDs in combination with the tag flag"))
+				}
+				tag := strings.TrimPrefix(DeleteTag, "+")
+				n := len(filterTasks(getTasks(db, TASKS_BUCKET), []string{tag}, nil, false))
+				if n == 0 {
+					fmt.Fprintln(out, tr("No matching tasks to delete"))
+					return nil
+				}
+				if !DeleteYes && !confirm(cmd.InOrStdin(), out, fmt.Sprintf(tr("Delete %d tasks tagged +%s?"), n, tag)) {
+					fmt.Fprintln(out, tr("Aborted, nothing was deleted"))
+					return nil
+				}
+				deleted, err := deleteTagged(db, tag)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, tr("Deleted %d tasks\n"), deleted)
+				if tp := getTasks(db, TASKS_BUCKET); len(tp) > 0 {
+					fmt.Fprintln(out, formatTasks(tp))
+				}
+				return nil
+			}
+
 			var ids []int
 			taskCount := getCount(db, TASKS_BUCKET)
 
 			for _, s := range args {
-				id, err := strconv.Atoi(s)
+				id, err := parseTaskID(db, s)
 				if err != nil {
-					fmt.Fprintln(out, "Arguments should only be numbers")
-					fmt.Fprintf(out, "%s is not a number\n", args[0])
-					os.Exit(1)
+					return err
 				}
 				if id > taskCount {
-					fmt.Fprintf(out, "%d is out of range, only %d tasks exist\n", id, taskCount)
-					return
+					fmt.Fprintf(out, tr("%d is out of range, only %d tasks exist\n"), id, taskCount)
+					return nil
 				}
 				ids = append(ids, id)
 			}
@@ -280,28 +1275,44 @@ func newDeleteCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 			if len(ids) == 1 {
 				er := deleteKey(ids[0], db, TASKS_BUCKET)
 				check(er)
-				fmt.Fprintf(out, "Deleted task %d\n", ids[0])
+				fmt.Fprintf(out, tr("Deleted task %d\n"), ids[0])
 				tp := getTasks(db, TASKS_BUCKET)
 				fmt.Fprintln(out, formatTasks(tp))
-				return
+				return nil
 			}
 
-			deleteKeys(ids, db, TASKS_BUCKET)
+			if err := deleteKeys(ids, db, TASKS_BUCKET); err != nil {
+				return err
+			}
 			for _, n := range ids {
-				fmt.Fprintln(out, "Deleted Task ", n)
+				fmt.Fprintln(out, tr("Deleted Task "), n)
 			}
 
 			fmt.Fprintln(out)
 			tp := getTasks(db, TASKS_BUCKET)
 			fmt.Fprintln(out, formatTasks(tp))
+			return nil
 		},
 	}
+	dCmd.Flags().StringVarP(&DeleteTag, "tag", "t", "", "Delete every task carrying the tag, after asking for confirmation")
+	dCmd.Flags().BoolVarP(&DeleteYes, "yes", "y", false, "Don't ask for confirmation")
+	dCmd.Flags().BoolVarP(&DeleteInteractive, "interactive", "i", false, "Choose the tasks to delete from a list: space selects a task and enter deletes the selected tasks")
+	return dCmd
+}
+
+// Ask the yes or no question `prompt` on `out` and read the answer from `in`. Anything but
+// a yes, including no input at all, counts as a no
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes" || answer == strings.ToLower(tr("yes"))
 }
 
 func newArchiveCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 	arCmd := &cobra.Command{
-		Use:   "archive -[c]",
-		Short: "View all previously completed tasks",
+		Use:   "archive -[cg]",
+		Short: tr("View all previously completed tasks"),
 		Run: func(cmd *cobra.Command, args []string) {
 			db := mgr.db
 			if ClearArchive {
@@ -310,14 +1321,23 @@ func newArchiveCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 					check(er)
 					return nil
 				})
-				fmt.Fprintln(out, "Cleared the archive")
+				fmt.Fprintln(out, tr("Cleared the archive"))
+				return
+			}
+			if GroupByDay {
+				archive := getTasks(db, ARCHIVE_BUCKET)
+				if len(archive) == 0 {
+					fmt.Fprintln(out, tr("Archive is empty, finish a task to add it to the archive"))
+					return
+				}
+				fmt.Fprintln(out, formatArchiveByDay(archive))
 				return
 			}
 
 			db.View(func(tx *bolt.Tx) error {
 				archive := tx.Bucket(ARCHIVE_BUCKET)
 				if archive == nil || archive.Stats().KeyN == 0 {
-					fmt.Fprintln(out, "Archive is empty, finish a task to add it to the archive")
+					fmt.Fprintln(out, tr("Archive is empty, finish a task to add it to the archive"))
 					return nil
 				}
 
@@ -334,14 +1354,92 @@ func newArchiveCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 		},
 	}
 	arCmd.Flags().BoolVarP(&ClearArchive, "clear", "c", false, "Delete all archive entries")
+	arCmd.Flags().BoolVarP(&GroupByDay, "group-day", "g", false, "List the archive under the days tasks were completed on, most recent first")
+	arCmd.AddCommand(newArchiveExportCmd(mgr, out), newArchiveStatsCmd(mgr, out), newArchiveRestoreCmd(mgr, out))
 	return arCmd
 }
 
-func newStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
-	sCmd := &cobra.Command{
-		Use:   "stats",
-		Short: "See statistics on your task completion",
-		Run: func(cmd *cobra.Command, args []string) {
+func newArchiveRestoreCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	rCmd := &cobra.Command{
+		Use:          "restore --since [date] -[u]",
+		Short:        tr("Move tasks finished in a period back to your TODO list"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if RestoreSince == "" {
+				return errors.New(tr("Must specify a start date with --since"))
+			}
+			period, err := parsePeriod(RestoreSince, RestoreUntil)
+			if err != nil {
+				return err
+			}
+			restored, err := restoreArchived(mgr.db, period)
+			if err != nil {
+				return err
+			}
+			if len(restored) == 0 {
+				fmt.Fprintln(out, tr("No archived tasks were finished in that period"))
+				return nil
+			}
+			fmt.Fprintf(out, tr("Restored %d tasks\n"), len(restored))
+			fmt.Fprintln(out, formatTasks(getTasks(mgr.db, TASKS_BUCKET)))
+			return nil
+		},
+	}
+	rCmd.Flags().StringVarP(&RestoreSince, "since", "s", "", "mm/dd/yyyy formated date. Restore tasks completed on or after it")
+	rCmd.Flags().StringVarP(&RestoreUntil, "until", "u", "", "mm/dd/yyyy formated date. Only restore tasks completed on or before it")
+	return rCmd
+}
+
+func newArchiveStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: tr("Summarize every task in the archive"),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			archive := getTasks(mgr.db, ARCHIVE_BUCKET)
+			if len(archive) == 0 {
+				fmt.Fprintln(out, tr("Archive is empty, finish a task to add it to the archive"))
+				return
+			}
+			fmt.Fprintln(out, formatArchiveSummary(archive))
+		},
+	}
+}
+
+func newArchiveExportCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	eCmd := &cobra.Command{
+		Use:          "export -[fse]",
+		Short:        tr("Print the archive as JSON or CSV"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			period, err := parsePeriod(ExportStart, ExportEnd)
+			if err != nil {
+				return err
+			}
+			var tasks []Task
+			for _, t := range getTasks(mgr.db, ARCHIVE_BUCKET) {
+				// Without a range, tasks missing a completion date are exported as well
+				completed, err := parseTimestamp(t.task.Completed)
+				if (err == nil && period.Contains(completed)) || (ExportStart == "" && ExportEnd == "") {
+					tasks = append(tasks, t.task)
+				}
+			}
+			return exportTasks(out, tasks, ExportFormat)
+		},
+	}
+	eCmd.Flags().StringVarP(&ExportFormat, "format", "f", "json", "Output format, json or csv")
+	eCmd.Flags().StringVarP(&ExportStart, "start", "s", "", "mm/dd/yyyy formated date. Only export tasks completed on or after it")
+	eCmd.Flags().StringVarP(&ExportEnd, "end", "e", "", "mm/dd/yyyy formated date. Only export tasks completed on or before it")
+	return eCmd
+}
+
+func newStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	sCmd := &cobra.Command{
+		Use:   "stats -[aseoplct]",
+		Short: tr("See statistics on your task completion"),
+		Run: func(cmd *cobra.Command, args []string) {
 			db := mgr.db
 			// Define the expected date format
 			mmddyyyy := "01/02/2006"
@@ -351,7 +1449,7 @@ func newStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 			var err error
 
 			// Attempt to parse using mm/dd/yyy format
-			endDate, err = time.Parse(mmddyyyy, EndTime)
+			endDate, err = time.ParseInLocation(mmddyyyy, EndTime, time.Local)
 			if err == nil {
 				mustInputStart = true
 			} else {
@@ -360,30 +1458,26 @@ func newStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 			}
 
 			// Attempt to parse using mm/dd/yyy format
-			startDate, err = time.Parse(mmddyyyy, StartTime)
+			startDate, err = time.ParseInLocation(mmddyyyy, StartTime, time.Local)
 			if err != nil && mustInputStart {
 				// User input an end but no start
-				fmt.Fprintln(out, "Must specify a start date")
+				fmt.Fprintln(out, tr("Must specify a start date"))
 				return
 			}
 			if err != nil {
 				// Defaults to last 24hrs
-				startDate, err = time.Parse(RFC3339, time.Now().Add(-24*time.Hour).Format(RFC3339))
-				if err != nil {
-					fmt.Fprintln(out, "Error parsing start date:", err)
-					return
-				}
+				startDate = time.Now().Add(-24 * time.Hour)
 			}
 
 			if endDate.Before(startDate) {
-				fmt.Fprintln(out, "Error: End date occured prior to the Start date")
+				fmt.Fprintln(out, tr("Error: End date occured prior to the Start date"))
 				return
 			}
 
 			if OnDay != "" {
-				day, err := time.Parse(mmddyyyy, OnDay)
+				day, err := time.ParseInLocation(mmddyyyy, OnDay, time.Local)
 				if err != nil {
-					fmt.Fprintln(out, "Error parsing date:", err)
+					fmt.Fprintln(out, tr("Error parsing date:"), err)
 					return
 				}
 				startDate = day
@@ -395,12 +1489,34 @@ func newStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 				endDate = lastTick(endDate)
 			}
 
-			var filtered []TaskPosition
 			tasks := getTasks(db, ARCHIVE_BUCKET)
+			if StatsTags != "" {
+				tasks = filterTasks(tasks, splitTags(StatsTags), nil, false)
+			}
+			// Tasks finished in another done state, such as cancelled, weren't completed
+			var otherDone []TaskPosition
+			tasks = slices.DeleteFunc(tasks, func(t TaskPosition) bool {
+				if closedIncomplete(t.task) {
+					otherDone = append(otherDone, t)
+					return true
+				}
+				return false
+			})
+			if CompareWith != "" {
+				before, after, err := comparedPeriods(CompareWith, Period{startDate, endDate}, time.Now())
+				if err != nil {
+					fmt.Fprintln(out, tr("Error parsing date:"), err)
+					return
+				}
+				fmt.Fprintln(out, formatComparison(tasks, before, after))
+				return
+			}
+
+			var filtered []TaskPosition
 			for _, t := range tasks {
-				completed, err := time.Parse(RFC3339, t.task.Completed)
+				completed, err := parseTimestamp(t.task.Completed)
 				if err != nil {
-					fmt.Fprintln(out, "Error parsing completed date:", err)
+					fmt.Fprintln(out, tr("Error parsing completed date:"), err)
 					return
 				}
 
@@ -414,16 +1530,72 @@ func newStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 			if ShowCompleted {
 				fmt.Fprintln(out, formatTasks(filtered))
 			}
-			sy, sm, sd := startDate.Date()
-			ey, em, ed := endDate.Date()
 			numCompleted := max(len(filtered), 0)
 
-			fmt.Fprintf(out, "\nYou completed %d tasks from %d/%d/%d to %d/%d/%d\n", numCompleted, sm, sd, sy, em, ed, ey)
+			fmt.Fprintf(out, tr("\nYou completed %d tasks from %s to %s\n"), numCompleted, formatDate(startDate), formatDate(endDate))
+			if points := sumPoints(filtered); points > 0 {
+				fmt.Fprintf(out, tr("That's %d points\n"), points)
+			}
+			otherCounts := map[string]int{}
+			for _, t := range otherDone {
+				completed, err := parseTimestamp(t.task.Completed)
+				if err == nil && completed.After(startDate) && completed.Before(endDate) {
+					otherCounts[t.task.Status]++
+				}
+			}
+			for _, s := range config.Statuses {
+				if otherCounts[s.Name] > 0 {
+					fmt.Fprintf(out, tr("%d tasks were %s\n"), otherCounts[s.Name], s.Name)
+				}
+			}
 			if ShowAverage {
 				diff := endDate.Sub(startDate)
 				numDays := diff.Hours() / 24
 				avg := float64(numCompleted) / numDays
-				fmt.Fprintf(out, "Average: %.1f/day\n", avg)
+				fmt.Fprintf(out, tr("Average: %.1f/day\n"), avg)
+			}
+			if ShowSparkline {
+				counts := completionsByDay(filtered, startDate, endDate)
+				fmt.Fprintf(out, tr("Per day: %s (most %d)\n"), sparkline(counts), slices.Max(counts))
+			}
+			// Without a period, patterns and lead times cover the whole archive
+			scope := filtered
+			if StartTime == "" && OnDay == "" {
+				scope = tasks
+			}
+			if ShowPattern {
+				var completed []time.Time
+				for _, t := range scope {
+					c, _ := parseTimestamp(t.task.Completed)
+					completed = append(completed, c)
+				}
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, formatPattern(completed))
+			}
+			if ShowLeadTime {
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, formatLeadTimes(scope))
+			}
+			if ShowBurnup {
+				all := append(getTasks(db, TASKS_BUCKET), getTasks(db, ARCHIVE_BUCKET)...)
+				if StatsTags != "" {
+					all = filterTasks(all, splitTags(StatsTags), nil, false)
+				}
+				// Without a period, the chart starts with the first task created. Dates given
+				// on the command line are local days
+				from := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.Local)
+				to := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, time.Local)
+				if StartTime == "" && OnDay == "" {
+					to = time.Now()
+					from = to
+					for _, t := range all {
+						if c, err := parseTimestamp(t.task.Created); err == nil && c.Before(from) {
+							from = c.Local()
+						}
+					}
+				}
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, formatBurnup(burnup(all, from, to)))
 			}
 		},
 	}
@@ -432,41 +1604,277 @@ func newStatsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
 	sCmd.Flags().StringVarP(&OnDay, "on", "o", "", "mm/dd/yyyy formated date. Shorthand for setting the start and end date to the same day. Note that the on flag cannot be used with the start or end flags")
 	sCmd.Flags().BoolVarP(&ShowCompleted, "verbose", "v", false, "Show the completed tasks")
 	sCmd.Flags().BoolVarP(&ShowAverage, "average", "a", false, "Show the average tasks completed/day")
+	sCmd.Flags().BoolVarP(&ShowPattern, "pattern", "p", false, "Show when tasks get completed by hour of day and weekday. Covers the whole archive unless a period is given")
+	sCmd.Flags().StringVarP(&StatsTags, "tag", "t", "", "Only count tasks carrying any of the listed tags. The tags should be comma seperated. Example: -t=tag1,tag2")
+	sCmd.Flags().BoolVarP(&ShowLeadTime, "lead-time", "l", false, "Show the average, median and 90th percentile time from creation to completion, overall and per tag. Covers the whole archive unless a period is given")
+	sCmd.Flags().BoolVar(&ShowSparkline, "sparkline", false, "Show the completions on each day of the period as a sparkline, such as ▁▃█▂")
+	sCmd.Flags().BoolVar(&ShowBurnup, "burnup", false, "Chart the running totals of created and completed tasks, showing whether the open tasks are shrinking. Covers the whole history unless a period is given")
+	sCmd.Flags().StringVarP(&CompareWith, "compare", "c", "", "Compare this week with last week, or the chosen period with a mm/dd/yyyy-mm/dd/yyyy formated range")
+	sCmd.Flags().Lookup("compare").NoOptDefVal = "week"
 	sCmd.MarkFlagsMutuallyExclusive("start", "on")
 	sCmd.MarkFlagsMutuallyExclusive("end", "on")
 	return sCmd
 }
 
+func newReportCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	rCmd := &cobra.Command{
+		Use:          "report [name] -[fo] [--start date] [--end date]",
+		Short:        tr("Print a digest of your tasks over a period, or a report defined in the config file"),
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(REPORT_FORMATS, ReportFormat) {
+				return fmt.Errorf(tr(`Invalid format "%s", must be one of %s`), ReportFormat, strings.Join(REPORT_FORMATS, ", "))
+			}
+			if ReportFormat == "html" {
+				return runHTMLReport(mgr, args, out)
+			}
+			if ReportOut != "" {
+				return errors.New(tr("--out can only be used with --format html"))
+			}
+			if len(args) == 0 {
+				fmt.Fprintln(out, formatReportList(cmd, config.Reports))
+				return nil
+			}
+			report, ok := config.Reports[args[0]]
+			if !ok {
+				return fmt.Errorf(tr(`No report named "%s"`), args[0])
+			}
+			now := time.Now()
+			groups, err := runReport(report, getTasks(mgr.db, TASKS_BUCKET), now)
+			if err != nil {
+				return err
+			}
+			if len(groups) == 0 || len(groups[0].Tasks) == 0 {
+				fmt.Fprintln(out, tr("No tasks"))
+				return nil
+			}
+			fmt.Fprintln(out, formatReportTable(groups, report.Columns, now))
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var names []string
+			for name := range config.Reports {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	rCmd.Flags().StringVarP(&ReportFormat, "format", "f", "text", "Format of the report, text or html. An html report is a page with the open tasks, the tasks completed in the period and charts of the completions")
+	rCmd.Flags().StringVarP(&ReportOut, "out", "o", "", "File to write the html report to instead of printing it")
+	rCmd.Flags().StringVar(&ReportStart, "start", "", "mm/dd/yyyy formated first day of the html report's period. Defaults to a week before the end")
+	rCmd.Flags().StringVar(&ReportEnd, "end", "", "mm/dd/yyyy formated last day of the html report's period. Defaults to today")
+
+	mCmd := &cobra.Command{
+		Use:          "monthly -[m]",
+		Short:        tr("Print a digest of the tasks created and completed in a month"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			month := time.Now()
+			if ReportMonth != "" {
+				var err error
+				month, err = time.ParseInLocation("2006-01", ReportMonth, time.Local)
+				if err != nil {
+					return fmt.Errorf(tr(`Invalid month "%s", must be in the format yyyy-mm`), ReportMonth)
+				}
+			}
+			r := monthlyReport(getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET), month)
+			fmt.Fprintln(out, formatReport(r, ReportMarkdown))
+			return nil
+		},
+	}
+	mCmd.Flags().StringVar(&ReportMonth, "month", "", "yyyy-mm formated month to report on. Defaults to the current month")
+	mCmd.Flags().BoolVarP(&ReportMarkdown, "markdown", "m", false, "Print the report as markdown")
+
+	rCmd.AddCommand(mCmd, newReportWeeklyCmd(mgr, out))
+	return rCmd
+}
+
+func newForecastCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	fCmd := &cobra.Command{
+		Use:          "forecast -[tw]",
+		Short:        tr("Estimate when your open tasks will be done at your recent pace"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ForecastWindow < 1 {
+				return errors.New(tr("The window must be at least 1 day"))
+			}
+			now := time.Now()
+			tag := strings.TrimPrefix(ForecastTag, "+")
+			f := forecast(getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET), tag, ForecastWindow, now)
+
+			fmt.Fprintf(out, tr("Velocity: %.2f tasks/day over the last %d days\n"), f.Velocity, ForecastWindow)
+			fmt.Fprintf(out, tr("Backlog:  %d open tasks\n"), f.Backlog)
+			// Only shown to users estimating their tasks
+			if f.PointsVelocity > 0 || f.BacklogPoints > 0 {
+				fmt.Fprintf(out, tr("Points:   %.2f points/day, %d open points\n"), f.PointsVelocity, f.BacklogPoints)
+			}
+			switch {
+			case f.Backlog == 0:
+				fmt.Fprintln(out, tr("Nothing left to do"))
+			case f.Velocity == 0:
+				fmt.Fprintf(out, tr("No tasks were completed in the last %d days, the backlog won't be cleared at this pace\n"), ForecastWindow)
+			default:
+				days := int(math.Ceil(f.Clear.Sub(now).Hours() / 24))
+				fmt.Fprintf(out, tr("At this pace the backlog is cleared in %d days, around %s\n"), days, formatDate(f.Clear))
+			}
+			return nil
+		},
+	}
+	fCmd.Flags().StringVarP(&ForecastTag, "tag", "t", "", "Only forecast the tasks carrying the tag")
+	fCmd.Flags().IntVarP(&ForecastWindow, "window", "w", 28, "Number of past days the velocity is measured over")
+	return fCmd
+}
+
 func newCountCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
-	return &cobra.Command{
-		Use:   "count",
-		Short: "Print the number of existing tasks",
-		Run: func(cmd *cobra.Command, args []string) {
-			num := getCount(mgr.db, TASKS_BUCKET)
-			fmt.Fprintf(out, "%d tasks\n", num)
+	cCmd := &cobra.Command{
+		Use:          "count [+tag...] -[tsoa]",
+		Short:        tr("Print the number of existing tasks"),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// `count +tag...` counts the tasks carrying every tag, like `task +tag... count`
+			for _, arg := range args {
+				if len(arg) < 2 || arg[0] != '+' {
+					return fmt.Errorf(tr("Tags must be in the form +tag, got \"%s\""), arg)
+				}
+			}
+			tags, _ := parseFilter(args, time.Now())
+			filter := append(slices.Clone(CommandFilter), tags...)
+
+			bucket := TASKS_BUCKET
+			if CountArchive {
+				bucket = ARCHIVE_BUCKET
+			}
+
+			status, err := parseStatus(CountStatus)
+			if err != nil {
+				return err
+			}
+
+			tag := strings.TrimPrefix(CountTag, "+")
+
+			if CountJSON {
+				// Tasks in any done state, such as cancelled, are no longer open
+				counts := TaskCounts{Archived: countTasks(mgr.db, ARCHIVE_BUCKET, tag, "", false, filter)}
+				for _, t := range getTasks(mgr.db, TASKS_BUCKET) {
+					if tag != "" && !slices.Contains(t.task.Tags, tag) || !filter.Match(t.task) {
+						continue
+					}
+					if isDone(t.task) {
+						counts.Completed++
+					} else {
+						counts.Open++
+					}
+				}
+				buf, err := json.Marshal(counts)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(out, string(buf))
+				if counts == (TaskCounts{}) {
+					return noMatch(cmd)
+				}
+				return nil
+			}
+
+			// Avoid reading every task when there's nothing to filter by
+			var num int
+			if tag == "" && CountStatus == "" && !CountOverdue && len(filter) == 0 {
+				num = getCount(mgr.db, bucket)
+			} else {
+				num = countTasks(mgr.db, bucket, tag, status, CountOverdue, filter)
+			}
+			fmt.Fprintf(out, tr("%d tasks\n"), num)
+			if num == 0 {
+				return noMatch(cmd)
+			}
+			return nil
 		},
 	}
+	cCmd.Flags().StringVarP(&CountTag, "tag", "t", "", "Only count tasks carrying the tag")
+	cCmd.Flags().StringVarP(&CountStatus, "status", "s", "", "Only count tasks with the status, such as incomplete, in-progress or complete")
+	cCmd.RegisterFlagCompletionFunc("status", completeStatuses)
+	cCmd.Flags().BoolVarP(&CountOverdue, "overdue", "o", false, "Only count incomplete tasks whose due date has passed")
+	cCmd.Flags().BoolVarP(&CountArchive, "archive", "a", false, "Count tasks in the archive instead")
+	cCmd.Flags().BoolVar(&CountJSON, "json", false, `Print the open, completed and archived counts as JSON, e.g. {"open":1,"completed":2,"archived":3}`)
+	cCmd.MarkFlagsMutuallyExclusive("json", "status")
+	cCmd.MarkFlagsMutuallyExclusive("json", "overdue")
+	cCmd.MarkFlagsMutuallyExclusive("json", "archive")
+	return cCmd
+}
+
+// Number of tasks in each stage, used by `count --json`
+type TaskCounts struct {
+	Open      int `json:"open"`
+	Completed int `json:"completed"`
+	Archived  int `json:"archived"`
+}
+
+// Opens a View transaction with `db` and returns the number of tasks in `bucket` matching
+// every non-empty filter, `filter` included
+func countTasks(db *bolt.DB, bucket []byte, tag, status string, overdue bool, filter Filter) int {
+	now := time.Now()
+	count := 0
+	for _, t := range getTasks(db, bucket) {
+		if tag != "" && !slices.Contains(t.task.Tags, tag) {
+			continue
+		}
+		if status != "" && t.task.Status != status {
+			continue
+		}
+		if overdue && !isOverdue(t.task, now) {
+			continue
+		}
+		if !filter.Match(t.task) {
+			continue
+		}
+		count++
+	}
+	return count
 }
 
 func newTagsCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
-	return &cobra.Command{
-		Use:   "tags",
-		Short: "Print existing tags",
+	tCmd := &cobra.Command{
+		Use:   "tags -[c]",
+		Short: tr("Print existing tags"),
 		Run: func(cmd *cobra.Command, args []string) {
+			if TagCounts {
+				stats := getTagStats(mgr.db)
+				if len(stats) == 0 {
+					fmt.Fprintln(out, tr("No tags"))
+					return
+				}
+				fmt.Fprintln(out, formatTagStats(stats))
+				return
+			}
 			tags := getAllTags(mgr.db)
 			fmt.Fprintln(out, strings.Join(tags, ","))
 		},
 	}
+	tCmd.Flags().BoolVarP(&TagCounts, "count", "c", false, "Show how many open and archived tasks carry each tag and when it was last used")
+	return tCmd
 }
 
 func getAllTags(db *bolt.DB) []string {
 	var tags []string
 	db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return nil
+		}
 		return b.ForEach(func(k, v []byte) error {
 			t := bToTask(v)
-			if t.Tag != "" && !slices.Contains(tags, t.Tag) {
-				tags = append(tags, t.Tag)
+			for _, tag := range t.Tags {
+				if !slices.Contains(tags, tag) {
+					tags = append(tags, tag)
+				}
 			}
 			return nil
 		})
@@ -474,118 +1882,1238 @@ func getAllTags(db *bolt.DB) []string {
 	return tags
 }
 
-// Flags
-// $ archive
-var ClearArchive bool
-
-// $ list
-var ShowTags bool
-var ExcludeTags string
-
-// $ update
-var UpdatedDesc string
-var UpdateStatus bool
+// Usage information for a single tag
+type TagStat struct {
+	Name     string
+	Open     int
+	Archived int
+	LastUsed time.Time
+}
 
-// $ do
-var DeleteOnDo bool
+// Collect usage information for every tag in the tasks and archive buckets.
+// A tag is "used" when a task carrying it is created or completed.
+// The result is sorted by frequency, most used first.
+func getTagStats(db *bolt.DB) []TagStat {
+	byName := map[string]*TagStat{}
+	record := func(t Task, archived bool) {
+		for _, tag := range t.Tags {
+			s, ok := byName[tag]
+			if !ok {
+				s = &TagStat{Name: tag}
+				byName[tag] = s
+			}
+			if archived {
+				s.Archived++
+			} else {
+				s.Open++
+			}
+			for _, ts := range []string{t.Created, t.Completed} {
+				used, err := parseTimestamp(ts)
+				if err == nil && used.After(s.LastUsed) {
+					s.LastUsed = used
+				}
+			}
+		}
+	}
 
-// $ stats
-var StartTime string
-var EndTime string
-var OnDay string
-var ShowCompleted bool
-var ShowAverage bool
+	for _, t := range getTasks(db, TASKS_BUCKET) {
+		record(t.task, false)
+	}
+	for _, t := range getTasks(db, ARCHIVE_BUCKET) {
+		record(t.task, true)
+	}
 
-// Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
+	var stats []TagStat
+	for _, s := range byName {
+		stats = append(stats, *s)
 	}
+	slices.SortFunc(stats, func(a, b TagStat) int {
+		if n := (b.Open + b.Archived) - (a.Open + a.Archived); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return stats
 }
 
-func init() {
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
-
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.task-cli.yaml)")
+// Format tag stats as aligned rows, one tag per line
+func formatTagStats(stats []TagStat) string {
+	width := 0
+	for _, s := range stats {
+		width = max(width, textWidth(s.Name))
+	}
 
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	// rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	var builder strings.Builder
+	for idx, s := range stats {
+		lastUsed := tr("never")
+		if !s.LastUsed.IsZero() {
+			lastUsed = formatDate(s.LastUsed)
+		}
+		builder.WriteString(fmt.Sprintf(tr("%s  open: %d  archived: %d  last used: %s"), padRight(s.Name, width), s.Open, s.Archived, lastUsed))
+		if idx < len(stats)-1 {
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
 }
 
-var TASKS_BUCKET = []byte("tasks")
-var ARCHIVE_BUCKET = []byte("archive")
-var STATUS = TaskStatus{"complete", "incomplete"}
-
-var RFC3339 = "2006-01-02T15:04:05Z07:00"
-
-type BoltManager interface {
-	Database() *bolt.DB
-	Ping() error
-	Close() error
+// Digest of the tasks created and completed in a month
+type Report struct {
+	Month     time.Time
+	Completed int
+	Created   int
+	// Completions per tag, most completed first
+	TopTags []TagCount
+	// The completed task that was open the longest, if any task was completed
+	LongestOpen *Task
 }
 
-// Implements BoltManager. Note that db is initially nil
-type connectionManager struct {
-	db *bolt.DB
+type TagCount struct {
+	Name  string
+	Count int
 }
 
-// Returns the currently connected db instance
-func (c *connectionManager) Database() *bolt.DB {
-	return c.db
+// Turn a count per tag name into TagCounts, most counted first and then by name
+func sortTagCounts(counts map[string]int) []TagCount {
+	var tags []TagCount
+	for name, n := range counts {
+		tags = append(tags, TagCount{name, n})
+	}
+	slices.SortFunc(tags, func(a, b TagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tags
 }
 
-// Validates the connection to db
-func (c *connectionManager) Ping() error {
-	db := c.db.String()
-	if db == `DB<"">` {
-		return fmt.Errorf("Could not establish connection. Pinged db: %s", db)
+// Number of tags listed in a report
+const reportTopTags = 3
+
+// Build the report for the month containing `month` from the `tasks` and `archive` buckets
+func monthlyReport(tasks, archive []TaskPosition, month time.Time) Report {
+	y, m, _ := month.Date()
+	start := time.Date(y, m, 1, 0, 0, 0, 0, month.Location())
+	period := Period{start, start.AddDate(0, 1, 0)}
+	r := Report{Month: start}
+
+	var longest time.Duration
+	tagCounts := map[string]int{}
+	for _, t := range append(slices.Clone(tasks), archive...) {
+		created, err := parseTimestamp(t.task.Created)
+		if err == nil && period.Contains(created) {
+			r.Created++
+		}
+		completed, err := parseTimestamp(t.task.Completed)
+		if err != nil || t.task.Status != STATUS.COMPLETE || !period.Contains(completed) {
+			continue
+		}
+		r.Completed++
+		for _, tag := range t.task.Tags {
+			tagCounts[tag]++
+		}
+		if open := completed.Sub(created); !created.IsZero() && open > longest {
+			longest = open
+			task := t.task
+			r.LongestOpen = &task
+		}
 	}
-	return nil
-}
 
-// Closes connection to the database
-func (c *connectionManager) Close() error {
-	return c.db.Close()
+	r.TopTags = sortTagCounts(tagCounts)
+	r.TopTags = r.TopTags[:min(len(r.TopTags), reportTopTags)]
+	return r
 }
 
-func newBoltManager() (*connectionManager, error) {
-	var connErr error
+// Render `r` as plain text, or as markdown if `markdown` is set
+func formatReport(r Report, markdown bool) string {
+	var tags []string
+	for _, t := range r.TopTags {
+		tags = append(tags, fmt.Sprintf("+%s (%d)", t.Name, t.Count))
+	}
+	topTags := strings.Join(tags, ", ")
+	if topTags == "" {
+		topTags = "-"
+	}
+	longest := "-"
+	if r.LongestOpen != nil {
+		created, _ := parseTimestamp(r.LongestOpen.Created)
+		completed, _ := parseTimestamp(r.LongestOpen.Completed)
+		longest = fmt.Sprintf(tr(`"%s" (%d days)`), r.LongestOpen.Desc, int(completed.Sub(created).Hours()/24))
+	}
+
+	title := fmt.Sprintf(tr("Report for %s"), r.Month.Format("2006-01"))
+	rows := [][]string{
+		{tr("Completed:"), strconv.Itoa(r.Completed)},
+		{tr("Created:"), strconv.Itoa(r.Created)},
+		{tr("Backlog change:"), fmt.Sprintf("%+d", r.Created-r.Completed)},
+		{tr("Top tags:"), topTags},
+		{tr("Longest open:"), longest},
+	}
 
-	mgr := &connectionManager{}
-	mgr.db = newBoltConnection()
-	if err := mgr.Ping(); err != nil {
-		connErr = err
+	if markdown {
+		lines := []string{"# " + title, ""}
+		for _, row := range rows {
+			lines = append(lines, fmt.Sprintf("- **%s** %s", row[0], row[1]))
+		}
+		return strings.Join(lines, "\n")
 	}
-	return mgr, connErr
+	return title + "\n" + formatTable(rows)
 }
 
-// Returns a db instance
-func newBoltConnection() *bolt.DB {
-	hDir, e := os.UserHomeDir()
-	check(e)
+// Returns the total points of `tasks`
+func sumPoints(tasks []TaskPosition) int {
+	total := 0
+	for _, t := range tasks {
+		total += t.task.Points
+	}
+	return total
+}
 
-	// default is "/task"
-	path := hDir + "/task"
+// Pace of completions and the open tasks it has to get through
+type Forecast struct {
+	// Completed tasks per day
+	Velocity float64
+	// Number of open tasks
+	Backlog int
+	// When the backlog is cleared. Zero if it never is at the current velocity
+	Clear time.Time
+	// Points of the completed tasks per day and of the open tasks, for estimated tasks
+	PointsVelocity float64
+	BacklogPoints  int
+}
 
-	// creates the `task` dir if it doesn't exist
-	dErr := os.MkdirAll(path, 0777)
-	check(dErr)
+// Measure the velocity over the `window` days before `now` from the `archive` and forecast
+// when the open `tasks` are done. If `tag` is not empty only tasks carrying it are considered
+func forecast(tasks, archive []TaskPosition, tag string, window int, now time.Time) Forecast {
+	var f Forecast
+	matches := func(t Task) bool {
+		return tag == "" || slices.Contains(t.Tags, tag)
+	}
 
-	// default is "/tasks.db"
-	db, err := bolt.Open(path+"/tasks.db", 0600, &bolt.Options{Timeout: 1 * time.Second})
-	check(err)
+	period := Period{now.AddDate(0, 0, -window), now}
+	completed, points := 0, 0
+	for _, t := range archive {
+		c, err := parseTimestamp(t.task.Completed)
+		if err == nil && t.task.Status == STATUS.COMPLETE && period.Contains(c) && matches(t.task) {
+			completed++
+			points += t.task.Points
+		}
+	}
+	f.Velocity = float64(completed) / float64(window)
+	f.PointsVelocity = float64(points) / float64(window)
 
-	return db
+	for _, t := range tasks {
+		if !isDone(t.task) && matches(t.task) {
+			f.Backlog++
+			f.BacklogPoints += t.task.Points
+		}
+	}
+	if f.Velocity > 0 {
+		days := float64(f.Backlog) / f.Velocity
+		f.Clear = now.Add(time.Duration(days * float64(24*time.Hour)))
+	}
+	return f
+}
+
+// Render the `archive` under a header for each day tasks were completed on, most recent
+// day first. Tasks are listed in the order they were completed in
+func formatArchiveByDay(archive []TaskPosition) string {
+	type entry struct {
+		completed time.Time
+		t         TaskPosition
+	}
+	var days []string
+	byDay := map[string][]entry{}
+	for _, t := range archive {
+		completed, err := parseTimestamp(t.task.Completed)
+		day := ""
+		if err == nil {
+			completed = completed.Local()
+			day = completed.Format("2006-01-02")
+		}
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], entry{completed, t})
+	}
+	// Tasks without a completion date have an empty day and end up last
+	slices.Sort(days)
+	slices.Reverse(days)
+
+	var sections []string
+	for _, day := range days {
+		header := tr("Unknown date")
+		if d, err := time.Parse("2006-01-02", day); err == nil {
+			header = formatDate(d)
+		}
+		entries := byDay[day]
+		slices.SortStableFunc(entries, func(a, b entry) int {
+			return a.completed.Compare(b.completed)
+		})
+		lines := []string{header}
+		for _, e := range entries {
+			line := fmt.Sprintf("  %d: %s", e.t.dbKey, e.t.task.Desc)
+			// Completed tasks are the norm, other resolutions stand out
+			if closedIncomplete(e.t.task) {
+				line += " " + statusIcon(e.t.task)
+			}
+			lines = append(lines, line)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// Render the number of completions, the first, last and busiest completion days and the
+// completions per tag of the `archive`
+func formatArchiveSummary(archive []TaskPosition) string {
+	var first, last time.Time
+	days := map[string]int{}
+	tagCounts := map[string]int{}
+	// Tasks finished in other done states, such as cancelled, weren't completed
+	completions := 0
+	otherDone := map[string]int{}
+	for _, t := range archive {
+		if closedIncomplete(t.task) {
+			otherDone[t.task.Status]++
+			continue
+		}
+		completions++
+		for _, tag := range t.task.Tags {
+			tagCounts[tag]++
+		}
+		completed, err := parseTimestamp(t.task.Completed)
+		if err != nil {
+			continue
+		}
+		completed = completed.Local()
+		if first.IsZero() || completed.Before(first) {
+			first = completed
+		}
+		if completed.After(last) {
+			last = completed
+		}
+		days[completed.Format("2006-01-02")]++
+	}
+
+	// The earliest of the days with the most completions
+	busiest := "-"
+	most := 0
+	for day, n := range days {
+		if n > most || (n == most && day < busiest) {
+			busiest, most = day, n
+		}
+	}
+	if most > 0 {
+		d, _ := time.Parse("2006-01-02", busiest)
+		busiest = fmt.Sprintf(tr("%s (%d tasks)"), formatDate(d), most)
+	}
+
+	firstDay, lastDay := "-", "-"
+	if !first.IsZero() {
+		firstDay, lastDay = formatDate(first), formatDate(last)
+	}
+	rows := [][]string{
+		{tr("Completions:"), strconv.Itoa(completions)},
+		{tr("First completion:"), firstDay},
+		{tr("Last completion:"), lastDay},
+		{tr("Busiest day:"), busiest},
+	}
+	for _, s := range config.Statuses {
+		if otherDone[s.Name] > 0 {
+			rows = append(rows, []string{s.Name + ":", strconv.Itoa(otherDone[s.Name])})
+		}
+	}
+
+	tags := sortTagCounts(tagCounts)
+	if len(tags) > 0 {
+		rows = append(rows, []string{tr("Per tag:"), ""})
+		for _, t := range tags {
+			rows = append(rows, []string{"  +" + t.Name, strconv.Itoa(t.Count)})
+		}
+	}
+	return formatTable(rows)
+}
+
+// Write `tasks` to `w` in `format`, either "json" or "csv"
+func exportTasks(w io.Writer, tasks []Task, format string) error {
+	switch format {
+	case "json":
+		if tasks == nil {
+			tasks = []Task{}
+		}
+		buf, err := json.MarshalIndent(tasks, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(buf))
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"description", "status", "created", "completed", "due", "tags"})
+		for _, t := range tasks {
+			cw.Write([]string{t.Desc, t.Status, t.Created, t.Completed, t.Due, strings.Join(t.Tags, " ")})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf(tr(`Invalid format "%s", must be "json" or "csv"`), format)
+	}
+}
+
+// Build the period covering the days from `start` to `end`, both mm/dd/yyyy formated and
+// inclusive. A missing start or end leaves the period open on that side
+func parsePeriod(start, end string) (Period, error) {
+	// Zero time up to a time no task is completed after
+	p := Period{End: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if start != "" {
+		s, err := parseDueDate(start)
+		if err != nil {
+			return p, fmt.Errorf(tr(`Invalid date "%s", must be in the format mm/dd/yyyy`), start)
+		}
+		p.Start = s
+	}
+	if end != "" {
+		e, err := parseDueDate(end)
+		if err != nil {
+			return p, fmt.Errorf(tr(`Invalid date "%s", must be in the format mm/dd/yyyy`), end)
+		}
+		p.End = lastTick(e)
+	}
+	if p.End.Before(p.Start) {
+		return p, errors.New(tr("The end date is before the start date"))
+	}
+	return p, nil
+}
+
+// A span of time from Start up to End
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Returns the periods compared by `stats --compare=value`. "week" compares the week so far,
+// starting on Monday, with the whole previous week. Otherwise `value` is a mm/dd/yyyy-mm/dd/yyyy
+// range that `current` is compared with
+func comparedPeriods(value string, current Period, now time.Time) (Period, Period, error) {
+	if value == "week" {
+		y, m, d := now.Date()
+		monday := time.Date(y, m, d-(int(now.Weekday())+6)%7, 0, 0, 0, 0, now.Location())
+		return Period{monday.AddDate(0, 0, -7), monday}, Period{monday, now}, nil
+	}
+
+	start, end, found := strings.Cut(value, "-")
+	if !found {
+		return Period{}, Period{}, fmt.Errorf(tr(`"%s" is not a mm/dd/yyyy-mm/dd/yyyy range`), value)
+	}
+	s, err := time.ParseInLocation("01/02/2006", start, time.Local)
+	if err != nil {
+		return Period{}, Period{}, err
+	}
+	e, err := time.ParseInLocation("01/02/2006", end, time.Local)
+	if err != nil {
+		return Period{}, Period{}, err
+	}
+	return Period{s, lastTick(e)}, current, nil
+}
+
+// Reports whether `t` falls within the period
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Number of days in the period, including partial days
+func (p Period) Days() float64 {
+	return p.End.Sub(p.Start).Hours() / 24
+}
+
+func (p Period) String() string {
+	// End isn't part of the period
+	return formatDate(p.Start) + "-" + formatDate(p.End.Add(-time.Nanosecond))
+}
+
+// Render completions, the average per day and completions per tag of the `archive` tasks
+// completed in `before` and `after` side by side, along with the change between them
+func formatComparison(archive []TaskPosition, before, after Period) string {
+	type counts struct {
+		total int
+		tags  map[string]int
+	}
+	count := func(p Period) counts {
+		c := counts{tags: map[string]int{}}
+		for _, t := range archive {
+			completed, err := parseTimestamp(t.task.Completed)
+			if err != nil || !p.Contains(completed) {
+				continue
+			}
+			c.total++
+			for _, tag := range t.task.Tags {
+				c.tags[tag]++
+			}
+		}
+		return c
+	}
+	b, a := count(before), count(after)
+
+	var tags []string
+	for tag := range b.tags {
+		tags = append(tags, tag)
+	}
+	for tag := range a.tags {
+		if _, ok := b.tags[tag]; !ok {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+
+	avgBefore, avgAfter := 0.0, 0.0
+	if before.Days() > 0 {
+		avgBefore = float64(b.total) / before.Days()
+	}
+	if after.Days() > 0 {
+		avgAfter = float64(a.total) / after.Days()
+	}
+	rows := [][]string{
+		{"", before.String(), after.String(), tr("Change")},
+		{tr("Completions"), strconv.Itoa(b.total), strconv.Itoa(a.total), fmt.Sprintf("%+d", a.total-b.total)},
+		{tr("Average/day"), fmt.Sprintf("%.1f", avgBefore), fmt.Sprintf("%.1f", avgAfter), fmt.Sprintf("%+.1f", avgAfter-avgBefore)},
+	}
+	for _, tag := range tags {
+		rows = append(rows, []string{"+" + tag, strconv.Itoa(b.tags[tag]), strconv.Itoa(a.tags[tag]), fmt.Sprintf("%+d", a.tags[tag]-b.tags[tag])})
+	}
+	return formatTable(rows)
+}
+
+// Render `rows` with every column padded to its widest cell
+func formatTable(rows [][]string) string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], textWidth(cell))
+		}
+	}
+
+	var lines []string
+	for _, row := range rows {
+		var cells []string
+		for i, cell := range row {
+			cells = append(cells, padRight(cell, widths[i]))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Render the number of tasks and the average, median and 90th percentile time from
+// creation to completion of `tasks`, overall and per tag
+func formatLeadTimes(tasks []TaskPosition) string {
+	all := tr("All")
+	byTag := map[string][]time.Duration{}
+	for _, t := range tasks {
+		created, err := parseTimestamp(t.task.Created)
+		if err != nil {
+			continue
+		}
+		completed, err := parseTimestamp(t.task.Completed)
+		if err != nil {
+			continue
+		}
+		lead := completed.Sub(created)
+		byTag[all] = append(byTag[all], lead)
+		for _, tag := range t.task.Tags {
+			byTag["+"+tag] = append(byTag["+"+tag], lead)
+		}
+	}
+	if len(byTag) == 0 {
+		return tr("No completed tasks")
+	}
+
+	var tags []string
+	for tag := range byTag {
+		if tag != all {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+
+	rows := [][]string{{"", tr("Tasks"), tr("Average"), tr("Median"), "p90"}}
+	for _, tag := range append([]string{all}, tags...) {
+		leads := byTag[tag]
+		slices.Sort(leads)
+		var sum time.Duration
+		for _, l := range leads {
+			sum += l
+		}
+		rows = append(rows, []string{
+			tag,
+			strconv.Itoa(len(leads)),
+			formatDuration(sum / time.Duration(len(leads))),
+			formatDuration(percentile(leads, 50)),
+			formatDuration(percentile(leads, 90)),
+		})
+	}
+	return formatTable(rows)
+}
+
+// Returns the `p`th percentile of the sorted, non-empty `values` using the nearest rank
+func percentile(values []time.Duration, p int) time.Duration {
+	rank := (p*len(values) + 99) / 100
+	return values[max(rank, 1)-1]
+}
+
+// Format `d` in minutes, hours or days, whichever reads best
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	default:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	}
+}
+
+// Width of the longest bar in a histogram
+const histogramWidth = 30
+
+// Render histograms of the local hour of day and weekday of each completion time
+func formatPattern(completed []time.Time) string {
+	var hours [24]int
+	var days [7]int
+	for _, c := range completed {
+		c = c.Local()
+		hours[c.Hour()]++
+		days[c.Weekday()]++
+	}
+
+	var builder strings.Builder
+	builder.WriteString(tr("By hour of day") + "\n")
+	for h, n := range hours {
+		builder.WriteString(histogramRow(fmt.Sprintf("%02d", h), n, slices.Max(hours[:])))
+	}
+	builder.WriteString("\n" + tr("By weekday") + "\n")
+	// Weeks start on Monday
+	names := []string{tr("Mon"), tr("Tue"), tr("Wed"), tr("Thu"), tr("Fri"), tr("Sat"), tr("Sun")}
+	width := 0
+	for _, name := range names {
+		width = max(width, textWidth(name))
+	}
+	for i, name := range names {
+		d := time.Weekday((i + 1) % 7)
+		builder.WriteString(histogramRow(padRight(name, width), days[d], slices.Max(days[:])))
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// A labeled bar scaled so `most` fills histogramWidth
+func histogramRow(label string, n, most int) string {
+	bar := 0
+	if most > 0 {
+		bar = n * histogramWidth / most
+	}
+	if n > 0 {
+		// Never hide a non-zero count
+		bar = max(bar, 1)
+		return fmt.Sprintf("%s | %s %d\n", label, strings.Repeat("█", bar), n)
+	}
+	return fmt.Sprintf("%s |\n", label)
+}
+
+// The number of tasks created and completed up to the end of a day, week or month
+type BurnupPoint struct {
+	Start     time.Time
+	Created   int
+	Completed int
+}
+
+// Returns the running totals of created and completed `tasks` from `start` to `end`, per day for
+// up to a month, per week for up to half a year and per month beyond that. Tasks closed in
+// other done states, such as cancelled, are left out, so the gap between the totals is the
+// number of open tasks
+func burnup(tasks []TaskPosition, start, end time.Time) []BurnupPoint {
+	var created, completed []time.Time
+	for _, t := range tasks {
+		if closedIncomplete(t.task) {
+			continue
+		}
+		if c, err := parseTimestamp(t.task.Created); err == nil {
+			created = append(created, c)
+		}
+		if c, err := parseTimestamp(t.task.Completed); err == nil {
+			completed = append(completed, c)
+		}
+	}
+	countUntil := func(times []time.Time, until time.Time) int {
+		n := 0
+		for _, t := range times {
+			if t.Before(until) {
+				n++
+			}
+		}
+		return n
+	}
+
+	next := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	days := end.Sub(start).Hours() / 24
+	switch {
+	case days > 183:
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	case days > 31:
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	}
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	var points []BurnupPoint
+	for t := start; !t.After(end); t = next(t) {
+		until := next(t)
+		points = append(points, BurnupPoint{t, countUntil(created, until), countUntil(completed, until)})
+	}
+	return points
+}
+
+// Returns the number of `tasks` completed on each day from the day of `start` to the day of
+// `end`, in local time
+func completionsByDay(tasks []TaskPosition, start, end time.Time) []int {
+	start, end = start.Local(), end.Local()
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	var days []string
+	for day := first; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+	}
+	counts := make([]int, len(days))
+	for _, t := range tasks {
+		completed, err := parseTimestamp(t.task.Completed)
+		if err != nil {
+			continue
+		}
+		if i := slices.Index(days, completed.Local().Format("2006-01-02")); i >= 0 {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// Render `counts` as a sparkline, one block per count scaled to the largest one. Zero is the
+// lowest block, so any other count stands out from it
+func sparkline(counts []int) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	most := 0
+	for _, n := range counts {
+		most = max(most, n)
+	}
+	var builder strings.Builder
+	for _, n := range counts {
+		level := 0
+		if n > 0 {
+			level = len(blocks) - 1
+			if most > 1 {
+				level = 1 + (n-1)*(len(blocks)-2)/(most-1)
+			}
+		}
+		builder.WriteRune(blocks[level])
+	}
+	return builder.String()
+}
+
+// Render `points` as bars scaled so the most created tasks fill histogramWidth. The completed
+// tasks are drawn with █ and the tasks still open with ░
+func formatBurnup(points []BurnupPoint) string {
+	most := 0
+	for _, p := range points {
+		most = max(most, p.Created)
+	}
+	scale := func(n int) int {
+		if most == 0 {
+			return 0
+		}
+		return n * histogramWidth / most
+	}
+
+	// Dates in some layouts, such as "Jan 2", vary in width
+	labelWidth := 0
+	for _, p := range points {
+		labelWidth = max(labelWidth, textWidth(formatDate(p.Start)))
+	}
+
+	var builder strings.Builder
+	builder.WriteString(tr("Burnup: completed █, open ░") + "\n")
+	for _, p := range points {
+		done := scale(p.Completed)
+		open := scale(p.Created) - done
+		builder.WriteString(fmt.Sprintf("%s | %s%s %d/%d\n", padRight(formatDate(p.Start), labelWidth), strings.Repeat("█", done), strings.Repeat("░", open), p.Completed, p.Created))
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// Flags
+// $ task (every command)
+var ReadOnly bool
+var NoColor bool
+var RelativeDates bool
+
+// $ add
+var DueDate string
+var AddFromClipboard bool
+var AddWithEditor bool
+var AddPriority string
+var AddParent string
+var AddPoints int
+var AddAssignee string
+
+// $ count
+var CountTag string
+var CountStatus string
+var CountOverdue bool
+var CountArchive bool
+var CountJSON bool
+
+// $ dup
+var DupCount int
+
+// $ show
+var ShowHistory bool
+
+// $ open
+var OpenAttachment int
+
+// $ attach
+var CopyAttachment bool
+
+// $ finish
+var FinishTag string
+
+// $ clear
+var ClearTag string
+var ClearCompleted bool
+
+// $ delete
+var DeleteTag string
+var DeleteYes bool
+var DeleteInteractive bool
+
+// $ sort
+var SortInteractive bool
+
+// $ edit
+var EditAll bool
+
+// $ archive
+var ClearArchive bool
+var GroupByDay bool
+
+// $ list
+var ShowTags bool
+var ExcludeTags string
+var MatchAnyTag bool
+var MatchAllTags bool
+var ShowAge bool
+var AgeThreshold int
+var ListGroup string
+var ListTree bool
+var ListStatus string
+var ListBlocked bool
+var ShowAnnotations bool
+var NoDefaultFilter bool
+var ListAssignee string
+var ListIDs bool
+var ListPrint0 bool
+var ListQuiet bool
+
+// $ block
+var BlockReason string
+
+// $ update
+var UpdatedDesc string
+var UpdateStatus bool
+var UpdateAddTags string
+var UpdateRemoveTags string
+var UpdateDue string
+var UpdateNoDue bool
+var UpdatePriority string
+var UpdateParent string
+var UpdatePoints int
+var UpdateAssignee string
+
+// $ do
+var DeleteOnDo bool
+var DoAll bool
+var DoTag string
+
+// $ cancel
+var FinishOnCancel bool
+
+// $ tags
+var TagCounts bool
+
+// $ stats
+var StartTime string
+var EndTime string
+var OnDay string
+var ShowCompleted bool
+var ShowAverage bool
+var ShowPattern bool
+var CompareWith string
+var ShowLeadTime bool
+var StatsTags string
+var ShowBurnup bool
+var ShowSparkline bool
+
+// $ matrix
+var MatrixDays int
+
+// $ timew
+var TimewWrite bool
+
+// $ sync reminders
+var SyncList string
+
+// $ remind
+var RemindLead time.Duration
+var RemindInterval time.Duration
+var RemindOnce bool
+
+// $ backup
+var BackupKeep int
+
+// $ statusline
+var StatuslineFormat string
+var StatuslineTmux bool
+
+// $ prompt
+var PromptJSON bool
+
+// $ api
+var APIStdio bool
+
+// $ import
+var ImportFormat string
+var ImportMap string
+var ImportHeader bool
+var ImportArchiveDone bool
+var ImportDryRun bool
+var ImportSkipDuplicates bool
+var ImportOverwrite bool
+
+// $ archive export
+var ExportFormat string
+var ExportStart string
+var ExportEnd string
+
+// $ export
+var TaskExportFormat string
+var ExportPrint bool
+var ExportPageLength int
+var ExportTags string
+var ExportStatus string
+var ExportSince string
+
+// $ archive restore
+var RestoreSince string
+var RestoreUntil string
+
+// $ forecast
+var ForecastTag string
+var ForecastWindow int
+
+// $ report
+var ReportFormat string
+var ReportOut string
+var ReportStart string
+var ReportEnd string
+
+// $ report monthly
+var ReportMonth string
+var ReportMarkdown bool
+
+// $ report weekly
+var ReportEmail bool
+
+// $ db load
+var LoadYes bool
+
+// $ db merge
+var MergeDryRun bool
+var MergeSkipDuplicates bool
+var MergeOverwrite bool
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	// Saved views are expanded before the command runs. An invalid config is reported once
+	// the command opens the database
+	if dir, err := taskDir(); err == nil {
+		if c, err := loadConfig(dir); err == nil {
+			config = c
+		}
+	}
+	args, filter, err := parseCommandLine(rootCmd, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(filteredCommand(rootCmd, os.Args[1:]), err))
+	}
+	CommandFilter = filter
+	rootCmd.SetArgs(args)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		os.Exit(exitCode(cmd, err))
+	}
+}
+
+// Commands that exit like grep: 0 when tasks match, 1 when none do and 2 on errors
+var searchCommands = []string{"list", "count"}
+
+// Returned by the search commands when no task matches. The command has printed its output
+// already, the error only sets the exit status
+var errNoMatch = errors.New("no matching tasks")
+
+// Report that no task matched, without cobra printing the error
+func noMatch(cmd *cobra.Command) error {
+	cmd.SilenceErrors = true
+	return errNoMatch
+}
+
+// Exit status of `cmd` once it returned `err`
+func exitCode(cmd *cobra.Command, err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errNoMatch):
+		return 1
+	case cmd != nil && slices.Contains(searchCommands, cmd.Name()):
+		return 2
+	}
+	return 1
+}
+
+// The command run by `args` once the filter terms before it are skipped, which is list when
+// there is none
+func filteredCommand(root *cobra.Command, args []string) *cobra.Command {
+	args = expandView(args)
+	for len(args) > 0 && isFilterTerm(args[0]) {
+		args = args[1:]
+	}
+	if cmd, _, err := root.Find(args); err == nil && cmd != root {
+		return cmd
+	}
+	cmd, _, _ := root.Find([]string{"list"})
+	return cmd
+}
+
+// `task +tag ...` is shorthand for `task list +tag ...`
+func expandShorthand(args []string) []string {
+	if len(args) > 0 && strings.HasPrefix(args[0], "+") {
+		return append([]string{"list"}, args...)
+	}
+	return args
+}
+
+// Commands that never write to the db and can be used with --read-only
+var readOnlyCommands = []string{"list", "show", "open", "count", "tags", "stats", "report", "monthly", "forecast", "summary", "matrix", "timew", "remind", "backup", "statusline", "prompt", "export", "dump", "help"}
+
+// Commands that always open the database read-only. They start faster and can run alongside
+// each other, e.g. from a status bar refreshing every few seconds
+var sharedCommands = []string{"statusline", "prompt"}
+
+// Commands that open the database themselves, only while they need it
+var selfOpeningCommands = []string{"remind", "api"}
+
+// Returns an error if `cmd` can't run with the --read-only flag or the filter it was given
+func checkCommand(cmd *cobra.Command) error {
+	if ReadOnly && !slices.Contains(readOnlyCommands, cmd.Name()) {
+		return fmt.Errorf(tr("%s can't be used with --read-only"), cmd.Name())
+	}
+	return checkFilter(cmd)
+}
+
+// Open the database for `cmd` once its flags are parsed, so --read-only can be honored
+func openDatabase(mgr *connectionManager, cmd *cobra.Command, args []string) error {
+	// Failing to open the db isn't a usage error
+	cmd.SilenceUsage = true
+	if err := checkCommand(cmd); err != nil {
+		return err
+	}
+	dir, err := taskDir()
+	if err != nil {
+		return err
+	}
+	if config, err = loadConfig(dir); err != nil {
+		return err
+	}
+	if err := setTimezone(config.Timezone); err != nil {
+		return err
+	}
+	if slices.Contains(selfOpeningCommands, cmd.Name()) {
+		return nil
+	}
+	// The daemon's subcommands complete without the db, which a running daemon holds
+	if completesDaemon(append([]string{cmd.Name()}, args...)) {
+		return nil
+	}
+	readOnly := ReadOnly
+	// A database that doesn't exist yet can't be opened read-only
+	if _, err := os.Stat(dbPath(dir)); err == nil && slices.Contains(sharedCommands, cmd.Name()) {
+		readOnly = true
+	}
+	return mgr.Open(readOnly)
+}
+
+func init() {
+	// Here you will define your flags and configuration settings.
+	// Cobra supports persistent flags, which, if defined here,
+	// will be global for your application.
+
+	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.task-cli.yaml)")
+
+	// Cobra also supports local flags, which will only run
+	// when this action is called directly.
+	// rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
+
+var TASKS_BUCKET = []byte("tasks")
+var ARCHIVE_BUCKET = []byte("archive")
+var STATUS = TaskStatus{
+	COMPLETE:    "complete",
+	INCOMPLETE:  "incomplete",
+	IN_PROGRESS: "in-progress",
+	BLOCKED:     "blocked",
+	CANCELLED:   "cancelled",
+}
+
+// Task priorities, highest first
+var PRIORITIES = []string{"high", "med", "low"}
+
+var RFC3339 = "2006-01-02T15:04:05Z07:00"
+
+type BoltManager interface {
+	Database() *bolt.DB
+	Ping() error
+	Close() error
+}
+
+// Implements BoltManager. Note that db is initially nil
+type connectionManager struct {
+	db *bolt.DB
+}
+
+// Returns the currently connected db instance
+func (c *connectionManager) Database() *bolt.DB {
+	return c.db
+}
+
+// Validates the connection to db
+func (c *connectionManager) Ping() error {
+	db := c.db.String()
+	if db == `DB<"">` {
+		return fmt.Errorf("Could not establish connection. Pinged db: %s", db)
+	}
+	return nil
+}
+
+// Closes connection to the database, if one was opened
+func (c *connectionManager) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	err := c.db.Close()
+	c.db = nil
+	return err
+}
+
+// Connects to the database in the task directory and initializes the buckets.
+// A read-only connection can share the database with other read-only connections
+func (c *connectionManager) Open(readOnly bool) error {
+	dir, err := taskDir()
+	if err != nil {
+		return err
+	}
+	db, err := newBoltConnection(dir, readOnly)
+	if err != nil {
+		return err
+	}
+	c.db = db
+	if err := c.Ping(); err != nil {
+		return err
+	}
+
+	// buckets can't be created in a read-only transaction, any
+	// missing bucket is treated as empty instead
+	if readOnly {
+		return nil
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		tx.CreateBucketIfNotExists(TASKS_BUCKET)
+		tx.CreateBucketIfNotExists(ARCHIVE_BUCKET)
+		return assignUUIDs(tx, TASKS_BUCKET, ARCHIVE_BUCKET)
+	})
+}
+
+// Returns a manager whose connection is opened later by Open
+func newBoltManager() *connectionManager {
+	return &connectionManager{}
+}
+
+// Returns the directory holding the database and other task files
+// The home directory is $HOME on Unix and %USERPROFILE% on Windows
+func taskDir() (string, error) {
+	hDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	// default is "~/task"
+	return filepath.Join(hDir, "task"), nil
+}
+
+// Returns the path of the database file inside `dir`, or the path set with $TASK_DB, e.g. to
+// give a CI job a database of its own
+func dbPath(dir string) string {
+	if path := os.Getenv("TASK_DB"); path != "" {
+		// The daemon listens next to the database, so the path must not depend on the
+		// working directory
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return path
+	}
+	// default is "tasks.db"
+	return filepath.Join(dir, "tasks.db")
+}
+
+// Returns a db instance for the database in `dir`. If another process holds the lock
+// on the database for more than a second, returns an error naming the likely holders
+func newBoltConnection(dir string, readOnly bool) (*bolt.DB, error) {
+	// creates the `task` dir if it doesn't exist, and the directory of $TASK_DB. The
+	// permissions are ignored on Windows
+	for _, d := range []string{dir, filepath.Dir(dbPath(dir))} {
+		if err := os.MkdirAll(d, 0777); err != nil {
+			return nil, err
+		}
+	}
+
+	// bolt locks the file with flock on Unix and LockFileEx on Windows, so
+	// a second process waits for the lock on both
+	path := dbPath(dir)
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: readOnly})
+	if err == bolt.ErrTimeout {
+		if holders := lockHolders(path); len(holders) > 0 {
+			return nil, fmt.Errorf(tr("The database at %s is locked by another process, likely %s. Close it and try again"), path, strings.Join(holders, ", "))
+		}
+		return nil, fmt.Errorf(tr("The database at %s is locked by another process. Close it and try again"), path)
+	}
+	return db, err
 }
 
 type TaskStatus struct {
-	COMPLETE   string
-	INCOMPLETE string
+	COMPLETE    string
+	INCOMPLETE  string
+	IN_PROGRESS string
+	BLOCKED     string
+	CANCELLED   string
 }
 
 type Task struct {
@@ -593,7 +3121,62 @@ type Task struct {
 	Status    string
 	Created   string
 	Completed string
-	Tag       string
+	Tags      []string
+	Due       string
+	// Position of the task when displayed, independent of its key. Tasks created
+	// before ordering existed have an Order of 0 and are displayed first, by key
+	Order int
+	// Paths of files linked to the task
+	Attachments []string
+	// One of PRIORITIES, empty if the task has no priority
+	Priority string
+	// Random identifier that, unlike the key, never changes
+	UUID string
+	// UUID of the task this is a subtask of, empty for top level tasks
+	Parent string
+	// Time spent in progress, logged while time tracking is on
+	Intervals []Interval
+	// Why the task is blocked, cleared once it's no longer blocked
+	BlockedReason string
+	// Notes added with `comment`, oldest first
+	Comments []Comment
+	// Estimated effort in relative units such as story points, 0 if the task isn't estimated
+	Points int
+	// Who the task is assigned to, empty if it's unassigned
+	Assignee string
+	// Changes made to the task since it was created, oldest first
+	History []Change
+}
+
+// A timestamped note on a task
+type Comment struct {
+	Time string
+	Text string
+}
+
+// A span of time spent on a task
+type Interval struct {
+	Start string
+	// Empty while the task is in progress
+	End string
+}
+
+// Decode a task, migrating the single `Tag` field written by older versions to `Tags`
+func (t *Task) UnmarshalJSON(b []byte) error {
+	// task has the same fields as Task but none of its methods, avoiding recursion
+	type task Task
+	var legacy struct {
+		task
+		Tag string
+	}
+	if err := json.Unmarshal(b, &legacy); err != nil {
+		return err
+	}
+	*t = Task(legacy.task)
+	if len(t.Tags) == 0 && legacy.Tag != "" {
+		t.Tags = []string{legacy.Tag}
+	}
+	return nil
 }
 
 type TaskPosition struct {
@@ -611,7 +3194,7 @@ func check(e error) {
 // tags removed. If no tags are found, returns an empty slice and the original string. Always returns ([]tags, s)
 func parseTags(s string) ([]string, string) {
 	// Matches substrings in the form "+text" Captures "text".
-	re := regexp.MustCompile(`\+([^ ]+)`)
+	re := regexp.MustCompile(`\+([^\s]+)`)
 	var tags []string
 	parsed := s
 
@@ -634,24 +3217,77 @@ func parseTags(s string) ([]string, string) {
 	return tags, strings.TrimSpace(parsed)
 }
 
+// Returns a new incomplete task created now
+func newTask(s string, tags []string) Task {
+	return Task{
+		Desc:      s,
+		Status:    STATUS.INCOMPLETE,
+		Created:   timestamp(time.Now()),
+		Completed: "",
+		Tags:      tags,
+	}
+}
+
+// Returns a copy of `t` as a new incomplete task created now
+func cloneTask(t Task) Task {
+	c := t
+	c.Status = STATUS.INCOMPLETE
+	c.Created = timestamp(time.Now())
+	c.Completed = ""
+	c.Tags = slices.Clone(t.Tags)
+	c.Attachments = slices.Clone(t.Attachments)
+	c.Order = 0
+	c.UUID = ""
+	c.Intervals = nil
+	c.Comments = nil
+	c.History = nil
+	return c
+}
+
 // Opens an Update transaction with `db`, creates a Task from `s` and inserts the task into `bucket`
-func insert(db *bolt.DB, bucket []byte, s string, tag string) error {
-	err := db.Update(func(tx *bolt.Tx) error {
-		b, err := tx.CreateBucketIfNotExists(bucket)
-		if err != nil {
-			return err
-		}
+func insert(db *bolt.DB, bucket []byte, s string, tags []string) error {
+	return insertTask(db, bucket, newTask(s, tags))
+}
+
+// Opens an Update transaction with `db` and inserts `task` into `bucket`
+func insertTask(db *bolt.DB, bucket []byte, task Task) error {
+	return insertTasks(db, bucket, []Task{task})
+}
+
+// Opens a single Update transaction with `db` and inserts every task into `bucket`, in order
+func insertTasks(db *bolt.DB, bucket []byte, tasks []Task) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		return insertTasksTx(tx, bucket, tasks)
+	})
+}
+
+// Same as insertTasks, within the Update transaction `tx`
+func insertTasksTx(tx *bolt.Tx, bucket []byte, tasks []Task) error {
+	b, err := tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return err
+	}
+
+	// new tasks are displayed last
+	order := 0
+	if bytes.Equal(bucket, TASKS_BUCKET) {
+		b.ForEach(func(k, v []byte) error {
+			order = max(order, bToTask(v).Order)
+			return nil
+		})
+	}
 
+	for _, task := range tasks {
 		// create an id and convert it to a []byte
 		id, _ := b.NextSequence()
 		byteId := itob(int(id))
 
-		task := Task{
-			Desc:      s,
-			Status:    STATUS.INCOMPLETE,
-			Created:   time.Now().Format(RFC3339),
-			Completed: "",
-			Tag:       tag,
+		if bytes.Equal(bucket, TASKS_BUCKET) {
+			order++
+			task.Order = order
+		}
+		if task.UUID == "" {
+			task.UUID = newUUID()
 		}
 
 		// Marshal Task data into bytes.
@@ -659,10 +3295,46 @@ func insert(db *bolt.DB, bucket []byte, s string, tag string) error {
 		if err != nil {
 			return err
 		}
-		return b.Put(byteId, buf)
+		if err := b.Put(byteId, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Move the archived tasks completed within `period` back to the tasks bucket as incomplete
+// tasks, in a single transaction. Returns the restored tasks
+func restoreArchived(db *bolt.DB, period Period) ([]Task, error) {
+	var restored []Task
+	err := db.Update(func(tx *bolt.Tx) error {
+		archive := tx.Bucket(ARCHIVE_BUCKET)
+		if archive == nil {
+			return nil
+		}
+
+		var keys []int
+		archive.ForEach(func(k, v []byte) error {
+			t := bToTask(v)
+			completed, err := parseTimestamp(t.Completed)
+			if err != nil || !period.Contains(completed) {
+				return nil
+			}
+			t.Status = STATUS.INCOMPLETE
+			t.Completed = ""
+			keys = append(keys, btoi(k))
+			restored = append(restored, t)
+			return nil
+		})
+		if len(keys) == 0 {
+			return nil
+		}
 
+		if err := insertTasksTx(tx, TASKS_BUCKET, restored); err != nil {
+			return err
+		}
+		return deleteKeysTx(tx, keys, ARCHIVE_BUCKET)
 	})
-	return err
+	return restored, err
 }
 
 // Returns a slice containing all tasks in the database along with their respective positions.
@@ -670,6 +3342,9 @@ func getTasks(db *bolt.DB, bucket []byte) []TaskPosition {
 	var tasks []TaskPosition
 	db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
 		return b.ForEach(func(k, v []byte) error {
 			t := bToTask(v)
 			tasks = append(tasks, TaskPosition{
@@ -679,9 +3354,111 @@ func getTasks(db *bolt.DB, bucket []byte) []TaskPosition {
 			return nil
 		})
 	})
+	// Tasks are displayed in their own order rather than key order
+	if bytes.Equal(bucket, TASKS_BUCKET) {
+		sortByOrder(tasks)
+	}
 	return tasks
 }
 
+// Sort tasks by their display order, breaking ties by key
+func sortByOrder(tp []TaskPosition) {
+	slices.SortStableFunc(tp, func(a, b TaskPosition) int {
+		if a.task.Order != b.task.Order {
+			return a.task.Order - b.task.Order
+		}
+		return a.dbKey - b.dbKey
+	})
+}
+
+// Move the task with key `k` by `delta` positions in the display order.
+// Every task's order is rewritten as 1..N so legacy tasks get a position too
+func moveTask(db *bolt.DB, k int, delta int) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return errors.New("Tasks bucket does not exist")
+		}
+
+		var tp []TaskPosition
+		b.ForEach(func(k, v []byte) error {
+			tp = append(tp, TaskPosition{task: bToTask(v), dbKey: btoi(k)})
+			return nil
+		})
+		sortByOrder(tp)
+
+		from := slices.IndexFunc(tp, func(t TaskPosition) bool { return t.dbKey == k })
+		if from == -1 {
+			return fmt.Errorf(tr("Task %d does not exist"), k)
+		}
+		to := from + delta
+		if to < 0 || to >= len(tp) {
+			return fmt.Errorf(tr("Task %d can't be moved any further"), k)
+		}
+		tp[from], tp[to] = tp[to], tp[from]
+
+		for i, t := range tp {
+			t.task.Order = i + 1
+			buf, err := json.Marshal(t.task)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(t.dbKey), buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// A task whose ID changed from Old to New
+type Renumbered struct {
+	Old int
+	New int
+}
+
+// Rewrite the keys of the tasks bucket so they follow the display order, 1..N, in a single
+// transaction. Returns the tasks whose ID changed
+func renumberTasks(db *bolt.DB) ([]Renumbered, error) {
+	var changed []Renumbered
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return nil
+		}
+
+		var tp []TaskPosition
+		b.ForEach(func(k, v []byte) error {
+			tp = append(tp, TaskPosition{task: bToTask(v), dbKey: btoi(k)})
+			return nil
+		})
+		sortByOrder(tp)
+
+		if err := tx.DeleteBucket(TASKS_BUCKET); err != nil {
+			return err
+		}
+		newBucket, err := tx.CreateBucket(TASKS_BUCKET)
+		if err != nil {
+			return err
+		}
+		for i, t := range tp {
+			t.task.Order = i + 1
+			buf, err := json.Marshal(t.task)
+			if err != nil {
+				return err
+			}
+			if err := newBucket.Put(itob(i+1), buf); err != nil {
+				return err
+			}
+			if t.dbKey != i+1 {
+				changed = append(changed, Renumbered{t.dbKey, i + 1})
+			}
+		}
+		return newBucket.SetSequence(uint64(len(tp)))
+	})
+	return changed, err
+}
+
 // Retrieve a task by key. Returns an error if the task bucket does not exist or if the key does not exist.
 func getTask(db *bolt.DB, key int) (Task, error) {
 	var t Task
@@ -691,100 +3468,638 @@ func getTask(db *bolt.DB, key int) (Task, error) {
 			return errors.New("Task bucket does not exist")
 		}
 
-		buf := b.Get(itob(key))
-		if buf == nil {
-			return errors.New("Key does not exist")
-		}
+		buf := b.Get(itob(key))
+		if buf == nil {
+			return errors.New("Key does not exist")
+		}
+
+		t = bToTask(buf)
+		return nil
+	})
+	return t, err
+}
+
+// Update a task in the db. Returns an error if the tasks bucket does not exist,
+// if failed to marshal the task, or if failed to update the task in the db. If taskId does not exist
+// in the db, a new task will be created
+func updateTask(db *bolt.DB, taskId int, updated Task) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return errors.New("Tasks bucket does not exist")
+		}
+
+		if v := b.Get(itob(taskId)); v != nil {
+			recordChanges(bToTask(v), &updated, time.Now())
+		}
+		t, jsonErr := json.Marshal(updated)
+		if jsonErr != nil {
+			return errors.New("Failed to marshal updated task")
+		}
+
+		return b.Put(itob(taskId), t)
+	})
+}
+
+// Normalize the assignee name `s`, dropping a leading "@". "none" means no assignee
+func parseAssignee(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "@")
+	if strings.ToLower(s) == "none" {
+		return ""
+	}
+	return s
+}
+
+// Split a comma separated list of tags, dropping any leading "+"
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "+"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Apply `update` to the tasks with the given keys in a single transaction. Nothing is
+// changed if a task does not exist or `update` returns an error
+func updateTasks(db *bolt.DB, keys []int, update func(t *Task) error) error {
+	now := time.Now()
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return errors.New("Tasks bucket does not exist")
+		}
+
+		for _, k := range keys {
+			v := b.Get(itob(k))
+			if v == nil {
+				return fmt.Errorf(tr("Task %d does not exist"), k)
+			}
+			t := bToTask(v)
+			old := snapshot(t)
+			if err := update(&t); err != nil {
+				return err
+			}
+			recordChanges(old, &t, now)
+			buf, err := json.Marshal(t)
+			if err != nil {
+				return errors.New("Failed to marshal updated task")
+			}
+			if err := b.Put(itob(k), buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Filter tasks by tag. Returns a slice of tasks carrying any of the tags in `include`,
+// or all of them when `matchAll` is true. Tasks carrying a tag in `exclude` are dropped.
+// One of the []string must be empty i.e. can only include or exclude, can't do both.
+func filterTasks(tp []TaskPosition, include, exclude []string, matchAll bool) []TaskPosition {
+	// no tags to filter by, return tp
+	if len(include) == 0 && len(exclude) == 0 {
+		return tp
+	}
+
+	var filtered []TaskPosition
+
+	// First filter out any unwanted tasks
+	excludeNoTag := slices.Contains(exclude, "none")
+	for _, t := range tp {
+		if slices.ContainsFunc(t.task.Tags, func(tag string) bool { return slices.Contains(exclude, tag) }) {
+			continue
+		}
+		if len(t.task.Tags) == 0 && excludeNoTag {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+
+	var finalFilter []TaskPosition
+
+	// "none" tag can be used to filter tasks with no tag
+	for _, t := range filtered {
+		hasTag := func(tag string) bool {
+			if tag == "none" {
+				return len(t.task.Tags) == 0
+			}
+			return slices.Contains(t.task.Tags, tag)
+		}
+		if matchAll && !slices.ContainsFunc(include, func(tag string) bool { return !hasTag(tag) }) {
+			finalFilter = append(finalFilter, t)
+		}
+		if !matchAll && slices.ContainsFunc(include, hasTag) {
+			finalFilter = append(finalFilter, t)
+		}
+	}
+	if len(include) > 0 {
+		return finalFilter
+	}
+	return filtered
+}
+
+// Format the tasks in db, return the formatted string
+func formatTasks(tp []TaskPosition) string {
+	var builder strings.Builder
+
+	// Line up the tag column, accounting for wide characters
+	width := terminalWidth()
+	colored := !NoColor && colorOutput()
+	now := time.Now()
+	tagWidth := 0
+	keyWidth := 0
+	for _, t := range tp {
+		tagWidth = max(tagWidth, textWidth(displayTags(t.task)))
+		keyWidth = max(keyWidth, len(strconv.Itoa(t.dbKey)))
+	}
+
+	for idx, t := range tp {
+		s := statusIcon(t.task)
+
+		// Build the task strings.
+		// format: num. [tag: ] desc status [age] [\n]
+		prefix := padRight(fmt.Sprintf("%d:", t.dbKey), keyWidth+1) + " "
+		if ShowTags {
+			prefix += padRight(displayTags(t.task)+":", tagWidth+1) + " "
+		}
+		text := fmt.Sprintf("%s %s", t.task.Desc, s) + blockedNote(t.task)
+		if t.task.Assignee != "" {
+			text += " @" + t.task.Assignee
+		}
+		if ShowAge && !isDone(t.task) {
+			text += formatAge(t.task, now)
+		}
+
+		// Wrapped and multi-line descriptions are indented to line up with the first line
+		indent := strings.Repeat(" ", textWidth(prefix))
+		available := 0
+		if width > 0 {
+			available = max(width-len(indent), 10)
+		}
+		row := prefix + strings.Join(wrapText(text, available), "\n"+indent)
+		if colored {
+			row = colorize(row, rowColor(t.task, now))
+		}
+		builder.WriteString(row)
+		// The latest comment goes on its own line below the task
+		if c, ok := latestComment(t.task); ok && ShowAnnotations {
+			note := formatWhen(c.Time, false) + " " + c.Text
+			builder.WriteString("\n" + indent + strings.Join(wrapText(note, available), "\n"+indent))
+		}
+		//   Add a newline if it's not the last task
+		if idx < len(tp)-1 {
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
+}
+
+// Ways `list --group` can split the task list into sections
+var GROUPINGS = []string{"tag", "priority", "due"}
+
+// A section of the task list, see groupTasks
+type TaskGroup struct {
+	Name  string
+	Tasks []TaskPosition
+}
+
+// Split `tp` into sections according to `by`, one of GROUPINGS. Tasks keep their order within a
+// section. `now` decides which due dates are overdue, today or this week
+func groupTasks(tp []TaskPosition, by string, now time.Time) []TaskGroup {
+	var groups []TaskGroup
+	switch by {
+	case "tag":
+		// A task with several tags is listed under each of them, untagged tasks come last
+		byTag := map[string][]TaskPosition{}
+		var untagged []TaskPosition
+		for _, t := range tp {
+			if len(t.task.Tags) == 0 {
+				untagged = append(untagged, t)
+			}
+			for _, tag := range t.task.Tags {
+				byTag[tag] = append(byTag[tag], t)
+			}
+		}
+		var tags []string
+		for tag := range byTag {
+			tags = append(tags, tag)
+		}
+		slices.Sort(tags)
+		for _, tag := range tags {
+			groups = append(groups, TaskGroup{"+" + tag, byTag[tag]})
+		}
+		if len(untagged) > 0 {
+			groups = append(groups, TaskGroup{tr("Untagged"), untagged})
+		}
+	case "priority":
+		names := map[string]string{"high": tr("High"), "med": tr("Medium"), "low": tr("Low"), "": tr("No priority")}
+		for _, p := range append(slices.Clone(PRIORITIES), "") {
+			var tasks []TaskPosition
+			for _, t := range tp {
+				if t.task.Priority == p {
+					tasks = append(tasks, t)
+				}
+			}
+			if len(tasks) > 0 {
+				groups = append(groups, TaskGroup{names[p], tasks})
+			}
+		}
+	case "due":
+		names := []string{tr("Overdue"), tr("Today"), tr("This week"), tr("Later"), tr("No due date")}
+		sections := make([][]TaskPosition, len(names))
+		for _, t := range tp {
+			i := dueSection(t.task, now)
+			sections[i] = append(sections[i], t)
+		}
+		for i, tasks := range sections {
+			if len(tasks) > 0 {
+				groups = append(groups, TaskGroup{names[i], tasks})
+			}
+		}
+	}
+	return groups
+}
+
+// Returns the index of the `list --group due` section of `t`: 0 if it was due before today,
+// 1 if it's due today, 2 if it's due in the next 6 days, 3 if it's due later and 4 if it has no due date
+func dueSection(t Task, now time.Time) int {
+	due, err := parseTimestamp(t.Due)
+	if err != nil {
+		return 4
+	}
+	y, m, d := due.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	y, m, d = now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch {
+	case day.Before(today):
+		return 0
+	case day.Equal(today):
+		return 1
+	case day.Before(today.AddDate(0, 0, 7)):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Format each group as a heading, with the number of tasks in the group, followed by its tasks
+func formatGroups(groups []TaskGroup) string {
+	var sections []string
+	for _, g := range groups {
+		sections = append(sections, fmt.Sprintf("%s (%d)\n%s", g.Name, len(g.Tasks), formatTasks(g.Tasks)))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// Format tasks as a tree, subtasks below their parent. Parents show the share of their subtasks
+// that are complete. Tasks whose parent isn't in `tp` are shown at the top level
+func formatTree(tp []TaskPosition) string {
+	listed := map[string]bool{}
+	for _, t := range tp {
+		listed[t.task.UUID] = true
+	}
+	children := map[string][]TaskPosition{}
+	for _, t := range tp {
+		if t.task.Parent != "" && listed[t.task.Parent] {
+			children[t.task.Parent] = append(children[t.task.Parent], t)
+		}
+	}
+
+	width := terminalWidth()
+	var lines []string
+	visited := map[int]bool{}
+	// `branch` is drawn before the task, `indent` before its wrapped lines and its subtasks
+	var walk func(t TaskPosition, branch, indent string)
+	walk = func(t TaskPosition, branch, indent string) {
+		visited[t.dbKey] = true
+		subtasks := children[t.task.UUID]
+
+		text := fmt.Sprintf("%s %s", t.task.Desc, statusIcon(t.task)) + blockedNote(t.task)
+		if len(subtasks) > 0 {
+			done := 0
+			for _, c := range subtasks {
+				if isDone(c.task) {
+					done++
+				}
+			}
+			text += fmt.Sprintf(" %d%%", 100*done/len(subtasks))
+		}
+
+		key := fmt.Sprintf("%d: ", t.dbKey)
+		hang := indent + strings.Repeat(" ", len(key))
+		if len(subtasks) > 0 {
+			hang = indent + padRight("│", len(key))
+		}
+		available := 0
+		if width > 0 {
+			available = max(width-textWidth(hang), 10)
+		}
+		lines = append(lines, branch+key+strings.Join(wrapText(text, available), "\n"+hang))
+
+		for i, c := range subtasks {
+			if visited[c.dbKey] {
+				continue
+			}
+			if i == len(subtasks)-1 {
+				walk(c, indent+"└── ", indent+"    ")
+			} else {
+				walk(c, indent+"├── ", indent+"│   ")
+			}
+		}
+	}
+	for _, t := range tp {
+		if t.task.Parent == "" || !listed[t.task.Parent] {
+			walk(t, "", "")
+		}
+	}
+	// Tasks left are in a cycle of parents, which update prevents but an older db may contain
+	for _, t := range tp {
+		if !visited[t.dbKey] {
+			walk(t, "", "")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the most recent comment on `t`. False if `t` has no comments
+func latestComment(t Task) (Comment, bool) {
+	if len(t.Comments) == 0 {
+		return Comment{}, false
+	}
+	return slices.MaxFunc(t.Comments, func(a, b Comment) int {
+		return compareTimestamps(a.Time, b.Time)
+	}), true
+}
+
+// Matches http(s) URLs up to the next whitespace
+var urlRegex = regexp.MustCompile(`https?://[^\s]+`)
 
-		t = bToTask(buf)
-		return nil
-	})
-	return t, err
+// Returns the first URL in `s`, without trailing punctuation. Returns an empty string if `s` has no URL
+func findURL(s string) string {
+	return strings.TrimRight(urlRegex.FindString(s), ".,;:!?)\"'")
 }
 
-// Update a task in the db. Returns an error if the tasks bucket does not exist,
-// if failed to marshal the task, or if failed to update the task in the db. If taskId does not exist
-// in the db, a new task will be created
-func updateTask(db *bolt.DB, taskId int, updated Task) error {
-	return db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(TASKS_BUCKET)
-		if b == nil {
-			return errors.New("Tasks bucket does not exist")
-		}
+// Open `target` (a URL or a file path) with the default application of the OS.
+// A variable so tests can avoid launching a browser
+var openExternal = func(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	return cmd.Start()
+}
 
-		t, jsonErr := json.Marshal(updated)
-		if jsonErr != nil {
-			return errors.New("Failed to marshal updated task")
+// Returns the text in the system clipboard.
+// A variable so tests can avoid depending on the system clipboard
+var readClipboard = func() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbpaste")
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "Get-Clipboard")
+	default:
+		// Try the Wayland clipboard before the X11 tools
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			cmd = exec.Command("wl-paste", "--no-newline")
+		} else if _, err := exec.LookPath("xclip"); err == nil {
+			cmd = exec.Command("xclip", "-selection", "clipboard", "-o")
+		} else {
+			cmd = exec.Command("xsel", "--clipboard", "--output")
 		}
-
-		return b.Put(itob(taskId), t)
-	})
+	}
+	buf, err := cmd.Output()
+	return string(buf), err
 }
 
-// Filter tasks by tag. Returns a slice of tasks whose tag is present in `include`.
-// One of the []string must be empty i.e. can only include or exclude, can't do both.
-func filterTasks(tp []TaskPosition, include, exclude []string) []TaskPosition {
-	// no tags to filter by, return tp
-	if len(include) == 0 && len(exclude) == 0 {
-		return tp
+// Copy the file at `path` into the attachments directory, prefixing its name
+// with a timestamp to avoid collisions. Returns the path of the copy
+func copyAttachment(path string) (string, error) {
+	dir, err := taskDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
 	}
 
-	var filtered []TaskPosition
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
 
-	// First filter out any unwanted tasks
-	excludeNoTag := slices.Contains(exclude, "none")
-	for _, t := range tp {
-		if slices.Contains(exclude, t.task.Tag) {
-			continue
+	dest := filepath.Join(dir, fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(path)))
+	dst, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	// A write can fail as late as Close, e.g. on a full disk, so the partial copy is removed.
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dest)
+		return "", err
+	}
+	return dest, nil
+}
+
+// Format every field of a task as a multi-line card
+func formatTaskCard(id int, t Task) string {
+	tags := strings.Join(t.Tags, ", ")
+	if tags == "" {
+		tags = "-"
+	}
+	priority := t.Priority
+	if priority == "" {
+		priority = "-"
+	}
+	uuid, hash := t.UUID, shortHash(t)
+	if uuid == "" {
+		uuid, hash = "-", "-"
+	}
+
+	rows := [][2]string{
+		{tr("Description"), t.Desc},
+		{tr("Tags"), tags},
+		{tr("Status"), t.Status},
+		{tr("Created"), formatWhen(t.Created, true)},
+		{tr("Completed"), formatWhen(t.Completed, true)},
+		{tr("Due"), formatWhen(t.Due, false)},
+		{tr("Priority"), priority},
+		{tr("UUID"), uuid},
+		{tr("Hash"), hash},
+	}
+	if t.Points > 0 {
+		rows = append(rows, [2]string{tr("Points"), strconv.Itoa(t.Points)})
+	}
+	if t.Assignee != "" {
+		rows = append(rows, [2]string{tr("Assignee"), t.Assignee})
+	}
+	if t.BlockedReason != "" {
+		rows = append(rows, [2]string{tr("Blocked"), t.BlockedReason})
+	}
+	if len(t.Intervals) > 0 {
+		rows = append(rows, [2]string{tr("Time spent"), formatDuration(timeSpent(t, time.Now()))})
+	}
+	for i, a := range t.Attachments {
+		label := ""
+		if i == 0 {
+			label = tr("Attachments")
 		}
-		if t.task.Tag == "" && excludeNoTag {
-			continue
+		rows = append(rows, [2]string{label, fmt.Sprintf("%d. %s", i+1, a)})
+	}
+	comments := slices.Clone(t.Comments)
+	slices.SortStableFunc(comments, func(a, b Comment) int {
+		return compareTimestamps(a.Time, b.Time)
+	})
+	for i, c := range comments {
+		label := ""
+		if i == 0 {
+			label = tr("Comments")
 		}
-		filtered = append(filtered, t)
+		rows = append(rows, [2]string{label, formatWhen(c.Time, true) + " " + c.Text})
 	}
 
-	var finalFilter []TaskPosition
+	var builder strings.Builder
+	labelWidth := 0
+	for _, r := range rows {
+		labelWidth = max(labelWidth, textWidth(r[0])+1)
+	}
 
-	// "none" tag can be used to filter tasks with no tag
-	includeNoTag := slices.Contains(include, "none")
-	for _, t := range filtered {
-		if t.task.Tag == "" && includeNoTag {
-			finalFilter = append(finalFilter, t)
+	builder.WriteString(fmt.Sprintf(tr("Task %d"), id))
+	for _, r := range rows {
+		label := r[0]
+		if label != "" {
+			label += ":"
 		}
-		if slices.Contains(include, t.task.Tag) {
-			finalFilter = append(finalFilter, t)
+		builder.WriteString(fmt.Sprintf("\n%s %s", padRight(label, labelWidth), r[1]))
+	}
+	return builder.String()
+}
+
+// Reformat an RFC3339 timestamp in the local timezone using `layout`. Returns "-" for empty or
+// invalid timestamps
+func formatTimestamp(ts string, layout string) string {
+	parsed, err := parseTimestamp(ts)
+	if err != nil {
+		return "-"
+	}
+	return parsed.Format(layout)
+}
+
+// Split `s` into lines no wider than `width`, breaking on whitespace. Existing line breaks
+// are kept and words wider than `width` are left whole. A width <= 0 disables wrapping
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		if width <= 0 {
+			lines = append(lines, paragraph)
+			continue
 		}
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case textWidth(line)+1+textWidth(word) > width:
+				lines = append(lines, line)
+				line = word
+			default:
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
 	}
-	if len(include) > 0 {
-		return finalFilter
+	return lines
+}
+
+// Returns the width of the terminal output is written to, or 0 if output isn't a terminal.
+// $COLUMNS takes precedence over querying the terminal. A variable so tests can control wrapping
+var terminalWidth = func() int {
+	if !isTerminal(os.Stdout) {
+		return 0
 	}
-	return filtered
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return terminalSize(os.Stdout)
 }
 
-// Format the tasks in db, return the formatted string
-func formatTasks(tp []TaskPosition) string {
-	var builder strings.Builder
+// Reports whether `f` is a terminal rather than a pipe or a file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-	for idx, t := range tp {
-		s := "🔴"
-		if t.task.Status == STATUS.COMPLETE {
-			s = "✅"
+// Open `initial` in the user's $EDITOR and return the saved text without surrounding whitespace.
+// A variable so tests can avoid launching an editor
+var editText = func(initial string) (string, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
 		}
+	}
 
-		// Build the task strings.
-		// format: num. [tag: ] desc status [\n]
-		builder.WriteString(fmt.Sprintf("%d: ", t.dbKey))
-		if ShowTags {
-			builder.WriteString(fmt.Sprintf("%s: ", t.task.Tag))
-		}
-		builder.WriteString(fmt.Sprintf("%s %s", t.task.Desc, s))
-		//   Add a newline if it's not the last task
-		if idx < len(tp)-1 {
-			builder.WriteString("\n")
-		}
+	f, err := os.CreateTemp("", "task-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(initial)
+	f.Close()
+
+	// $EDITOR may include arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
 	}
 
-	return builder.String()
+	buf, err := os.ReadFile(f.Name())
+	return strings.TrimSpace(string(buf)), err
+}
+
+// Format how long `t` has been open as " 3d", flagging tasks open for longer than
+// AgeThreshold days with a warning sign. Returns an empty string if the creation date is unknown
+func formatAge(t Task, now time.Time) string {
+	created, err := parseTimestamp(t.Created)
+	if err != nil {
+		return ""
+	}
+
+	age := now.Sub(created)
+	var s string
+	switch {
+	case age < time.Hour:
+		s = fmt.Sprintf(" %dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		s = fmt.Sprintf(" %dh", int(age.Hours()))
+	default:
+		s = fmt.Sprintf(" %dd", int(age.Hours()/24))
+	}
+
+	if age > time.Duration(AgeThreshold)*24*time.Hour {
+		s += " ⚠️"
+	}
+	return s
 }
 
 // Opens a View transaction with `db` and returns the number of entries in `bucket`
@@ -822,73 +4137,233 @@ func deleteKey(k int, db *bolt.DB, bucket []byte) error {
 // Remove the specified keys by filtering the bucket, deleting the bucket and
 // inserting the filtered items into a new bucket with the same name.
 // O(n), filter n items, insert n items
-func deleteKeys(toDelete []int, db *bolt.DB, bucket []byte) {
-	db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(bucket)
-		if b == nil {
-			fmt.Printf("`%s` bucket does not exist", string(bucket))
-			os.Exit(1)
-		}
+func deleteKeys(toDelete []int, db *bolt.DB, bucket []byte) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		return deleteKeysTx(tx, toDelete, bucket)
+	})
+}
 
-		var filtered [][]byte
-		b.ForEach(func(k, v []byte) error {
-			ignore := slices.Contains(toDelete, btoi(k))
-			if !ignore {
-				filtered = append(filtered, v)
-			}
-			return nil
-		})
-		tx.DeleteBucket(bucket)
+// Same as deleteKeys, within the Update transaction `tx`
+func deleteKeysTx(tx *bolt.Tx, toDelete []int, bucket []byte) error {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return fmt.Errorf("`%s` bucket does not exist", string(bucket))
+	}
 
-		// Create a new bucket, insert the filtered tasks and renumber
-		newBucket, _ := tx.CreateBucket(bucket)
-		for _, t := range filtered {
-			k, _ := newBucket.NextSequence()
-			newBucket.Put(itob(int(k)), t)
+	var filtered [][]byte
+	b.ForEach(func(k, v []byte) error {
+		ignore := slices.Contains(toDelete, btoi(k))
+		if !ignore {
+			filtered = append(filtered, v)
 		}
-		return renumberEntires(newBucket)
+		return nil
 	})
+	tx.DeleteBucket(bucket)
+
+	// Create a new bucket, insert the filtered tasks and renumber
+	newBucket, _ := tx.CreateBucket(bucket)
+	for _, t := range filtered {
+		k, _ := newBucket.NextSequence()
+		newBucket.Put(itob(int(k)), t)
+	}
+	return renumberEntires(newBucket)
 }
 
 // Update the specified tasks status to `completed`
-func completeTask(taskID int, db *bolt.DB) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func completeTask(taskID int, db *bolt.DB, out io.Writer) error {
+	completed, err := completeTasks(db, []int{taskID}, false)
+	if err == nil && len(completed) == 0 {
+		fmt.Fprintf(out, tr("You already finished task %d\n"), taskID)
+	}
+	return err
+}
+
+// Update the status of the tasks with the given keys to `completed` and, if `archive` is
+// set, move them to the archive. Everything happens in a single transaction: nothing is
+// changed if any of the tasks does not exist. Returns the keys of the tasks that weren't
+// done already, the ones that were completed
+func completeTasks(db *bolt.DB, keys []int, archive bool) ([]int, error) {
+	var completed []int
+	err := db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(TASKS_BUCKET)
 		if b == nil {
 			return fmt.Errorf("Could not find a tasks database")
 		}
 
-		byteId := itob(taskID)
+		now := time.Now()
+		complete, _ := config.status(STATUS.COMPLETE)
+		var tasks []Task
+		for i, taskID := range keys {
+			// a task listed twice is only archived once
+			if slices.Contains(keys[:i], taskID) {
+				continue
+			}
+			byteId := itob(taskID)
+			val := b.Get(byteId)
+			if val == nil {
+				return fmt.Errorf(tr("Task %d does not exist\n"), taskID)
+			}
+
+			t := bToTask(val)
+			if isDone(t) {
+				tasks = append(tasks, t)
+				continue
+			}
+
+			old := snapshot(t)
+			setTaskStatus(&t, complete, now)
+			recordChanges(old, &t, now)
+			tasks = append(tasks, t)
+			completed = append(completed, taskID)
+			updatedTask, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
 
-		val := b.Get(byteId)
-		if val == nil {
-			return fmt.Errorf("Task %d does not exist\n", taskID)
+			// update the `tasks` bucket with the completed task
+			if err := b.Put(byteId, updatedTask); err != nil {
+				return err
+			}
 		}
 
-		var t Task
+		if !archive {
+			return nil
+		}
+		// add the specified tasks to the archive ->
+		// remove _only_ the specified tasks from the
+		// tasks bucket
+		if err := archiveTasks(tx, tasks); err != nil {
+			return err
+		}
+		return deleteKeysTx(tx, keys, TASKS_BUCKET)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return completed, nil
+}
 
-		json.Unmarshal(val, &t)
-		if t.Status == STATUS.COMPLETE {
-			fmt.Printf("You already finished task %d\n", taskID)
+// Delete every task carrying `tag` in a single transaction. Returns the number of deleted tasks
+func deleteTagged(db *bolt.DB, tag string) (int, error) {
+	var keys []int
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return nil
+		}
+		b.ForEach(func(k, v []byte) error {
+			if slices.Contains(bToTask(v).Tags, tag) {
+				keys = append(keys, btoi(k))
+			}
+			return nil
+		})
+		if len(keys) == 0 {
 			return nil
 		}
+		return deleteKeysTx(tx, keys, TASKS_BUCKET)
+	})
+	return len(keys), err
+}
+
+// Mark every incomplete task carrying `tag` as completed in a single transaction, moving
+// them to the archive if `archive` is set. If `tag` is empty, every incomplete task is
+// completed. Returns the completed keys
+func completeMatching(db *bolt.DB, tag string, archive bool) ([]int, error) {
+	var keys []int
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return fmt.Errorf("Could not find a tasks database")
+		}
 
-		t.Status = STATUS.COMPLETE
-		t.Completed = time.Now().Format(RFC3339)
-		updatedTask, err := json.Marshal(t)
+		now := time.Now()
+		complete, _ := config.status(STATUS.COMPLETE)
+		updates := map[int][]byte{}
+		var tasks []Task
+		err := b.ForEach(func(k, v []byte) error {
+			t := bToTask(v)
+			if isDone(t) || (tag != "" && !slices.Contains(t.Tags, tag)) {
+				return nil
+			}
+			old := snapshot(t)
+			setTaskStatus(&t, complete, now)
+			recordChanges(old, &t, now)
+			buf, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, btoi(k))
+			tasks = append(tasks, t)
+			updates[btoi(k)] = buf
+			return nil
+		})
 		if err != nil {
 			return err
 		}
+		if archive {
+			if err := archiveTasks(tx, tasks); err != nil {
+				return err
+			}
+			return deleteKeysTx(tx, keys, TASKS_BUCKET)
+		}
 
-		// update the `tasks` bucket with the completed task
-		b.Put(byteId, updatedTask)
-
+		// Modifying a bucket while iterating over it is unsafe, apply the updates afterwards
+		for _, k := range keys {
+			if err := b.Put(itob(k), updates[k]); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
+	return keys, err
+}
+
+// Move the tasks with `keys` to the status `to`, a cancelled status, and when `archive` is true
+// move them to the archive, all in a single transaction
+func cancelTasks(db *bolt.DB, keys []int, to Status, archive bool) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(TASKS_BUCKET)
+		if b == nil {
+			return fmt.Errorf("Could not find a tasks database")
+		}
+
+		now := time.Now()
+		var tasks []Task
+		for _, k := range keys {
+			v := b.Get(itob(k))
+			if v == nil {
+				return fmt.Errorf(tr("Task %d does not exist"), k)
+			}
+			t := bToTask(v)
+			if err := canMove(t, to); err != nil {
+				return err
+			}
+			old := snapshot(t)
+			setTaskStatus(&t, to, now)
+			recordChanges(old, &t, now)
+			tasks = append(tasks, t)
+			buf, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(k), buf); err != nil {
+				return err
+			}
+		}
+
+		if !archive {
+			return nil
+		}
+		if err := archiveTasks(tx, tasks); err != nil {
+			return err
+		}
+		return deleteKeysTx(tx, keys, TASKS_BUCKET)
+	})
 }
 
-// Filter out completed tasks from the `tasks` bucket
-func finish(db *bolt.DB) ([]Task, error) {
+// Filter out completed tasks from the `tasks` bucket. If `tag` is not empty,
+// only completed tasks carrying `tag` are filtered out
+func finish(db *bolt.DB, tag string) ([]Task, error) {
 	var deletedTasks []Task
 	updateErr := db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(TASKS_BUCKET)
@@ -902,7 +4377,7 @@ func finish(db *bolt.DB) ([]Task, error) {
 		err := b.ForEach(func(k, v []byte) error {
 			t := bToTask(v)
 
-			if t.Status != STATUS.COMPLETE {
+			if !isDone(t) || (tag != "" && !slices.Contains(t.Tags, tag)) {
 				filtered = append(filtered, v)
 				return nil
 			}
@@ -928,6 +4403,21 @@ func finish(db *bolt.DB) ([]Task, error) {
 	return deletedTasks, updateErr
 }
 
+// Delete all completed tasks from the `tasks` bucket without archiving them.
+// Returns the number of deleted tasks
+func purge(db *bolt.DB) int {
+	var keys []int
+	for _, t := range getTasks(db, TASKS_BUCKET) {
+		if isDone(t.task) {
+			keys = append(keys, t.dbKey)
+		}
+	}
+	if len(keys) > 0 {
+		deleteKeys(keys, db, TASKS_BUCKET)
+	}
+	return len(keys)
+}
+
 // Renumber bucket entries in ascending order.
 // Especially useful after deleting an entry in the middle of the bucket
 func renumberEntires(bucket *bolt.Bucket) error {
@@ -949,16 +4439,29 @@ func renumberEntires(bucket *bolt.Bucket) error {
 // Adds each task in the slice to the archive bucket
 func addToArchive(db *bolt.DB, tasks []Task) {
 	db.Update(func(tx *bolt.Tx) error {
-		b, _ := tx.CreateBucketIfNotExists(ARCHIVE_BUCKET)
-		for _, t := range tasks {
-			k, _ := b.NextSequence()
-			buf, _ := json.Marshal(t)
-			b.Put(itob(int(k)), buf)
-		}
-		return nil
+		return archiveTasks(tx, tasks)
 	})
 }
 
+// Same as addToArchive, within the Update transaction `tx`
+func archiveTasks(tx *bolt.Tx, tasks []Task) error {
+	b, err := tx.CreateBucketIfNotExists(ARCHIVE_BUCKET)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		k, _ := b.NextSequence()
+		buf, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(itob(int(k)), buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Convert an int to a byte slice
 func itob(v int) []byte {
 	b := make([]byte, 8)
@@ -979,6 +4482,95 @@ func bToTask(b []byte) Task {
 	return task
 }
 
+// Validate a priority given on the command line. "none" and "" mean no priority
+func parsePriority(s string) (string, error) {
+	s = strings.ToLower(s)
+	switch {
+	case s == "" || s == "none":
+		return "", nil
+	case s == "medium":
+		return "med", nil
+	case slices.Contains(PRIORITIES, s):
+		return s, nil
+	}
+	return "", fmt.Errorf(tr(`Invalid priority "%s", must be high, med, low or none`), s)
+}
+
+// Shell completion for the priority flags
+func completePriorities(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return append(slices.Clone(PRIORITIES), "none"), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeGroupings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return GROUPINGS, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Resolve a status given on the command line, by name or alias, to its name. "" stays empty
+func parseStatus(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	status, ok := config.status(s)
+	if !ok {
+		var names []string
+		for _, s := range config.Statuses {
+			names = append(names, s.Name)
+		}
+		return "", fmt.Errorf(tr(`Invalid status "%s", must be one of %s`), s, strings.Join(names, ", "))
+	}
+	return status.Name, nil
+}
+
+func completeStatuses(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, s := range config.Statuses {
+		names = append(names, s.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Width descriptions are cut to in shell completions
+const completionWidth = 40
+
+// Returns a shell completion for task IDs that describes each task by its description, so the
+// shell shows "3  buy milk". IDs already given are left out
+func completeTaskIDs(mgr *connectionManager) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if mgr.db == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var ids []string
+		for _, t := range getTasks(mgr.db, TASKS_BUCKET) {
+			id := strconv.Itoa(t.dbKey)
+			if !strings.HasPrefix(id, toComplete) || slices.Contains(args, id) {
+				continue
+			}
+			// Tabs and newlines would break the completion protocol
+			desc := strings.Join(strings.Fields(t.task.Desc), " ")
+			ids = append(ids, id+"\t"+truncateText(desc, completionWidth))
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// Parse a due date in the local timezone. See parseDate for the accepted formats
+func parseDueDate(s string) (time.Time, error) {
+	return parseDate(s, time.Now())
+}
+
+// Reports whether `t` is incomplete and its due date passed before `now`.
+// A task is due until the end of its due date.
+func isOverdue(t Task, now time.Time) bool {
+	if t.Due == "" || isDone(t) {
+		return false
+	}
+	due, err := parseTimestamp(t.Due)
+	if err != nil {
+		return false
+	}
+	return now.After(lastTick(due))
+}
+
 // Returns the last tick of the provided time in the form:
 // yyyy-mm-dd 23:59:59.999999999
 func lastTick(t time.Time) time.Time {
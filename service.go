@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// A file starting a background job: a systemd unit on Linux or a launchd agent on macOS
+type ServiceFile struct {
+	// Unit name, or label of the agent
+	Name    string
+	Content string
+	// Whether the service manager should start the file. Services run by a timer aren't
+	// started themselves
+	Enable bool
+}
+
+// Label prefix of the launchd agents
+const launchdPrefix = "com.github.allmtz.task-cli."
+
+// Run the service manager, systemctl or launchctl, returning its combined output.
+// A variable so tests can avoid changing the user's services
+var runServiceManager = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// Don't open the database: the service commands don't need it, and a running daemon has it
+func skipDatabase(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	return nil
+}
+
+func newDaemonInstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "install",
+		Short:             tr("Run remind in the background and back up the database daily, with systemd or launchd"),
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		PersistentPreRunE: skipDatabase,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exe, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			if exe, err = filepath.EvalSymlinks(exe); err != nil {
+				return err
+			}
+			dir, err := serviceDir()
+			if err != nil {
+				return err
+			}
+			tDir, err := taskDir()
+			if err != nil {
+				return err
+			}
+			// The jobs run with the TASK_* variables of this shell. They don't run in its
+			// directory, so the database path is made absolute
+			env := taskEnv()
+			if _, ok := env["TASK_DB"]; ok {
+				env["TASK_DB"] = dbPath(tDir)
+			}
+
+			files := systemdUnits(exe, env)
+			if runtime.GOOS == "darwin" {
+				files = launchdAgents(exe, tDir, env)
+			}
+			if err := installServices(dir, files); err != nil {
+				return err
+			}
+			for _, f := range files {
+				fmt.Fprintf(cmd.OutOrStdout(), tr("Installed %s\n"), filepath.Join(dir, serviceFileName(f)))
+			}
+			return nil
+		},
+	}
+}
+
+func newDaemonUninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "uninstall",
+		Short:             tr("Stop and remove the background jobs added by install"),
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		PersistentPreRunE: skipDatabase,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := serviceDir()
+			if err != nil {
+				return err
+			}
+			removed, err := uninstallServices(dir, serviceFiles())
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), tr("Nothing is installed"))
+			}
+			for _, path := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), tr("Removed %s\n"), path)
+			}
+			return nil
+		},
+	}
+}
+
+func newDaemonStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "status",
+		Short:             tr("Print whether the background jobs added by install are running"),
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		PersistentPreRunE: skipDatabase,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := serviceDir()
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, f := range serviceFiles() {
+				if f.Enable {
+					rows = append(rows, []string{f.Name, serviceStatus(dir, f)})
+				}
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), formatTable(rows))
+			return nil
+		},
+	}
+}
+
+// Returns the directory the service manager reads the user's services from
+func serviceDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents"), nil
+	case "linux":
+		if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+			return filepath.Join(dir, "systemd", "user"), nil
+		}
+		return filepath.Join(home, ".config", "systemd", "user"), nil
+	}
+	return "", fmt.Errorf(tr("Installing background jobs needs systemd or launchd, which %s doesn't have"), runtime.GOOS)
+}
+
+// Returns the service files of this OS, for their names
+func serviceFiles() []ServiceFile {
+	if runtime.GOOS == "darwin" {
+		return launchdAgents("", "", nil)
+	}
+	return systemdUnits("", nil)
+}
+
+// Returns the name of the file holding `f`
+func serviceFileName(f ServiceFile) string {
+	if runtime.GOOS == "darwin" {
+		return f.Name + ".plist"
+	}
+	return f.Name
+}
+
+// Returns the systemd user units running `exe` with the environment variables `env`: a
+// service for remind, and a daily timer for backup with the service it starts
+func systemdUnits(exe string, env map[string]string) []ServiceFile {
+	// Paths with spaces must be quoted
+	if strings.ContainsAny(exe, " \t") {
+		exe = `"` + exe + `"`
+	}
+	// Quoted, with systemd's escapes for backslashes, quotes, newlines and its % specifiers
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%")
+	var environment string
+	for _, key := range envNames(env) {
+		environment += `Environment="` + quote.Replace(key+"="+env[key]) + "\"\n"
+	}
+	return []ServiceFile{
+		{Name: "task-remind.service", Enable: true, Content: `[Unit]
+Description=task: notify when tasks are due
+
+[Service]
+` + environment + `ExecStart=` + exe + ` remind
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`},
+		{Name: "task-backup.service", Content: `[Unit]
+Description=task: back up the database
+
+[Service]
+Type=oneshot
+` + environment + `ExecStart=` + exe + ` backup
+`},
+		{Name: "task-backup.timer", Enable: true, Content: `[Unit]
+Description=task: back up the database daily
+
+[Timer]
+OnCalendar=daily
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`},
+	}
+}
+
+// Returns the launchd agents running `exe` with the environment variables `env`: one keeping
+// remind running and one running backup daily. Their output is logged in `dir`
+func launchdAgents(exe, dir string, env map[string]string) []ServiceFile {
+	escape := func(s string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(s))
+		return buf.String()
+	}
+	var environment string
+	if len(env) > 0 {
+		environment = "\t<key>EnvironmentVariables</key>\n\t<dict>\n"
+		for _, key := range envNames(env) {
+			environment += "\t\t<key>" + escape(key) + "</key>\n\t\t<string>" + escape(env[key]) + "</string>\n"
+		}
+		environment += "\t</dict>\n"
+	}
+	plist := func(label, command, schedule string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + launchdPrefix + label + `</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>` + escape(exe) + `</string>
+		<string>` + command + `</string>
+	</array>
+` + environment + schedule + `	<key>StandardOutPath</key>
+	<string>` + escape(filepath.Join(dir, command+".log")) + `</string>
+	<key>StandardErrorPath</key>
+	<string>` + escape(filepath.Join(dir, command+".log")) + `</string>
+</dict>
+</plist>
+`
+	}
+	return []ServiceFile{
+		{Name: launchdPrefix + "remind", Enable: true, Content: plist("remind", "remind", `	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+`)},
+		// launchd runs a job missed while asleep once it wakes
+		{Name: launchdPrefix + "backup", Enable: true, Content: plist("backup", "backup", `	<key>StartCalendarInterval</key>
+	<dict>
+		<key>Hour</key>
+		<integer>0</integer>
+		<key>Minute</key>
+		<integer>0</integer>
+	</dict>
+`)},
+	}
+}
+
+// Returns the names of the variables in `env`, sorted so installing again writes the same files
+func envNames(env map[string]string) []string {
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Write `files` to `dir` and start them, replacing any previous install
+func installServices(dir string, files []ServiceFile) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, serviceFileName(f)), []byte(f.Content), 0644); err != nil {
+			return err
+		}
+	}
+
+	if runtime.GOOS == "darwin" {
+		for _, f := range files {
+			path := filepath.Join(dir, serviceFileName(f))
+			// Unloading an agent that isn't loaded fails, which is fine
+			runServiceManager("launchctl", "bootout", launchdDomain(), path)
+			if err := serviceCommand("launchctl", "bootstrap", launchdDomain(), path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := serviceCommand("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	args := []string{"--user", "enable", "--now"}
+	for _, f := range files {
+		if f.Enable {
+			args = append(args, f.Name)
+		}
+	}
+	if err := serviceCommand("systemctl", args...); err != nil {
+		return err
+	}
+	// Restart so an update takes effect
+	return serviceCommand("systemctl", "--user", "restart", "task-remind.service")
+}
+
+// Stop the services in `files` and remove them from `dir`. Returns the paths of the removed
+// files
+func uninstallServices(dir string, files []ServiceFile) ([]string, error) {
+	var removed []string
+	for _, f := range files {
+		path := filepath.Join(dir, serviceFileName(f))
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if runtime.GOOS == "darwin" {
+			runServiceManager("launchctl", "bootout", launchdDomain(), path)
+		} else if f.Enable {
+			runServiceManager("systemctl", "--user", "disable", "--now", f.Name)
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	if len(removed) > 0 && runtime.GOOS != "darwin" {
+		runServiceManager("systemctl", "--user", "daemon-reload")
+	}
+	return removed, nil
+}
+
+// Returns the state of the service `f` installed in `dir`, such as active, or not installed
+func serviceStatus(dir string, f ServiceFile) string {
+	if _, err := os.Stat(filepath.Join(dir, serviceFileName(f))); errors.Is(err, os.ErrNotExist) {
+		return tr("not installed")
+	}
+	if runtime.GOOS == "darwin" {
+		if _, err := runServiceManager("launchctl", "print", launchdDomain()+"/"+f.Name); err != nil {
+			return tr("not loaded")
+		}
+		return tr("loaded")
+	}
+	// is-active exits with an error for inactive units, but still prints their state
+	buf, _ := runServiceManager("systemctl", "--user", "is-active", f.Name)
+	return strings.TrimSpace(string(buf))
+}
+
+// Run the service manager, returning its output as the error if it fails
+func serviceCommand(name string, args ...string) error {
+	buf, err := runServiceManager(name, args...)
+	if err != nil {
+		if s := strings.TrimSpace(string(buf)); s != "" {
+			return fmt.Errorf("%s %s: %s", name, strings.Join(args, " "), s)
+		}
+		return fmt.Errorf("%s %s: %v", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// Returns the launchd domain of the user's GUI session
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Placeholders of the statusline format, such as {due}
+var STATUSLINE_FIELDS = []string{"due", "overdue", "open", "done"}
+
+// Format of the statusline when none is configured
+const defaultStatusline = "{due} due · {open} open · ✅{done} today"
+
+// Matches a placeholder of the statusline format, capturing its name
+var statuslinePlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+func newStatuslineCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	sCmd := &cobra.Command{
+		Use:          "statusline -[ft]",
+		Short:        tr("Print a one line summary for status bars such as tmux's"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := StatuslineFormat
+			if format == "" {
+				format = config.Statusline
+			}
+			if format == "" {
+				format = defaultStatusline
+			}
+			if err := checkStatusline(format); err != nil {
+				return err
+			}
+			s := summarize(getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET), time.Now())
+			fmt.Fprintln(out, formatStatusline(format, s, StatuslineTmux))
+			return nil
+		},
+	}
+	sCmd.Flags().StringVarP(&StatuslineFormat, "format", "f", "", "Format of the line, with the placeholders {due}, {overdue}, {open} and {done}. Defaults to the statusline setting")
+	sCmd.Flags().BoolVarP(&StatuslineTmux, "tmux", "t", false, "Color the counts that need attention with tmux's #[fg=color] codes")
+	return sCmd
+}
+
+// Returns an error if `format` has a placeholder that isn't one of STATUSLINE_FIELDS
+func checkStatusline(format string) error {
+	for _, m := range statuslinePlaceholder.FindAllStringSubmatch(format, -1) {
+		if !slices.Contains(STATUSLINE_FIELDS, m[1]) {
+			return fmt.Errorf(tr(`Unknown placeholder "%s", must be one of %s`), m[0], "{"+strings.Join(STATUSLINE_FIELDS, "}, {")+"}")
+		}
+	}
+	return nil
+}
+
+// Replace the placeholders in `format` with the counts of `s`. Tasks due today count as due
+// along with overdue tasks. With `tmux`, counts that need attention are colored: overdue
+// tasks red, due tasks yellow and tasks done today green
+func formatStatusline(format string, s Summary, tmux bool) string {
+	open := 0
+	for _, n := range s.ByStatus {
+		open += n
+	}
+	counts := map[string]int{
+		"due":     s.Overdue + s.DueToday,
+		"overdue": s.Overdue,
+		"open":    open,
+		"done":    s.CompletedToday,
+	}
+	colors := map[string]string{"due": "yellow", "overdue": "red", "done": "green"}
+	if s.Overdue > 0 {
+		colors["due"] = "red"
+	}
+
+	return statuslinePlaceholder.ReplaceAllStringFunc(format, func(p string) string {
+		name := p[1 : len(p)-1]
+		n, ok := counts[name]
+		if !ok {
+			return p
+		}
+		value := strconv.Itoa(n)
+		if color, ok := colors[name]; tmux && ok && n > 0 {
+			value = "#[fg=" + color + "]" + value + "#[default]"
+		}
+		return value
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// An overview of the task list, printed by `summary`
+type Summary struct {
+	// Open tasks, i.e. not in a done status, per status
+	ByStatus map[string]int
+	// Open tasks per tag
+	ByTag    map[string]int
+	Overdue  int
+	DueToday int
+	// Tasks completed today and since Monday, in the task list or the archive
+	CompletedToday    int
+	CompletedThisWeek int
+	// Number of days in a row, up to today, with at least one completed task. A streak
+	// isn't broken until a day ends without a completion, so it may end yesterday
+	Streak int
+}
+
+func newSummaryCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:          "summary",
+		Short:        tr("Print an overview of your tasks: open, due, completed and your streak"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := summarize(getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET), time.Now())
+			fmt.Fprintln(out, formatSummary(s))
+			return nil
+		},
+	}
+}
+
+// Summarize the task list `tp` and the `archive` as of `now`
+func summarize(tp, archive []TaskPosition, now time.Time) Summary {
+	s := Summary{ByStatus: map[string]int{}, ByTag: map[string]int{}}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -(int(now.Weekday())+6)%7)
+
+	for _, t := range tp {
+		if isDone(t.task) {
+			continue
+		}
+		s.ByStatus[t.task.Status]++
+		for _, tag := range t.task.Tags {
+			s.ByTag[tag]++
+		}
+		if isOverdue(t.task, now) {
+			s.Overdue++
+		} else if dueSection(t.task, now) == 1 {
+			s.DueToday++
+		}
+	}
+
+	days := map[string]bool{}
+	for _, tasks := range [][]TaskPosition{tp, archive} {
+		for _, t := range tasks {
+			// Tasks closed in other done states, such as cancelled, weren't completed
+			if t.task.Completed == "" || closedIncomplete(t.task) {
+				continue
+			}
+			completed, err := parseTimestamp(t.task.Completed)
+			if err != nil {
+				continue
+			}
+			completed = completed.In(now.Location())
+			if !completed.Before(today) {
+				s.CompletedToday++
+			}
+			if !completed.Before(monday) {
+				s.CompletedThisWeek++
+			}
+			days[completed.Format("2006-01-02")] = true
+		}
+	}
+
+	day := today
+	if !days[day.Format("2006-01-02")] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for days[day.Format("2006-01-02")] {
+		s.Streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return s
+}
+
+// Format `s` as a table, open tasks per status first and per tag last
+func formatSummary(s Summary) string {
+	open := 0
+	for _, n := range s.ByStatus {
+		open += n
+	}
+	rows := [][]string{{tr("Open:"), strconv.Itoa(open)}}
+	for _, status := range config.Statuses {
+		if n := s.ByStatus[status.Name]; n > 0 {
+			rows = append(rows, []string{"  " + status.Icon + " " + status.Name, strconv.Itoa(n)})
+		}
+	}
+	rows = append(rows,
+		[]string{tr("Overdue:"), strconv.Itoa(s.Overdue)},
+		[]string{tr("Due today:"), strconv.Itoa(s.DueToday)},
+		[]string{tr("Completed today:"), strconv.Itoa(s.CompletedToday)},
+		[]string{tr("Completed this week:"), strconv.Itoa(s.CompletedThisWeek)},
+		[]string{tr("Streak:"), fmt.Sprintf(tr("%d days"), s.Streak)},
+	)
+
+	tags := sortTagCounts(s.ByTag)
+	if len(tags) > 0 {
+		rows = append(rows, []string{tr("Per tag:"), ""})
+		for _, t := range tags {
+			rows = append(rows, []string{"  +" + t.Name, strconv.Itoa(t.Count)})
+		}
+	}
+	return formatTable(rows)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Returns the number of columns of the terminal attached to `f`, or 0 if it can't be determined
+func terminalSize(f *os.File) int {
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// Switch the terminal attached to `f` to reading single keys, without echoing them or waiting
+// for enter, and without Ctrl-C interrupting the program. Returns a function restoring the
+// previous settings
+func rawMode(f *os.File) (func(), error) {
+	prev, err := term.MakeRaw(int(f.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		term.Restore(int(f.Fd()), prev)
+	}, nil
+}
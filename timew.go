@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Layout of the timestamps in Timewarrior's data files
+const timewLayout = "20060102T150405Z"
+
+// An interval in Timewarrior's data format, e.g. `inc 20240301T090000Z - 20240301T103000Z # tag`
+type TimewInterval struct {
+	Start time.Time
+	// Zero while the task is in progress
+	End  time.Time
+	Tags []string
+}
+
+func newTimewCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	tCmd := &cobra.Command{
+		Use:          "timew -[w]",
+		Short:        tr("Export the time tracked with start and stop in Timewarrior's format"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tasks := append(getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET)...)
+			intervals := timewIntervals(CommandFilter.Apply(tasks))
+			if !TimewWrite {
+				for _, i := range intervals {
+					fmt.Fprintln(out, i)
+				}
+				return nil
+			}
+
+			dir, err := timewDataDir()
+			if err != nil {
+				return err
+			}
+			added, err := writeTimewData(dir, intervals)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Added %d intervals to %s\n"), added, dir)
+			return nil
+		},
+	}
+	tCmd.Flags().BoolVarP(&TimewWrite, "write", "w", false, "Add the finished intervals to Timewarrior's data files instead of printing them. Intervals already there are skipped")
+	return tCmd
+}
+
+// Returns the intervals tracked on `tasks` sorted by start time, tagged with the description
+// and the tags of their task, like Timewarrior's on-modify hook does
+func timewIntervals(tasks []TaskPosition) []TimewInterval {
+	var intervals []TimewInterval
+	for _, t := range tasks {
+		desc, _, _ := strings.Cut(t.task.Desc, "\n")
+		tags := append([]string{desc}, t.task.Tags...)
+		for _, i := range t.task.Intervals {
+			start, err := parseTimestamp(i.Start)
+			if err != nil {
+				continue
+			}
+			var end time.Time
+			if i.End != "" {
+				if end, err = parseTimestamp(i.End); err != nil {
+					continue
+				}
+			}
+			intervals = append(intervals, TimewInterval{start, end, tags})
+		}
+	}
+	slices.SortStableFunc(intervals, func(a, b TimewInterval) int {
+		return a.Start.Compare(b.Start)
+	})
+	return intervals
+}
+
+// Format `i` as a line of a Timewarrior data file
+func (i TimewInterval) String() string {
+	s := "inc " + i.Start.UTC().Format(timewLayout)
+	if !i.End.IsZero() {
+		s += " - " + i.End.UTC().Format(timewLayout)
+	}
+	if len(i.Tags) > 0 {
+		var tags []string
+		for _, tag := range i.Tags {
+			tags = append(tags, quoteTimewTag(tag))
+		}
+		s += " # " + strings.Join(tags, " ")
+	}
+	return s
+}
+
+// Quote `tag` the way Timewarrior does when it holds spaces, quotes or other special characters
+func quoteTimewTag(tag string) string {
+	if tag != "" && !strings.ContainsAny(tag, " \t\"'#") {
+		return tag
+	}
+	return `"` + strings.ReplaceAll(tag, `"`, `\"`) + `"`
+}
+
+// Returns Timewarrior's data directory, $TIMEWARRIORDB/data or ~/.timewarrior/data by default
+func timewDataDir() (string, error) {
+	if db := os.Getenv("TIMEWARRIORDB"); db != "" {
+		return filepath.Join(db, "data"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".timewarrior", "data"), nil
+}
+
+// Add the finished `intervals` to the monthly data files in `dir`, keeping each file sorted.
+// Intervals already in a file and intervals still open are skipped, since an open interval
+// would start tracking in Timewarrior. Returns the number of intervals added
+func writeTimewData(dir string, intervals []TimewInterval) (int, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf(tr("Timewarrior's data directory %s doesn't exist, is Timewarrior installed?"), dir)
+	}
+
+	byMonth := map[string][]string{}
+	for _, i := range intervals {
+		if !i.End.IsZero() {
+			month := i.Start.UTC().Format("2006-01")
+			byMonth[month] = append(byMonth[month], i.String())
+		}
+	}
+
+	added := 0
+	for month, lines := range byMonth {
+		path := filepath.Join(dir, month+".data")
+		buf, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return added, err
+		}
+		existing := strings.Split(strings.TrimSpace(string(buf)), "\n")
+		if len(buf) == 0 {
+			existing = nil
+		}
+		for _, line := range lines {
+			if !slices.Contains(existing, line) {
+				existing = append(existing, line)
+				added++
+			}
+		}
+		slices.Sort(existing)
+		if err := os.WriteFile(path, []byte(strings.Join(existing, "\n")+"\n"), 0644); err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
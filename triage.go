@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+func newSortCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	sCmd := &cobra.Command{
+		Use:          "sort -i",
+		Short:        tr("Go through the open tasks one at a time, setting their priority, due date and tags with single keys"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !SortInteractive {
+				return errors.New(tr("sort only runs interactively, use sort -i"))
+			}
+			db := mgr.db
+			var tp []TaskPosition
+			for _, t := range getTasks(db, TASKS_BUCKET) {
+				if !isDone(t.task) {
+					tp = append(tp, t)
+				}
+			}
+			if len(CommandFilter) > 0 {
+				tp = CommandFilter.Apply(tp)
+			}
+			if len(tp) == 0 {
+				fmt.Fprintln(out, tr("No tasks"))
+				return nil
+			}
+
+			in, screen, restore, err := startInteractive(cmd, out)
+			if err != nil {
+				return err
+			}
+			changed, err := triageTasks(db, in, screen, tp, time.Now())
+			restore()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Changed %d of %d tasks\n"), changed, len(tp))
+			return nil
+		},
+	}
+	sCmd.Flags().BoolVarP(&SortInteractive, "interactive", "i", false, "Show the tasks one at a time and change them with single keys")
+	return sCmd
+}
+
+// Days `s` pushes the due date of a task back by in a triage session
+const snoozeDays = 7
+
+// Go through the tasks `tp` one at a time, reading keys from `in` and drawing on `out`:
+// h, m and l set the priority and 0 clears it, d asks for a due date, t for tags to add and
+// remove, and s snoozes the task a week. Enter or → moves to the next task, ← back, and q
+// ends the session. Changes are saved as they're made. Returns the number of tasks changed
+func triageTasks(db *bolt.DB, in *bufio.Reader, out io.Writer, tp []TaskPosition, now time.Time) (int, error) {
+	var changed []int
+	i, drawn, message := 0, 0, ""
+	draw := func() {
+		if drawn > 0 {
+			fmt.Fprintf(out, "\x1b[%dA\x1b[J", drawn)
+		}
+		t := tp[i].task
+		orNone := func(s string) string {
+			if s == "" {
+				return "-"
+			}
+			return s
+		}
+		due := ""
+		if t.Due != "" {
+			due = formatWhen(t.Due, false)
+		}
+		lines := []string{
+			fmt.Sprintf(tr("Task %d of %d"), i+1, len(tp)),
+			fmt.Sprintf("%d: %s %s", tp[i].dbKey, t.Desc, statusIcon(t)),
+			fmt.Sprintf(tr("tags: %s  due: %s  priority: %s"), orNone(strings.Join(t.Tags, ", ")), orNone(due), orNone(t.Priority)),
+			tr("h/m/l priority, 0 no priority, d due date, t tags, s snooze a week, enter next, ← back, q quit"),
+		}
+		if message != "" {
+			lines = append(lines, message)
+		}
+		// A wrapped line would throw off the redraw
+		if width := terminalWidth(); width > 0 {
+			for j := range lines {
+				lines[j] = truncateText(lines[j], width-1)
+			}
+		}
+		fmt.Fprintln(out, strings.Join(lines, "\n"))
+		drawn = len(lines)
+	}
+	save := func(t Task) error {
+		// Setting a value the task already has isn't a change
+		if !slices.ContainsFunc(historyFields, func(f historyField) bool { return f.value(t) != f.value(tp[i].task) }) {
+			return nil
+		}
+		if err := updateTask(db, tp[i].dbKey, t); err != nil {
+			return err
+		}
+		// Read it back with the change added to its history
+		saved, err := getTask(db, tp[i].dbKey)
+		if err != nil {
+			return err
+		}
+		tp[i].task = saved
+		if !slices.Contains(changed, tp[i].dbKey) {
+			changed = append(changed, tp[i].dbKey)
+		}
+		return nil
+	}
+
+	for {
+		draw()
+		message = ""
+		key, err := readKey(in)
+		// The session ends with the input
+		if err == io.EOF {
+			key = "q"
+		} else if err != nil {
+			return len(changed), err
+		}
+
+		t := snapshot(tp[i].task)
+		switch key {
+		case "h", "m", "l", "0":
+			t.Priority = map[string]string{"h": "high", "m": "med", "l": "low", "0": ""}[key]
+			if err := save(t); err != nil {
+				return len(changed), err
+			}
+		case "s":
+			t.Due = snoozeDue(t, now)
+			if err := save(t); err != nil {
+				return len(changed), err
+			}
+		case "d":
+			line, ok, err := readLine(in, out, tr("Due date, empty for none: "))
+			fmt.Fprint(out, "\r\x1b[K")
+			if err != nil {
+				return len(changed), err
+			}
+			if !ok {
+				continue
+			}
+			t.Due = ""
+			if line = strings.TrimSpace(line); line != "" && strings.ToLower(line) != "none" {
+				d, err := parseDate(line, now)
+				if err != nil {
+					message = err.Error()
+					continue
+				}
+				t.Due = d.Format(RFC3339)
+			}
+			if err := save(t); err != nil {
+				return len(changed), err
+			}
+		case "t":
+			line, ok, err := readLine(in, out, tr("Tags, +tag to add and -tag to remove: "))
+			fmt.Fprint(out, "\r\x1b[K")
+			if err != nil {
+				return len(changed), err
+			}
+			if !ok {
+				continue
+			}
+			t.Tags = editTags(t.Tags, line)
+			if err := save(t); err != nil {
+				return len(changed), err
+			}
+		case keyEnter, keySpace, keyRight, keyDown, "j":
+			if i == len(tp)-1 {
+				fmt.Fprintf(out, "\x1b[%dA\x1b[J", drawn)
+				return len(changed), nil
+			}
+			i++
+		case keyLeft, keyUp, "k":
+			i = max(i-1, 0)
+		case "q", keyEscape, keyInterrupt:
+			fmt.Fprintf(out, "\x1b[%dA\x1b[J", drawn)
+			return len(changed), nil
+		}
+	}
+}
+
+// Returns the due date of `t` pushed back by snoozeDays from the later of its due date and
+// the start of the day of `now`
+func snoozeDue(t Task, now time.Time) string {
+	y, m, d := now.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	if due, err := parseTimestamp(t.Due); err == nil && due.After(from) {
+		from = due
+	}
+	return from.AddDate(0, 0, snoozeDays).Format(RFC3339)
+}
+
+// Returns `tags` changed by the space separated terms of `s`: -tag removes a tag, and +tag or
+// a bare tag adds it
+func editTags(tags []string, s string) []string {
+	tags = slices.Clone(tags)
+	for _, term := range strings.Fields(s) {
+		if tag, ok := strings.CutPrefix(term, "-"); ok {
+			tags = slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+		} else if tag := strings.TrimPrefix(term, "+"); tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
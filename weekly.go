@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/spf13/cobra"
+)
+
+// Tasks completed in the last week and due in the coming one, printed or emailed by
+// `report weekly`
+type WeeklyReport struct {
+	// The 7 days before today
+	Period Period
+	// Tasks completed in the period, in the order they were completed
+	Completed []TaskPosition
+	// Open tasks due in the 7 days from today, along with overdue tasks, soonest first
+	Due []TaskPosition
+}
+
+// When `daemon` emails the weekly report
+type WeeklySchedule struct {
+	Day    time.Weekday
+	Hour   int
+	Minute int
+}
+
+func newReportWeeklyCmd(mgr *connectionManager, out io.Writer) *cobra.Command {
+	wCmd := &cobra.Command{
+		Use:          "weekly -[e]",
+		Short:        tr("Print the tasks completed last week and the tasks due in the coming week"),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			r := weeklyReport(getTasks(mgr.db, TASKS_BUCKET), getTasks(mgr.db, ARCHIVE_BUCKET), now)
+			if !ReportEmail {
+				fmt.Fprintln(out, formatWeeklyReport(r))
+				return nil
+			}
+			if config.Email == nil {
+				return errors.New(tr("Set up the email section of config.json to email reports"))
+			}
+			if err := sendEmail(*config.Email, weeklyReportTitle(r), formatWeeklyReport(r), now); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, tr("Sent the weekly report to %s\n"), strings.Join(config.Email.To, ", "))
+			return nil
+		},
+	}
+	wCmd.Flags().BoolVarP(&ReportEmail, "email", "e", false, "Email the report with the SMTP server set up in config.json instead of printing it")
+	return wCmd
+}
+
+// Build the weekly report as of `now` from the `tasks` and `archive` buckets
+func weeklyReport(tasks, archive []TaskPosition, now time.Time) WeeklyReport {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	r := WeeklyReport{Period: Period{today.AddDate(0, 0, -7), today}}
+
+	for _, t := range append(slices.Clone(tasks), archive...) {
+		completed, err := parseTimestamp(t.task.Completed)
+		if err == nil && t.task.Status == STATUS.COMPLETE && r.Period.Contains(completed) {
+			r.Completed = append(r.Completed, t)
+		}
+	}
+	slices.SortStableFunc(r.Completed, func(a, b TaskPosition) int {
+		return compareTimestamps(a.task.Completed, b.task.Completed)
+	})
+
+	for _, t := range tasks {
+		if !isDone(t.task) && t.task.Due != "" && dueSection(t.task, now) <= 2 {
+			r.Due = append(r.Due, t)
+		}
+	}
+	slices.SortStableFunc(r.Due, func(a, b TaskPosition) int {
+		return compareTasks(a, b, "due")
+	})
+	return r
+}
+
+// Returns the title of `r`, which is also the subject of its email
+func weeklyReportTitle(r WeeklyReport) string {
+	// The end of a period is the first moment after it
+	end := r.Period.End.Add(-time.Nanosecond)
+	return fmt.Sprintf(tr("Weekly report for %s - %s"), formatDate(r.Period.Start), formatDate(end))
+}
+
+// Render `r` as plain text: its title, then the completed and the due tasks with their dates
+func formatWeeklyReport(r WeeklyReport) string {
+	section := func(heading string, tasks []TaskPosition, date func(Task) string) string {
+		lines := []string{fmt.Sprintf("%s (%d)", heading, len(tasks))}
+		for _, t := range tasks {
+			desc, _, _ := strings.Cut(t.task.Desc, "\n")
+			for _, tag := range t.task.Tags {
+				desc += " +" + tag
+			}
+			lines = append(lines, fmt.Sprintf("  %s  %s", date(t.task), desc))
+		}
+		if len(tasks) == 0 {
+			lines = append(lines, "  -")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	completed := section(tr("Completed last week"), r.Completed, func(t Task) string {
+		return formatTimestamp(t.Completed, "Mon") + " " + formatDateStamp(t.Completed, false)
+	})
+	due := section(tr("Due this week"), r.Due, func(t Task) string {
+		s := formatTimestamp(t.Due, "Mon") + " " + formatDateStamp(t.Due, false)
+		if isOverdue(t, r.Period.End) {
+			s += " " + tr("(overdue)")
+		}
+		return s
+	})
+	return weeklyReportTitle(r) + "\n\n" + completed + "\n\n" + due
+}
+
+// Parse a schedule such as "monday 08:00" or "mon 8:00", a weekday and a 24-hour time
+func parseWeeklySchedule(s string) (WeeklySchedule, error) {
+	invalid := fmt.Errorf(tr(`Invalid schedule "%s", must be a weekday and a time such as "monday 08:00"`), s)
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) != 2 {
+		return WeeklySchedule{}, invalid
+	}
+	day := -1
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if fields[0] == name || fields[0] == name[:3] {
+			day = int(d)
+		}
+	}
+	at, err := time.Parse("15:04", fields[1])
+	if day < 0 || err != nil {
+		return WeeklySchedule{}, invalid
+	}
+	return WeeklySchedule{time.Weekday(day), at.Hour(), at.Minute()}, nil
+}
+
+// Returns the last time the schedule came up, at or before `now`
+func (s WeeklySchedule) last(now time.Time) time.Time {
+	y, m, d := now.Date()
+	t := time.Date(y, m, d-(int(now.Weekday())-int(s.Day)+7)%7, s.Hour, s.Minute, 0, 0, now.Location())
+	if t.After(now) {
+		t = t.AddDate(0, 0, -7)
+	}
+	return t
+}
+
+// Email the weekly report of `db` if its scheduled time in the config file of `dir` came up
+// since it was last sent, printing a line to `out` when it's sent. The first check only records
+// the time, so setting up a schedule doesn't send a report right away
+func sendScheduledReport(db *bolt.DB, dir string, now time.Time, out io.Writer) error {
+	// The config file may have changed since the daemon started
+	c, err := loadConfig(dir)
+	if err != nil {
+		return err
+	}
+	if c.Email == nil || c.Email.Weekly == "" {
+		return nil
+	}
+	schedule, err := parseWeeklySchedule(c.Email.Weekly)
+	if err != nil {
+		return err
+	}
+	last, err := loadLastSent(dir)
+	if err != nil {
+		return err
+	}
+	if last.IsZero() {
+		return saveLastSent(dir, now)
+	}
+	if !last.Before(schedule.last(now)) {
+		return nil
+	}
+
+	r := weeklyReport(getTasks(db, TASKS_BUCKET), getTasks(db, ARCHIVE_BUCKET), now)
+	if err := sendEmail(*c.Email, weeklyReportTitle(r), formatWeeklyReport(r), now); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, tr("Sent the weekly report to %s\n"), strings.Join(c.Email.To, ", "))
+	return saveLastSent(dir, now)
+}
+
+// Check the weekly report schedule every minute until `done` is closed, for `daemon`. Errors
+// are printed to `errOut` and checked again on the next minute
+func scheduleReports(mgr *connectionManager, dir string, out, errOut io.Writer, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		daemonMu.Lock()
+		err := sendScheduledReport(mgr.db, dir, time.Now(), out)
+		daemonMu.Unlock()
+		if err != nil {
+			fmt.Fprintln(errOut, "Error:", err)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Returns the path of the file recording when the weekly report was last emailed
+func lastSentPath(dir string) string {
+	return filepath.Join(dir, "weekly_report.json")
+}
+
+// Read when the weekly report was last emailed from `dir`. Zero if it never was
+func loadLastSent(dir string) (time.Time, error) {
+	var last time.Time
+	buf, err := os.ReadFile(lastSentPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return last, nil
+	}
+	if err != nil {
+		return last, err
+	}
+	if err := json.Unmarshal(buf, &last); err != nil {
+		return last, fmt.Errorf(tr("Invalid %s: %v"), lastSentPath(dir), err)
+	}
+	return last, nil
+}
+
+// Record in `dir` that the weekly report was emailed at `t`
+func saveLastSent(dir string, t time.Time) error {
+	buf, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(lastSentPath(dir), buf, 0600)
+}
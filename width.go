@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// Measures text as most terminals do: characters of ambiguous width take one column whatever
+// the locale, so alignment doesn't depend on $LANG
+var widthCondition = &runewidth.Condition{StrictEmojiNeutral: true}
+
+// Returns the number of columns `s` occupies in a terminal. Each grapheme cluster takes the
+// width of its first visible character, and an emoji variation selector widens the narrow
+// symbol it follows, e.g. "⚠️"
+func textWidth(s string) int {
+	width := 0
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		w := widthCondition.StringWidth(g.Str())
+		if w == 1 && strings.ContainsRune(g.Str(), 0xFE0F) {
+			w = 2
+		}
+		width += w
+	}
+	return width
+}
+
+// Pad `s` with spaces on the right until it occupies `width` columns
+func padRight(s string, width int) string {
+	for w := textWidth(s); w < width; w++ {
+		s += " "
+	}
+	return s
+}
+
+// Cut `s` to fit `width` columns, ending it with "…" if anything was cut. Characters made of
+// several code points, such as emoji, are never split
+func truncateText(s string, width int) string {
+	if textWidth(s) <= width {
+		return s
+	}
+	const ellipsis = "…"
+	w := textWidth(ellipsis)
+	if w > width {
+		return ""
+	}
+	var cut strings.Builder
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		if w += textWidth(g.Str()); w > width {
+			break
+		}
+		cut.WriteString(g.Str())
+	}
+	return cut.String() + ellipsis
+}